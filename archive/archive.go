@@ -0,0 +1,228 @@
+// Package archive reads members of zip & tar archives, for dataset bodies
+// that are published as one file within an archive
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+// Format represents a type of archive
+type Format string
+
+// String implements the stringer interface
+func (f Format) String() string {
+	return string(f)
+}
+
+const (
+	// FmtNone is a sentinel for no archive
+	FmtNone Format = ""
+	// FmtZip zip archive
+	FmtZip Format = "zip"
+	// FmtTar tape archive
+	FmtTar Format = "tar"
+	// FmtTarGzip gzip-compressed tape archive
+	FmtTarGzip Format = "tar.gz"
+)
+
+// SupportedFormats indexes supported formats in a map for lookups
+var SupportedFormats = map[Format]struct{}{
+	FmtZip:     {},
+	FmtTar:     {},
+	FmtTarGzip: {},
+}
+
+// ParseFormat interprets a string into a supported archive format
+// errors when provided the empty string ("no archive" format)
+func ParseFormat(s string) (Format, error) {
+	f := Format(s)
+	if _, ok := SupportedFormats[f]; !ok {
+		return FmtNone, fmt.Errorf("unsupported archive format: %q", s)
+	}
+	return f, nil
+}
+
+// FormatFromFilename gives the archive format of a filename by examining
+// it's extension, returning FmtNone if the filename isn't an archive
+func FormatFromFilename(filename string) Format {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FmtZip
+	case strings.HasSuffix(name, ".tar"):
+		return FmtTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FmtTarGzip
+	}
+	return FmtNone
+}
+
+// Member describes a file within an archive
+type Member struct {
+	// Name is the path of the file within the archive
+	Name string `json:"name"`
+	// Size is the uncompressed size of the file in bytes
+	Size int64 `json:"size"`
+}
+
+// Members lists the regular files in an archive. zip archives require random
+// access, so readers that aren't an io.ReaderAt are spooled to a temporary
+// file
+func Members(f Format, r io.Reader) ([]Member, error) {
+	members := []Member{}
+	switch f {
+	case FmtZip:
+		zr, close, err := openZip(r)
+		if err != nil {
+			return nil, err
+		}
+		defer close()
+		for _, zf := range zr.File {
+			if !zf.FileInfo().Mode().IsRegular() {
+				continue
+			}
+			members = append(members, Member{Name: path.Clean(zf.Name), Size: int64(zf.UncompressedSize64)})
+		}
+		return members, nil
+	case FmtTar, FmtTarGzip:
+		tr, close, err := openTar(f, r)
+		if err != nil {
+			return nil, err
+		}
+		defer close()
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return members, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("reading tar archive: %w", err)
+			}
+			if hdr.FileInfo().Mode().IsRegular() {
+				members = append(members, Member{Name: path.Clean(hdr.Name), Size: hdr.Size})
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported archive format: %q", f)
+}
+
+// Open gives a reader of the named member of an archive. Callers must close
+// the returned reader
+func Open(f Format, r io.Reader, name string) (io.ReadCloser, error) {
+	switch f {
+	case FmtZip:
+		zr, close, err := openZip(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			if path.Clean(zf.Name) == path.Clean(name) && zf.FileInfo().Mode().IsRegular() {
+				rc, err := zf.Open()
+				if err != nil {
+					close()
+					return nil, err
+				}
+				return readCloser{rc, func() error {
+					rc.Close()
+					return close()
+				}}, nil
+			}
+		}
+		close()
+	case FmtTar, FmtTarGzip:
+		tr, close, err := openTar(f, r)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				close()
+				return nil, fmt.Errorf("reading tar archive: %w", err)
+			}
+			if path.Clean(hdr.Name) == path.Clean(name) && hdr.FileInfo().Mode().IsRegular() {
+				return readCloser{tr, close}, nil
+			}
+		}
+		close()
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %q", f)
+	}
+	return nil, fmt.Errorf("archive member %q not found", name)
+}
+
+// readCloser pairs a reader with a close function
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (rc readCloser) Close() error {
+	return rc.close()
+}
+
+func openZip(r io.Reader) (*zip.Reader, func() error, error) {
+	ra, size, close, err := ReaderAt(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		close()
+		return nil, nil, fmt.Errorf("opening zip archive: %w", err)
+	}
+	return zr, close, nil
+}
+
+func openTar(f Format, r io.Reader) (*tar.Reader, func() error, error) {
+	if f == FmtTarGzip {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening tar archive: %w", err)
+		}
+		return tar.NewReader(gr), gr.Close, nil
+	}
+	return tar.NewReader(r), func() error { return nil }, nil
+}
+
+// ReaderAt coerces a reader into an io.ReaderAt for formats that need random
+// access like zip, giving its size. Readers that don't support random access
+// are spooled to a temporary file instead of memory. Callers must call the
+// returned close function to remove any temporary file
+func ReaderAt(r io.Reader) (io.ReaderAt, int64, func() error, error) {
+	if rs, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := rs.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return rs, size, func() error { return nil }, nil
+	}
+
+	f, err := ioutil.TempFile("", "archive-")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() error {
+		f.Close()
+		return os.Remove(f.Name())
+	}
+	size, err := io.Copy(f, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return f, size, cleanup, nil
+}
@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/klauspost/compress/gzip"
+)
+
+type testFile struct {
+	name, data string
+}
+
+var testFiles = []testFile{
+	{"README.md", "# Readme"},
+	{"data/body.csv", "a,b\n1,2\n"},
+}
+
+func zipArchive(t *testing.T, files []testFile) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarArchive(t *testing.T, files []testFile, compress bool) []byte {
+	buf := &bytes.Buffer{}
+	var gw *gzip.Writer
+	tw := tar.NewWriter(buf)
+	if compress {
+		gw = gzip.NewWriter(buf)
+		tw = tar.NewWriter(gw)
+	}
+	tw.WriteHeader(&tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Size: int64(len(f.data)), Mode: 0644, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.data))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gw != nil {
+		gw.Close()
+	}
+	return buf.Bytes()
+}
+
+func TestArchives(t *testing.T) {
+	cases := []struct {
+		f    Format
+		data []byte
+	}{
+		{FmtZip, zipArchive(t, testFiles)},
+		{FmtTar, tarArchive(t, testFiles, false)},
+		{FmtTarGzip, tarArchive(t, testFiles, true)},
+	}
+
+	expect := []Member{
+		{Name: "README.md", Size: 8},
+		{Name: "data/body.csv", Size: 8},
+	}
+
+	for _, c := range cases {
+		t.Run(c.f.String(), func(t *testing.T) {
+			// wrap in a buffer to exercise spooling for formats that need random access
+			members, err := Members(c.f, bytes.NewBuffer(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, members); diff != "" {
+				t.Errorf("members mismatch (-want +got):\n%s", diff)
+			}
+
+			rc, err := Open(c.f, bytes.NewReader(c.data), "data/body.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rc.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "a,b\n1,2\n" {
+				t.Errorf("member contents mismatch. got: %q", string(got))
+			}
+
+			if _, err := Open(c.f, bytes.NewReader(c.data), "missing.csv"); err == nil || err.Error() != `archive member "missing.csv" not found` {
+				t.Errorf("expected missing member error, got: %v", err)
+			}
+			if _, err := Open(c.f, bytes.NewReader(c.data), "data"); err == nil {
+				t.Error("expected opening a directory to error")
+			}
+		})
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	cases := []struct {
+		filename string
+		expect   Format
+	}{
+		{"data.zip", FmtZip},
+		{"DATA.ZIP", FmtZip},
+		{"data.tar", FmtTar},
+		{"data.tar.gz", FmtTarGzip},
+		{"data.tgz", FmtTarGzip},
+		{"data.csv.gz", FmtNone},
+		{"data.csv", FmtNone},
+	}
+
+	for _, c := range cases {
+		if got := FormatFromFilename(c.filename); got != c.expect {
+			t.Errorf("%s format mismatch. expected: %q, got: %q", c.filename, c.expect, got)
+		}
+	}
+}
+
+func TestArchiveErrors(t *testing.T) {
+	if _, err := ParseFormat("rar"); err == nil || err.Error() != `unsupported archive format: "rar"` {
+		t.Errorf("expected unsupported format error, got: %v", err)
+	}
+	if _, err := Members(FmtZip, bytes.NewReader([]byte("not a zip"))); err == nil {
+		t.Error("expected invalid zip to error")
+	}
+	if _, err := Members(FmtTarGzip, bytes.NewReader([]byte("not a tar"))); err == nil {
+		t.Error("expected invalid tar.gz to error")
+	}
+}
@@ -3,11 +3,19 @@
 package compression
 
 import (
+	"bufio"
+	"compress/bzip2"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
 
+	"github.com/andybalholm/brotli"
+	"github.com/golang/snappy"
 	"github.com/klauspost/compress/gzip"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
 )
 
 // Format represents a type of byte compression
@@ -25,12 +33,38 @@ const (
 	FmtZStandard Format = "zst"
 	// FmtGZip GNU zip compression https://www.gnu.org/software/gzip/
 	FmtGZip Format = "gzip"
+	// FmtXZ xz compression https://tukaani.org/xz/
+	FmtXZ Format = "xz"
+	// FmtBZip2 bzip2 compression https://sourceware.org/bzip2/, only
+	// decompression is supported
+	FmtBZip2 Format = "bzip2"
+	// FmtLZ4 LZ4 frame compression https://lz4.github.io/lz4/
+	FmtLZ4 Format = "lz4"
+	// FmtSnappy snappy framing format compression https://google.github.io/snappy/
+	FmtSnappy Format = "snappy"
+	// FmtBrotli brotli compression https://github.com/google/brotli
+	FmtBrotli Format = "brotli"
 )
 
 // SupportedFormats indexes supported formats in a map for lookups
 var SupportedFormats = map[Format]struct{}{
 	FmtZStandard: {},
 	FmtGZip:      {},
+	FmtXZ:        {},
+	FmtBZip2:     {},
+	FmtLZ4:       {},
+	FmtSnappy:    {},
+	FmtBrotli:    {},
+}
+
+// extensions maps common file extensions to formats, for extensions that
+// aren't the format name
+var extensions = map[string]Format{
+	"gz":   FmtGZip,
+	"zstd": FmtZStandard,
+	"bz2":  FmtBZip2,
+	"sz":   FmtSnappy,
+	"br":   FmtBrotli,
 }
 
 // ParseFormat interprets a string into a supported compression format
@@ -43,27 +77,121 @@ func ParseFormat(s string) (f Format, err error) {
 	return f, nil
 }
 
+// ParseExtension interprets a file extension into a supported compression
+// format. Extensions are case-insensitive, and may have a leading "."
+func ParseExtension(ext string) (Format, error) {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if f, ok := extensions[ext]; ok {
+		return f, nil
+	}
+	return ParseFormat(ext)
+}
+
+// Concatenable reports if compressed streams of a format can be joined
+// end-to-end and decompressed as a single stream. Joined seekable zstd
+// streams decompress, but each has it's own seek table, and can't be seeked
+func Concatenable(f Format) bool {
+	switch f {
+	case FmtZStandard, FmtGZip, FmtXZ, FmtBZip2, FmtLZ4, FmtSnappy:
+		return true
+	}
+	return false
+}
+
 // Compressor wraps a given writer with a specified comrpession format
 // callers must Close the writer to fully flush the compressor
 func Compressor(compressionFormat string, w io.Writer) (io.WriteCloser, error) {
+	return CompressorWithOptions(compressionFormat, w, nil)
+}
+
+// CompressorWithOptions wraps a writer with a compressor configured by opts.
+// nil options use default settings. callers must Close the writer to fully
+// flush the compressor
+func CompressorWithOptions(compressionFormat string, w io.Writer, opts *Options) (io.WriteCloser, error) {
 	f, err := ParseFormat(compressionFormat)
 	if err != nil {
 		return nil, err
 	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := opts.check(f); err != nil {
+		return nil, err
+	}
 
 	switch f {
 	case FmtZStandard:
-		return zstd.NewWriter(w)
+		if opts.FrameSize != 0 {
+			return NewSeekableWriter(w, opts)
+		}
+		if opts.parallel() {
+			return newParallelZstdWriter(w, opts)
+		}
+		return zstd.NewWriter(w, zstdEncoderOptions(opts)...)
 	case FmtGZip:
-		return gzip.NewWriter(w), nil
+		if opts.parallel() {
+			return newParallelGzipWriter(w, opts)
+		}
+		level := gzip.DefaultCompression
+		if opts.Level != 0 {
+			level = opts.Level
+		}
+		gw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, err
+		}
+		gw.Name = opts.Name
+		gw.Comment = opts.Comment
+		gw.ModTime = opts.ModTime
+		return gw, nil
+	case FmtXZ:
+		return xz.NewWriter(w)
+	case FmtLZ4:
+		lw := lz4.NewWriter(w)
+		if opts.Level != 0 {
+			if opts.Level < 1 || opts.Level > 9 {
+				return nil, fmt.Errorf("invalid lz4 compression level: %d", opts.Level)
+			}
+			if err := lw.Apply(lz4.CompressionLevelOption(lz4.CompressionLevel(1 << (8 + opts.Level)))); err != nil {
+				return nil, err
+			}
+		}
+		return lw, nil
+	case FmtSnappy:
+		return snappy.NewBufferedWriter(w), nil
+	case FmtBrotli:
+		level := brotli.DefaultCompression
+		if opts.Level != 0 {
+			level = opts.Level
+		}
+		return brotli.NewWriterLevel(w, level), nil
 	}
 
 	return nil, fmt.Errorf("no available compressor for %q format", f)
 }
 
+// zstdEncoderOptions converts options to zstd encoder settings
+func zstdEncoderOptions(opts *Options) []zstd.EOption {
+	zopts := []zstd.EOption{}
+	if opts.Level != 0 {
+		zopts = append(zopts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)))
+	}
+	if len(opts.Dictionary) > 0 {
+		zopts = append(zopts, zstd.WithEncoderDict(opts.Dictionary))
+	}
+	return zopts
+}
+
 // Decompressor wraps a reader of compressed data with a decompressor
 // callers must .Close() the reader
 func Decompressor(compressionFormat string, r io.Reader) (io.ReadCloser, error) {
+	return DecompressorWithOptions(compressionFormat, r, nil)
+}
+
+// DecompressorWithOptions wraps a reader of compressed data with a
+// decompressor configured by opts. Only zstd dictionaries affect
+// decompression, other options are ignored. callers must .Close() the reader
+func DecompressorWithOptions(compressionFormat string, r io.Reader, opts *Options) (io.ReadCloser, error) {
 	f, err := ParseFormat(compressionFormat)
 	if err != nil {
 		return nil, err
@@ -71,13 +199,32 @@ func Decompressor(compressionFormat string, r io.Reader) (io.ReadCloser, error)
 
 	switch f {
 	case FmtZStandard:
-		rdr, err := zstd.NewReader(r)
+		zopts := []zstd.DOption{}
+		if opts != nil && len(opts.Dictionary) > 0 {
+			zopts = append(zopts, zstd.WithDecoderDicts(opts.Dictionary))
+		}
+		rdr, err := zstd.NewReader(r, zopts...)
 		if err != nil {
 			return nil, err
 		}
 		return zstdReadCloserShim{rdr}, nil
 	case FmtGZip:
 		return gzip.NewReader(r)
+	case FmtXZ:
+		rdr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(rdr), nil
+	case FmtBZip2:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case FmtLZ4:
+		br := bufio.NewReader(r)
+		return ioutil.NopCloser(&lz4MultiFrameReader{br: br, zr: lz4.NewReader(br)}), nil
+	case FmtSnappy:
+		return ioutil.NopCloser(snappy.NewReader(r)), nil
+	case FmtBrotli:
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
 	}
 
 	return nil, fmt.Errorf("no available decompressor for %q format", f)
@@ -94,3 +241,26 @@ func (d zstdReadCloserShim) Close() error {
 	d.Decoder.Close()
 	return nil
 }
+
+// lz4MultiFrameReader reads concatenated LZ4 frames, which the lz4 reader
+// stops reading after the first of
+type lz4MultiFrameReader struct {
+	br *bufio.Reader
+	zr *lz4.Reader
+}
+
+func (r *lz4MultiFrameReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.zr.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		if _, err := r.br.Peek(1); err != nil {
+			return n, io.EOF
+		}
+		r.zr.Reset(r.br)
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
@@ -35,6 +35,12 @@ func TestCompressionCycle(t *testing.T) {
 
 			buf := &bytes.Buffer{}
 			comp, err := Compressor(f.String(), buf)
+			if f == FmtBZip2 {
+				if err == nil {
+					t.Error("expected bzip2 compressor to error")
+				}
+				t.Skip("bzip2 is decompress-only")
+			}
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -74,3 +80,53 @@ func TestCompressionCycle(t *testing.T) {
 	}
 
 }
+
+func TestBZip2Decompressor(t *testing.T) {
+	data := []byte("\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x55\x5a\x44\xf7\x00\x00\x02\x19\x80\x40\x00\x10\x00\x12\x64\xc0\x10\x20\x00\x22\x00\x69\xea\x10\x03\x05\xd3\xb6\x21\x83\xc5\xdc\x91\x4e\x14\x24\x15\x56\x91\x3d\xc0")
+	decomp, err := Decompressor(FmtBZip2.String(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decomp.Close()
+
+	result := &bytes.Buffer{}
+	if _, err := io.Copy(result, decomp); err != nil {
+		t.Fatal(err)
+	}
+	if result.String() != "hello bzip2" {
+		t.Errorf("result mismatch. want: %q got: %q", "hello bzip2", result.String())
+	}
+}
+
+func TestParseExtension(t *testing.T) {
+	cases := []struct {
+		ext    string
+		expect Format
+		err    string
+	}{
+		{".zst", FmtZStandard, ""},
+		{"zstd", FmtZStandard, ""},
+		{".gzip", FmtGZip, ""},
+		{".gz", FmtGZip, ""},
+		{".xz", FmtXZ, ""},
+		{".bz2", FmtBZip2, ""},
+		{".bzip2", FmtBZip2, ""},
+		{".lz4", FmtLZ4, ""},
+		{".sz", FmtSnappy, ""},
+		{".snappy", FmtSnappy, ""},
+		{".br", FmtBrotli, ""},
+		{".GZ", FmtGZip, ""},
+		{".csv", FmtNone, `unsupported compression format: "csv"`},
+	}
+
+	for _, c := range cases {
+		got, err := ParseExtension(c.ext)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%s error mismatch. expected: %q, got: %v", c.ext, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("%s format mismatch. expected: %q, got: %q", c.ext, c.expect, got)
+		}
+	}
+}
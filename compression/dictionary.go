@@ -0,0 +1,91 @@
+package compression
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultDictionarySize is the maximum size of trained dictionaries when no
+// size is given, matching the zstd command line tool
+const DefaultDictionarySize = 112640
+
+// minDictionarySamples is the fewest samples a dictionary is trained from.
+// The zstd dictionary builder derives statistics from at least 512 repeated
+// sequences, each sample that repeats dictionary content gives one or more
+const minDictionarySamples = 512
+
+// TrainDictionary builds a zstd dictionary from samples of the data it will
+// compress, like individual entries of a dataset body. size caps the
+// dictionary content size, zero or less uses DefaultDictionarySize.
+// Dictionary content favours samples that repeat, with the most repeated
+// samples placed last where they're cheapest to reference. Dictionary IDs are
+// derived from dictionary content. At least 512 non-empty samples are
+// required
+func TrainDictionary(samples [][]byte, size int) ([]byte, error) {
+	if size <= 0 {
+		size = DefaultDictionarySize
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("at least one sample is required to train a dictionary")
+	}
+
+	counts := map[string]int{}
+	order := []string{}
+	nonEmpty := 0
+	for _, s := range samples {
+		if len(s) == 0 {
+			continue
+		}
+		nonEmpty++
+		if counts[string(s)] == 0 {
+			order = append(order, string(s))
+		}
+		counts[string(s)]++
+	}
+
+	// stable sort by ascending count, keeping first-seen order within a count
+	// so the most repeated samples end up at the end of the content
+	sorted := append([]string{}, order...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return counts[sorted[i]] < counts[sorted[j]]
+	})
+
+	start, total := len(sorted), 0
+	for start > 0 && total < size {
+		start--
+		total += len(sorted[start])
+	}
+	history := []byte{}
+	for _, s := range sorted[start:] {
+		history = append(history, s...)
+	}
+	if len(history) > size {
+		history = history[len(history)-size:]
+	}
+	if nonEmpty < minDictionarySamples {
+		return nil, fmt.Errorf("at least %d non-empty samples are required to train a dictionary, got %d", minDictionarySamples, nonEmpty)
+	}
+	if len(history) < 8 {
+		return nil, fmt.Errorf("samples are too small to train a dictionary")
+	}
+
+	return zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       dictionaryID(history),
+		Contents: samples,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+		Level:    zstd.SpeedDefault,
+	})
+}
+
+// dictionaryID derives a dictionary ID from content, avoiding the ranges the
+// zstd format reserves for registered dictionaries
+func dictionaryID(content []byte) uint32 {
+	sum := sha256.Sum256(content)
+	const low, high = 1 << 15, 1 << 31
+	return low + binary.LittleEndian.Uint32(sum[:4])%(high-low)
+}
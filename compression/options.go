@@ -0,0 +1,202 @@
+package compression
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Options configures compressors & decompressors beyond their default
+// settings. Datasets record options in the structure compressionConfig field
+type Options struct {
+	// Level sets compression level on the scale of the format. gzip levels are
+	// -2 to 9, zstd 1 to 22, lz4 1 to 9 and brotli 0 to 11. zero uses the
+	// default level. Levels aren't supported for xz & snappy
+	Level int
+	// Dictionary is a zstd dictionary used to compress & decompress data.
+	// Dictionaries must be in the zstd dictionary format, see TrainDictionary
+	Dictionary []byte
+	// FrameSize switches zstd compression to the seekable format, splitting
+	// data into independent frames of FrameSize uncompressed bytes indexed by
+	// a seek table. see NewSeekableWriter & NewSeekableReader
+	FrameSize int
+	// Workers sets the number of blocks gzip & zstd compress concurrently.
+	// Setting Workers or BlockSize switches to block-parallel compression,
+	// zero workers uses one per CPU. Parallel output depends on BlockSize &
+	// the other options, never on the number of workers
+	Workers int
+	// BlockSize is the number of uncompressed bytes in each block of parallel
+	// compression, defaulting to DefaultBlockSize. gzip blocks must be larger
+	// than 16KB. zstd blocks are independent frames, larger blocks compress
+	// better
+	BlockSize int
+	// Name is the gzip header file name
+	Name string
+	// Comment is the gzip header comment
+	Comment string
+	// ModTime is the gzip header modification time
+	ModTime time.Time
+}
+
+// NewOptions creates an Options pointer from a map. dictionaries are base64
+// encoded strings and modification times are RFC3339 strings
+func NewOptions(opts map[string]interface{}) (*Options, error) {
+	o := &Options{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["level"] != nil {
+		level, err := intOption(opts["level"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid level value: %v", opts["level"])
+		}
+		o.Level = level
+	}
+
+	for key, dst := range map[string]*int{
+		"frameSize": &o.FrameSize,
+		"workers":   &o.Workers,
+		"blockSize": &o.BlockSize,
+	} {
+		if opts[key] == nil {
+			continue
+		}
+		v, err := intOption(opts[key])
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid %s value: %v", key, opts[key])
+		}
+		*dst = v
+	}
+	// seek tables record frame sizes as 32 bit integers
+	if int64(o.FrameSize) > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid frameSize value: %v", opts["frameSize"])
+	}
+
+	if opts["dictionary"] != nil {
+		str, ok := opts["dictionary"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid dictionary value: %v", opts["dictionary"])
+		}
+		dict, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dictionary value: %w", err)
+		}
+		o.Dictionary = dict
+	}
+
+	if opts["name"] != nil {
+		if name, ok := opts["name"].(string); ok {
+			o.Name = name
+		} else {
+			return nil, fmt.Errorf("invalid name value: %v", opts["name"])
+		}
+	}
+
+	if opts["comment"] != nil {
+		if comment, ok := opts["comment"].(string); ok {
+			o.Comment = comment
+		} else {
+			return nil, fmt.Errorf("invalid comment value: %v", opts["comment"])
+		}
+	}
+
+	if opts["modTime"] != nil {
+		str, ok := opts["modTime"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid modTime value: %v", opts["modTime"])
+		}
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modTime value: %w", err)
+		}
+		o.ModTime = t
+	}
+
+	return o, nil
+}
+
+// Map structures Options as a map of string keys to values
+func (o *Options) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.Level != 0 {
+		opt["level"] = o.Level
+	}
+	if len(o.Dictionary) > 0 {
+		opt["dictionary"] = base64.StdEncoding.EncodeToString(o.Dictionary)
+	}
+	if o.FrameSize != 0 {
+		opt["frameSize"] = o.FrameSize
+	}
+	if o.Workers != 0 {
+		opt["workers"] = o.Workers
+	}
+	if o.BlockSize != 0 {
+		opt["blockSize"] = o.BlockSize
+	}
+	if o.Name != "" {
+		opt["name"] = o.Name
+	}
+	if o.Comment != "" {
+		opt["comment"] = o.Comment
+	}
+	if !o.ModTime.IsZero() {
+		opt["modTime"] = o.ModTime.Format(time.RFC3339)
+	}
+	return opt
+}
+
+// intOption reads an integer from a decoded options value. numbers decoded
+// from JSON are float64 values
+func intOption(v interface{}) (int, error) {
+	switch x := v.(type) {
+	case int:
+		return x, nil
+	case int64:
+		return int(x), nil
+	case float64:
+		if x == float64(int(x)) {
+			return int(x), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid integer value: %v", v)
+}
+
+// hasGzipHeader reports if any gzip header fields are set
+func (o *Options) hasGzipHeader() bool {
+	return o.Name != "" || o.Comment != "" || !o.ModTime.IsZero()
+}
+
+// check confirms options are supported by a format
+func (o *Options) check(f Format) error {
+	if o.Level != 0 {
+		switch f {
+		case FmtGZip, FmtZStandard, FmtLZ4, FmtBrotli:
+		default:
+			return fmt.Errorf("compression level isn't supported for %q format", f)
+		}
+	}
+	if len(o.Dictionary) > 0 && f != FmtZStandard {
+		return fmt.Errorf("dictionaries aren't supported for %q format", f)
+	}
+	if o.FrameSize != 0 && f != FmtZStandard {
+		return fmt.Errorf("frame size isn't supported for %q format", f)
+	}
+	if o.FrameSize < 0 || int64(o.FrameSize) > math.MaxUint32 {
+		return fmt.Errorf("invalid frame size: %d", o.FrameSize)
+	}
+	if o.parallel() && f != FmtGZip && f != FmtZStandard {
+		return fmt.Errorf("parallel compression isn't supported for %q format", f)
+	}
+	if o.BlockSize != 0 && o.FrameSize != 0 {
+		return fmt.Errorf("blockSize and frameSize can't both be set, seekable frames are frameSize bytes")
+	}
+	if o.hasGzipHeader() && f != FmtGZip {
+		return fmt.Errorf("header metadata isn't supported for %q format", f)
+	}
+	return nil
+}
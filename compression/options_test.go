@@ -0,0 +1,219 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/klauspost/compress/gzip"
+)
+
+func TestNewOptions(t *testing.T) {
+	modTime := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		opts   map[string]interface{}
+		expect *Options
+		err    string
+	}{
+		{nil, &Options{}, ""},
+		{map[string]interface{}{}, &Options{}, ""},
+		{map[string]interface{}{"level": float64(9)}, &Options{Level: 9}, ""},
+		{map[string]interface{}{"level": 3}, &Options{Level: 3}, ""},
+		{map[string]interface{}{"dictionary": "AQID"}, &Options{Dictionary: []byte{1, 2, 3}}, ""},
+		{map[string]interface{}{"name": "body.csv", "comment": "daily", "modTime": "2021-06-01T12:00:00Z"}, &Options{Name: "body.csv", Comment: "daily", ModTime: modTime}, ""},
+
+		{map[string]interface{}{"frameSize": float64(4096)}, &Options{FrameSize: 4096}, ""},
+
+		{map[string]interface{}{"workers": float64(4), "blockSize": 65536}, &Options{Workers: 4, BlockSize: 65536}, ""},
+		{map[string]interface{}{"workers": -2}, nil, "invalid workers value: -2"},
+		{map[string]interface{}{"blockSize": "big"}, nil, "invalid blockSize value: big"},
+		{map[string]interface{}{"frameSize": -1}, nil, "invalid frameSize value: -1"},
+		{map[string]interface{}{"frameSize": float64(1 << 32)}, nil, "invalid frameSize value: 4.294967296e+09"},
+		{map[string]interface{}{"level": 1.5}, nil, "invalid level value: 1.5"},
+		{map[string]interface{}{"level": "high"}, nil, "invalid level value: high"},
+		{map[string]interface{}{"dictionary": false}, nil, "invalid dictionary value: false"},
+		{map[string]interface{}{"name": 1}, nil, "invalid name value: 1"},
+		{map[string]interface{}{"comment": 1}, nil, "invalid comment value: 1"},
+		{map[string]interface{}{"modTime": 1}, nil, "invalid modTime value: 1"},
+	}
+
+	for i, c := range cases {
+		got, err := NewOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestOptionsMapRoundTrip(t *testing.T) {
+	opts := &Options{
+		Level:      5,
+		Dictionary: []byte("dictionary"),
+		Name:       "body.json",
+		Comment:    "hello",
+		Workers:    2,
+		BlockSize:  65536,
+		ModTime:    time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	got, err := NewOptions(opts.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(opts, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	var nilOpts *Options
+	if nilOpts.Map() != nil {
+		t.Error("expected nil options to map to nil")
+	}
+}
+
+func TestCompressorWithOptionsErrors(t *testing.T) {
+	cases := []struct {
+		f    Format
+		opts *Options
+		err  string
+	}{
+		{FmtSnappy, &Options{Level: 2}, `compression level isn't supported for "snappy" format`},
+		{FmtGZip, &Options{Dictionary: []byte("dict")}, `dictionaries aren't supported for "gzip" format`},
+		{FmtZStandard, &Options{Name: "body.csv"}, `header metadata isn't supported for "zst" format`},
+		{FmtGZip, &Options{FrameSize: 1024}, `frame size isn't supported for "gzip" format`},
+		{FmtZStandard, &Options{FrameSize: 1 << 32}, "invalid frame size: 4294967296"},
+		{FmtLZ4, &Options{Level: 12}, "invalid lz4 compression level: 12"},
+		{FmtGZip, &Options{Level: 12}, "gzip: invalid compression level: 12"},
+	}
+
+	for i, c := range cases {
+		_, err := CompressorWithOptions(c.f.String(), &bytes.Buffer{}, c.opts)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+		}
+	}
+}
+
+func TestCompressorWithOptionsLevels(t *testing.T) {
+	plainText := bytes.Repeat([]byte("levels should change compressed output. "), 200)
+	cases := []struct {
+		f    Format
+		low  int
+		high int
+	}{
+		{FmtGZip, 1, 9},
+		{FmtZStandard, 1, 19},
+		{FmtLZ4, 1, 9},
+		{FmtBrotli, 1, 11},
+	}
+
+	for _, c := range cases {
+		t.Run(c.f.String(), func(t *testing.T) {
+			sizes := []int{}
+			for _, level := range []int{c.low, c.high} {
+				buf := &bytes.Buffer{}
+				w, err := CompressorWithOptions(c.f.String(), buf, &Options{Level: level})
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write(plainText)
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+				sizes = append(sizes, buf.Len())
+
+				r, err := Decompressor(c.f.String(), buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := ioutil.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(plainText, got) {
+					t.Errorf("level %d round trip mismatch", level)
+				}
+			}
+			if sizes[1] > sizes[0] {
+				t.Errorf("expected level %d to compress at least as well as level %d. sizes: %v", c.high, c.low, sizes)
+			}
+		})
+	}
+}
+
+func TestGzipHeaderOptions(t *testing.T) {
+	opts := &Options{Name: "body.csv", Comment: "daily append", ModTime: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)}
+	buf := &bytes.Buffer{}
+	w, err := CompressorWithOptions(FmtGZip.String(), buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("a,b,c\n"))
+	w.Close()
+
+	r, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != opts.Name || r.Comment != opts.Comment || !r.ModTime.Equal(opts.ModTime) {
+		t.Errorf("header mismatch. expected: %q %q %s, got: %q %q %s", opts.Name, opts.Comment, opts.ModTime, r.Name, r.Comment, r.ModTime)
+	}
+}
+
+func TestTrainDictionary(t *testing.T) {
+	samples := [][]byte{}
+	for i := 0; i < 600; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(`{"id":%d,"city":"Toronto","country":"Canada","population":%d}`+"\n", i, i*1000)))
+	}
+
+	dict, err := TrainDictionary(samples, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &Options{Dictionary: dict}
+	sample := []byte(`{"id":1000,"city":"Toronto","country":"Canada","population":1000000}` + "\n")
+	withDict, without := &bytes.Buffer{}, &bytes.Buffer{}
+	for _, buf := range []*bytes.Buffer{withDict, without} {
+		o := opts
+		if buf == without {
+			o = nil
+		}
+		w, err := CompressorWithOptions(FmtZStandard.String(), buf, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(sample)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if withDict.Len() >= without.Len() {
+		t.Errorf("expected dictionary to improve compression. with: %d, without: %d", withDict.Len(), without.Len())
+	}
+
+	r, err := DecompressorWithOptions(FmtZStandard.String(), withDict, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sample, got) {
+		t.Errorf("round trip mismatch. expected: %q, got: %q", sample, got)
+	}
+
+	if _, err := TrainDictionary(nil, 0); err == nil {
+		t.Error("expected training without samples to error")
+	}
+	if _, err := TrainDictionary(samples[:10], 0); err == nil {
+		t.Error("expected training with too few samples to error")
+	}
+}
@@ -0,0 +1,198 @@
+package compression
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+)
+
+// DefaultBlockSize is the number of uncompressed bytes in each block of
+// parallel compression when options don't set a block size
+const DefaultBlockSize = 1 << 20
+
+// parallel reports if options configure block-parallel compression
+func (o *Options) parallel() bool {
+	return o.Workers != 0 || o.BlockSize != 0
+}
+
+// workers gives the number of concurrent compressors, defaulting to one per
+// CPU
+func (o *Options) workers() int {
+	if o.Workers == 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+// blockSize gives the number of uncompressed bytes compressed by each worker
+func (o *Options) blockSize() int {
+	if o.BlockSize == 0 {
+		return DefaultBlockSize
+	}
+	return o.BlockSize
+}
+
+// newParallelGzipWriter creates a gzip writer that compresses blocks
+// concurrently. Each block is compressed with the tail of the previous block
+// as a dictionary & ended with a sync flush, producing a single standard gzip
+// member. Output depends on block size & level, not the number of workers
+func newParallelGzipWriter(w io.Writer, opts *Options) (io.WriteCloser, error) {
+	level := pgzip.DefaultCompression
+	if opts.Level != 0 {
+		level = opts.Level
+	}
+	gw, err := pgzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	if err := gw.SetConcurrency(opts.blockSize(), opts.workers()); err != nil {
+		return nil, err
+	}
+	gw.Name = opts.Name
+	gw.Comment = opts.Comment
+	gw.ModTime = opts.ModTime
+	if gw.ModTime.IsZero() {
+		// pgzip writes zero times as a negative timestamp, use the gzip
+		// convention of a zero header field instead
+		gw.ModTime = time.Unix(0, 0)
+	}
+	return gw, nil
+}
+
+// newParallelZstdWriter creates a zstd writer that compresses blocks into
+// independent frames concurrently. Output depends on block size, level &
+// dictionary, not the number of workers
+func newParallelZstdWriter(w io.Writer, opts *Options) (io.WriteCloser, error) {
+	enc, err := zstd.NewWriter(nil, zstdEncoderOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	return newFrameWriter(w, enc, opts.blockSize(), opts.workers()), nil
+}
+
+// frameWriter splits data into blocks, encoding each block as an independent
+// zstd frame. Frames are encoded by up to workers goroutines & written in
+// order
+type frameWriter struct {
+	w         io.Writer
+	enc       *zstd.Encoder
+	blockSize int
+	buf       []byte
+	sem       chan struct{}
+	queue     chan chan frameResult
+	done      chan struct{}
+	blocks    int
+	closed    bool
+
+	lk     sync.Mutex
+	err    error
+	frames []SeekFrame
+}
+
+// frameResult is an encoded frame & it's decompressed size
+type frameResult struct {
+	data []byte
+	size int
+}
+
+func newFrameWriter(w io.Writer, enc *zstd.Encoder, blockSize, workers int) *frameWriter {
+	fw := &frameWriter{
+		w:         w,
+		enc:       enc,
+		blockSize: blockSize,
+		sem:       make(chan struct{}, workers),
+		queue:     make(chan chan frameResult, workers),
+		done:      make(chan struct{}),
+	}
+	go fw.writeFrames()
+	return fw
+}
+
+// writeFrames writes encoded frames in the order they were queued
+func (fw *frameWriter) writeFrames() {
+	defer close(fw.done)
+	for res := range fw.queue {
+		r := <-res
+		if fw.error() != nil {
+			continue
+		}
+		if _, err := fw.w.Write(r.data); err != nil {
+			fw.setError(err)
+			continue
+		}
+		fw.lk.Lock()
+		fw.frames = append(fw.frames, SeekFrame{CompressedSize: int64(len(r.data)), DecompressedSize: int64(r.size)})
+		fw.lk.Unlock()
+	}
+}
+
+func (fw *frameWriter) error() error {
+	fw.lk.Lock()
+	defer fw.lk.Unlock()
+	return fw.err
+}
+
+func (fw *frameWriter) setError(err error) {
+	fw.lk.Lock()
+	defer fw.lk.Unlock()
+	if fw.err == nil {
+		fw.err = err
+	}
+}
+
+// Write implements the io.Writer interface
+func (fw *frameWriter) Write(p []byte) (int, error) {
+	if fw.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n := len(p)
+	for len(p) > 0 {
+		if fw.buf == nil {
+			fw.buf = make([]byte, 0, fw.blockSize)
+		}
+		take := fw.blockSize - len(fw.buf)
+		if take > len(p) {
+			take = len(p)
+		}
+		fw.buf = append(fw.buf, p[:take]...)
+		p = p[take:]
+		if len(fw.buf) == fw.blockSize {
+			fw.encodeBlock()
+		}
+	}
+	return n, fw.error()
+}
+
+// encodeBlock queues buffered data for encoding
+func (fw *frameWriter) encodeBlock() {
+	block := fw.buf
+	fw.buf = nil
+	fw.blocks++
+	res := make(chan frameResult, 1)
+	fw.sem <- struct{}{}
+	fw.queue <- res
+	go func() {
+		res <- frameResult{data: fw.enc.EncodeAll(block, nil), size: len(block)}
+		<-fw.sem
+	}()
+}
+
+// Close encodes any buffered data & waits for all frames to be written
+func (fw *frameWriter) Close() error {
+	if fw.closed {
+		return fw.error()
+	}
+	fw.closed = true
+	// always write at least one frame, empty input is an empty frame
+	if len(fw.buf) > 0 || fw.blocks == 0 {
+		fw.encodeBlock()
+	}
+	close(fw.queue)
+	<-fw.done
+	fw.enc.Close()
+	return fw.error()
+}
@@ -0,0 +1,124 @@
+package compression
+
+import (
+	"bytes"
+	stdgzip "compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func parallelTestData() []byte {
+	buf := &bytes.Buffer{}
+	for i := 0; i < 40000; i++ {
+		fmt.Fprintf(buf, "%d,row %d,%f\n", i, i*7%13, float64(i)/3)
+	}
+	return buf.Bytes()
+}
+
+func compressWith(t *testing.T, f Format, opts *Options, data []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w, err := CompressorWithOptions(f.String(), buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// write in small chunks, crossing block boundaries
+	for p := data; len(p) > 0; {
+		n := 4000
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParallelCompression(t *testing.T) {
+	data := parallelTestData()
+
+	for _, f := range []Format{FmtGZip, FmtZStandard} {
+		t.Run(f.String(), func(t *testing.T) {
+			one := compressWith(t, f, &Options{Workers: 1, BlockSize: 64 << 10}, data)
+			many := compressWith(t, f, &Options{Workers: 8, BlockSize: 64 << 10}, data)
+			if !bytes.Equal(one, many) {
+				t.Error("expected output to be the same regardless of worker count")
+			}
+			again := compressWith(t, f, &Options{Workers: 8, BlockSize: 64 << 10}, data)
+			if !bytes.Equal(many, again) {
+				t.Error("expected output to be deterministic")
+			}
+
+			r, err := Decompressor(f.String(), bytes.NewReader(many))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Close()
+			if !bytes.Equal(data, got) {
+				t.Error("decompressed data mismatch")
+			}
+
+			empty := compressWith(t, f, &Options{Workers: 2}, nil)
+			r, err = Decompressor(f.String(), bytes.NewReader(empty))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ioutil.ReadAll(r); err != nil || len(got) != 0 {
+				t.Errorf("expected empty input to round trip. got: %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestParallelGzipStandard(t *testing.T) {
+	data := parallelTestData()
+	compressed := compressWith(t, FmtGZip, &Options{Workers: 4, BlockSize: 100 << 10, Level: 6, Name: "body.csv"}, data)
+
+	// the standard library must read parallel output as a single gzip member
+	r, err := stdgzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Multistream(false)
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, got) {
+		t.Error("decompressed data mismatch")
+	}
+	if r.Name != "body.csv" {
+		t.Errorf("header name mismatch. got: %q", r.Name)
+	}
+	if !r.ModTime.IsZero() {
+		t.Errorf("expected unset modification time, got: %s", r.ModTime)
+	}
+}
+
+func TestParallelCompressionErrors(t *testing.T) {
+	cases := []struct {
+		f    Format
+		opts *Options
+		err  string
+	}{
+		{FmtXZ, &Options{Workers: 2}, `parallel compression isn't supported for "xz" format`},
+		{FmtZStandard, &Options{BlockSize: 1024, FrameSize: 1024}, "blockSize and frameSize can't both be set, seekable frames are frameSize bytes"},
+		{FmtGZip, &Options{BlockSize: 1024}, "gzip: block size cannot be less than or equal to 16384"},
+	}
+
+	for _, c := range cases {
+		if _, err := CompressorWithOptions(c.f.String(), &bytes.Buffer{}, c.opts); err == nil || err.Error() != c.err {
+			t.Errorf("%s error mismatch. expected: %q, got: %v", c.f, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package compression
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultSeekableFrameSize is the number of uncompressed bytes in each frame
+// of seekable zstd data when options don't set a frame size
+const DefaultSeekableFrameSize = 1 << 20
+
+const (
+	// skippableFrameMagic starts the skippable frame holding a seek table
+	skippableFrameMagic = 0x184D2A5E
+	// seekableMagic ends a seek table
+	seekableMagic = 0x8F92EAB1
+	// seekTableFooterSize is the byte length of the seek table footer: frame
+	// count, descriptor & seekable magic number
+	seekTableFooterSize = 9
+	// seekTableEntrySize is the byte length of a seek table entry without
+	// checksums
+	seekTableEntrySize = 8
+	// seekTableChecksumFlag is set in the seek table descriptor when entries
+	// include checksums
+	seekTableChecksumFlag = 1 << 7
+)
+
+// ErrNoSeekTable indicates zstd data that wasn't written in seekable mode
+var ErrNoSeekTable = errors.New("zstd data has no seek table")
+
+// SeekFrame locates one frame of seekable zstd data
+type SeekFrame struct {
+	// CompressedOffset is the byte offset of the frame in compressed data
+	CompressedOffset int64
+	// DecompressedOffset is the byte offset of the frame's first byte in
+	// decompressed data
+	DecompressedOffset int64
+	// CompressedSize is the byte length of the compressed frame
+	CompressedSize int64
+	// DecompressedSize is the byte length of the frame once decompressed
+	DecompressedSize int64
+}
+
+// SeekTable indexes the frames of seekable zstd data. Seekable data follows the
+// zstd seekable format: independent frames followed by a skippable frame that
+// holds the seek table. Decompressors that don't understand the format skip
+// the table and read the frames as a single stream
+// https://github.com/facebook/zstd/blob/dev/contrib/seekable_format/zstd_seekable_compression_format.md
+type SeekTable struct {
+	Frames []SeekFrame
+}
+
+// Size gives the total length of decompressed data
+func (t *SeekTable) Size() int64 {
+	if len(t.Frames) == 0 {
+		return 0
+	}
+	last := t.Frames[len(t.Frames)-1]
+	return last.DecompressedOffset + last.DecompressedSize
+}
+
+// frame gives the index of the frame holding a decompressed offset
+func (t *SeekTable) frame(offset int64) int {
+	return sort.Search(len(t.Frames), func(i int) bool {
+		f := t.Frames[i]
+		return f.DecompressedOffset+f.DecompressedSize > offset
+	})
+}
+
+// ReadSeekTable reads the seek table from the end of seekable zstd data,
+// returning ErrNoSeekTable if the data has no table
+func ReadSeekTable(r io.ReadSeeker) (*SeekTable, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if end < seekTableFooterSize+8 {
+		return nil, ErrNoSeekTable
+	}
+
+	footer := make([]byte, seekTableFooterSize)
+	if _, err := r.Seek(end-seekTableFooterSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(footer[5:]) != seekableMagic {
+		return nil, ErrNoSeekTable
+	}
+
+	count := int64(binary.LittleEndian.Uint32(footer))
+	entrySize := int64(seekTableEntrySize)
+	if footer[4]&seekTableChecksumFlag != 0 {
+		entrySize += 4
+	}
+	tableSize := count*entrySize + seekTableFooterSize
+	if end < tableSize+8 {
+		return nil, fmt.Errorf("invalid seek table: %d frames don't fit in %d bytes", count, end)
+	}
+
+	header := make([]byte, 8)
+	if _, err := r.Seek(end-tableSize-8, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(header) != skippableFrameMagic || int64(binary.LittleEndian.Uint32(header[4:])) != tableSize {
+		return nil, fmt.Errorf("invalid seek table: missing skippable frame header")
+	}
+
+	entries := make([]byte, count*entrySize)
+	if _, err := io.ReadFull(r, entries); err != nil {
+		return nil, err
+	}
+
+	t := &SeekTable{Frames: make([]SeekFrame, count)}
+	var comp, decomp int64
+	for i := range t.Frames {
+		e := entries[int64(i)*entrySize:]
+		f := SeekFrame{
+			CompressedOffset:   comp,
+			DecompressedOffset: decomp,
+			CompressedSize:     int64(binary.LittleEndian.Uint32(e)),
+			DecompressedSize:   int64(binary.LittleEndian.Uint32(e[4:])),
+		}
+		comp += f.CompressedSize
+		decomp += f.DecompressedSize
+		t.Frames[i] = f
+	}
+	if comp != end-tableSize-8 {
+		return nil, fmt.Errorf("invalid seek table: frames total %d bytes, expected %d", comp, end-tableSize-8)
+	}
+	return t, nil
+}
+
+// seekableWriter compresses data into independent zstd frames of bounded
+// size, writing a seek table on close
+type seekableWriter struct {
+	*frameWriter
+}
+
+// NewSeekableWriter creates a writer of seekable zstd data. Uncompressed data
+// is split into frames of opts.FrameSize bytes, or DefaultSeekableFrameSize
+// when the frame size is zero. Smaller frames make seeking cheaper at the
+// expense of compression ratio. Frames are compressed in parallel when
+// opts.Workers is set. callers must Close the writer to write the seek table
+func NewSeekableWriter(w io.Writer, opts *Options) (io.WriteCloser, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := opts.check(FmtZStandard); err != nil {
+		return nil, err
+	}
+	frameSize := opts.FrameSize
+	if frameSize == 0 {
+		frameSize = DefaultSeekableFrameSize
+	}
+	workers := 1
+	if opts.Workers != 0 {
+		workers = opts.Workers
+	}
+
+	enc, err := zstd.NewWriter(nil, zstdEncoderOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	return seekableWriter{newFrameWriter(w, enc, frameSize, workers)}, nil
+}
+
+// Close flushes any buffered data & writes the seek table
+func (sw seekableWriter) Close() error {
+	if sw.closed {
+		return sw.error()
+	}
+	if err := sw.frameWriter.Close(); err != nil {
+		return err
+	}
+
+	tableSize := len(sw.frames)*seekTableEntrySize + seekTableFooterSize
+	table := make([]byte, 8+tableSize)
+	binary.LittleEndian.PutUint32(table, skippableFrameMagic)
+	binary.LittleEndian.PutUint32(table[4:], uint32(tableSize))
+	e := table[8:]
+	for _, f := range sw.frames {
+		binary.LittleEndian.PutUint32(e, uint32(f.CompressedSize))
+		binary.LittleEndian.PutUint32(e[4:], uint32(f.DecompressedSize))
+		e = e[seekTableEntrySize:]
+	}
+	// footer descriptor byte e[4] is left zero: entries have no checksums
+	binary.LittleEndian.PutUint32(e, uint32(len(sw.frames)))
+	binary.LittleEndian.PutUint32(e[5:], seekableMagic)
+	_, err := sw.w.Write(table)
+	return err
+}
+
+// SeekableReader decompresses seekable zstd data, decoding only the frames
+// that reads touch
+type SeekableReader struct {
+	r     io.ReadSeeker
+	dec   *zstd.Decoder
+	table *SeekTable
+	pos   int64
+	// frame & data cache the most recently decoded frame
+	frame int
+	data  []byte
+}
+
+var _ io.ReadSeeker = (*SeekableReader)(nil)
+
+// NewSeekableReader creates a decompressor that implements io.ReadSeeker over
+// seekable zstd data, reading the seek table from the end of r. It returns
+// ErrNoSeekTable when r wasn't written in seekable mode. Only the dictionary
+// option affects decompression. callers must Close the reader
+func NewSeekableReader(r io.ReadSeeker, opts *Options) (*SeekableReader, error) {
+	table, err := ReadSeekTable(r)
+	if err != nil {
+		return nil, err
+	}
+	dopts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if opts != nil && len(opts.Dictionary) > 0 {
+		dopts = append(dopts, zstd.WithDecoderDicts(opts.Dictionary))
+	}
+	dec, err := zstd.NewReader(nil, dopts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SeekableReader{r: r, dec: dec, table: table, frame: -1}, nil
+}
+
+// SeekTable gives the frame index of the data being read
+func (sr *SeekableReader) SeekTable() *SeekTable {
+	return sr.table
+}
+
+// Read implements the io.Reader interface
+func (sr *SeekableReader) Read(p []byte) (int, error) {
+	if sr.pos >= sr.table.Size() {
+		return 0, io.EOF
+	}
+	i := sr.table.frame(sr.pos)
+	if err := sr.loadFrame(i); err != nil {
+		return 0, err
+	}
+	n := copy(p, sr.data[sr.pos-sr.table.Frames[i].DecompressedOffset:])
+	sr.pos += int64(n)
+	return n, nil
+}
+
+// loadFrame decodes the frame at index i
+func (sr *SeekableReader) loadFrame(i int) error {
+	if sr.frame == i {
+		return nil
+	}
+	f := sr.table.Frames[i]
+	if _, err := sr.r.Seek(f.CompressedOffset, io.SeekStart); err != nil {
+		return err
+	}
+	src := make([]byte, f.CompressedSize)
+	if _, err := io.ReadFull(sr.r, src); err != nil {
+		return err
+	}
+	data, err := sr.dec.DecodeAll(src, sr.data[:0])
+	if err != nil {
+		return fmt.Errorf("decoding frame %d: %w", i, err)
+	}
+	if int64(len(data)) != f.DecompressedSize {
+		return fmt.Errorf("decoding frame %d: expected %d bytes, got %d", i, f.DecompressedSize, len(data))
+	}
+	sr.frame, sr.data = i, data
+	return nil
+}
+
+// Seek implements the io.Seeker interface. offsets are positions in
+// decompressed data
+func (sr *SeekableReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += sr.pos
+	case io.SeekEnd:
+		offset += sr.table.Size()
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative seek position: %d", offset)
+	}
+	sr.pos = offset
+	return offset, nil
+}
+
+// Close releases decoder resources
+func (sr *SeekableReader) Close() error {
+	sr.dec.Close()
+	return nil
+}
@@ -0,0 +1,135 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func seekableTestData() []byte {
+	buf := &bytes.Buffer{}
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(buf, "row %d of seekable data\n", i)
+	}
+	return buf.Bytes()
+}
+
+func TestSeekable(t *testing.T) {
+	data := seekableTestData()
+	compressed := &bytes.Buffer{}
+	w, err := CompressorWithOptions(FmtZStandard.String(), compressed, &Options{FrameSize: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// write in uneven chunks to cross frame boundaries mid-write
+	for p := data; len(p) > 0; {
+		n := 333
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// seekable data is readable as a plain zstd stream
+	dr, err := Decompressor(FmtZStandard.String(), bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr.Close()
+	if !bytes.Equal(data, got) {
+		t.Errorf("streaming decompression mismatch")
+	}
+
+	sr, err := NewSeekableReader(bytes.NewReader(compressed.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Close()
+
+	table := sr.SeekTable()
+	expectFrames := (len(data) + 999) / 1000
+	if len(table.Frames) != expectFrames {
+		t.Errorf("frame count mismatch. expected: %d, got: %d", expectFrames, len(table.Frames))
+	}
+	if table.Size() != int64(len(data)) {
+		t.Errorf("size mismatch. expected: %d, got: %d", len(data), table.Size())
+	}
+
+	offsets := []int64{0, 999, 1000, 12345, int64(len(data)) - 10, 5}
+	for _, off := range offsets {
+		if _, err := sr.Seek(off, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		p := make([]byte, 1500)
+		n, err := io.ReadFull(sr, p)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatal(err)
+		}
+		end := off + 1500
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		if !bytes.Equal(data[off:end], p[:n]) {
+			t.Errorf("offset %d: read mismatch.\nexpected: %q\ngot:      %q", off, data[off:end], p[:n])
+		}
+	}
+
+	if pos, err := sr.Seek(-10, io.SeekEnd); err != nil || pos != int64(len(data))-10 {
+		t.Errorf("seek from end mismatch. got: %d, %v", pos, err)
+	}
+	rest, err := ioutil.ReadAll(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[len(data)-10:], rest) {
+		t.Errorf("read from end mismatch. got: %q", rest)
+	}
+	if _, err := sr.Seek(-1, io.SeekStart); err == nil {
+		t.Error("expected seeking to a negative position to error")
+	}
+}
+
+func TestSeekableErrors(t *testing.T) {
+	plain := &bytes.Buffer{}
+	w, err := Compressor(FmtZStandard.String(), plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(seekableTestData())
+	w.Close()
+
+	if _, err := NewSeekableReader(bytes.NewReader(plain.Bytes()), nil); err != ErrNoSeekTable {
+		t.Errorf("expected ErrNoSeekTable, got: %v", err)
+	}
+	if _, err := NewSeekableReader(bytes.NewReader(nil), nil); err != ErrNoSeekTable {
+		t.Errorf("expected ErrNoSeekTable for empty data, got: %v", err)
+	}
+
+	// truncating the frames breaks the table's accounting
+	seekable := &bytes.Buffer{}
+	w, err = NewSeekableWriter(seekable, &Options{FrameSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(seekableTestData())
+	w.Close()
+	if _, err := NewSeekableReader(bytes.NewReader(seekable.Bytes()[10:]), nil); err == nil {
+		t.Error("expected truncated data to error")
+	}
+
+	if _, err := NewSeekableWriter(seekable, &Options{Name: "body.csv"}); err == nil {
+		t.Error("expected gzip header options to error")
+	}
+}
@@ -0,0 +1,70 @@
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// magicSize is the number of bytes needed to identify any format by it's
+// magic bytes
+const magicSize = 10
+
+var (
+	magicGZip   = []byte{0x1f, 0x8b}
+	magicZStd   = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	magicXZ     = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	magicBZip2  = []byte("BZh")
+	magicLZ4    = []byte{0x04, 0x22, 0x4d, 0x18}
+	magicSnappy = []byte("\xff\x06\x00\x00sNaPpY")
+
+	// bzip2 streams follow the header & block size with the magic of a first
+	// block, or of the end of stream when empty
+	magicBZip2Block = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	magicBZip2End   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// FormatFromMagic identifies a compression format from the leading bytes of
+// compressed data, returning FmtNone when no format matches. brotli streams
+// have no magic bytes, and are never identified
+func FormatFromMagic(p []byte) Format {
+	switch {
+	case bytes.HasPrefix(p, magicGZip):
+		return FmtGZip
+	case bytes.HasPrefix(p, magicZStd):
+		return FmtZStandard
+	case len(p) >= 4 && p[0]&0xf0 == 0x50 && bytes.Equal(p[1:4], []byte{0x2a, 0x4d, 0x18}):
+		// zstd skippable frame
+		return FmtZStandard
+	case bytes.HasPrefix(p, magicXZ):
+		return FmtXZ
+	case isBZip2(p):
+		return FmtBZip2
+	case bytes.HasPrefix(p, magicLZ4):
+		return FmtLZ4
+	case bytes.HasPrefix(p, magicSnappy):
+		return FmtSnappy
+	}
+	return FmtNone
+}
+
+// isBZip2 checks for a bzip2 header, block size & the magic that follows
+func isBZip2(p []byte) bool {
+	if len(p) < 10 || !bytes.HasPrefix(p, magicBZip2) || p[3] < '1' || p[3] > '9' {
+		return false
+	}
+	return bytes.Equal(p[4:10], magicBZip2Block) || bytes.Equal(p[4:10], magicBZip2End)
+}
+
+// DetectFormat peeks at the start of a reader to identify the compression
+// format of the stream by it's magic bytes, returning the format and a
+// reader that replays the peeked bytes. Uncompressed & unrecognized streams
+// give FmtNone
+func DetectFormat(r io.Reader) (Format, io.Reader, error) {
+	br := bufio.NewReader(r)
+	p, err := br.Peek(magicSize)
+	if err != nil && err != io.EOF {
+		return FmtNone, br, err
+	}
+	return FormatFromMagic(p), br, nil
+}
@@ -0,0 +1,81 @@
+package compression
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	plainText := []byte("id,name\n1,alpha\n2,beta\n")
+	for f := range SupportedFormats {
+		if f == FmtBZip2 || f == FmtBrotli {
+			continue
+		}
+		t.Run(f.String(), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := Compressor(f.String(), buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(plainText)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			compressed := append([]byte{}, buf.Bytes()...)
+
+			got, r, err := DetectFormat(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != f {
+				t.Errorf("format mismatch. expected: %q, got: %q", f, got)
+			}
+			replayed, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(compressed, replayed) {
+				t.Error("expected reader to replay all compressed bytes")
+			}
+		})
+	}
+}
+
+func TestFormatFromMagic(t *testing.T) {
+	cases := []struct {
+		description string
+		data        []byte
+		expect      Format
+	}{
+		{"empty", nil, FmtNone},
+		{"csv", []byte("a,b,c\n"), FmtNone},
+		{"json", []byte(`{"a":1}`), FmtNone},
+		{"short gzip", []byte{0x1f}, FmtNone},
+		{"bzip2", []byte("BZh91AY&SY"), FmtBZip2},
+		{"bzip2 empty stream", []byte("BZh9\x17\x72\x45\x38\x50\x90"), FmtBZip2},
+		{"bzip2 missing block size", []byte("BZhello"), FmtNone},
+		{"text starting with bzip2 header", []byte("BZh9 is a title\n1,2\n"), FmtNone},
+		{"zstd skippable frame", []byte{0x5e, 0x2a, 0x4d, 0x18, 0x00}, FmtZStandard},
+	}
+
+	for _, c := range cases {
+		if got := FormatFromMagic(c.data); got != c.expect {
+			t.Errorf("%s format mismatch. expected: %q, got: %q", c.description, c.expect, got)
+		}
+	}
+}
+
+func TestDetectFormatShortInput(t *testing.T) {
+	f, r, err := DetectFormat(bytes.NewReader([]byte("a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != FmtNone {
+		t.Errorf("expected no format, got: %q", f)
+	}
+	data, _ := ioutil.ReadAll(r)
+	if string(data) != "a" {
+		t.Errorf("expected replayed input. got: %q", string(data))
+	}
+}
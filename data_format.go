@@ -34,6 +34,12 @@ const (
 	XMLDataFormat
 	// XLSXDataFormat specifies microsoft excel formatted data
 	XLSXDataFormat
+	// ODSDataFormat specifies OpenDocument Spreadsheet formatted data, the
+	// native spreadsheet format of LibreOffice & OpenOffice
+	ODSDataFormat
+	// FixedWidthDataFormat specifies plain text data with columns at fixed
+	// character positions, common in mainframe & census extracts
+	FixedWidthDataFormat
 )
 
 // SupportedDataFormats gives a slice of data formats that are
@@ -47,19 +53,23 @@ func SupportedDataFormats() []DataFormat {
 		CSVDataFormat,
 		XLSXDataFormat,
 		NDJSONDataFormat,
+		ODSDataFormat,
+		FixedWidthDataFormat,
 	}
 }
 
 // String implements stringer interface for DataFormat
 func (f DataFormat) String() string {
 	s, ok := map[DataFormat]string{
-		UnknownDataFormat: "",
-		CSVDataFormat:     "csv",
-		JSONDataFormat:    "json",
-		XMLDataFormat:     "xml",
-		XLSXDataFormat:    "xlsx",
-		CBORDataFormat:    "cbor",
-		NDJSONDataFormat:  "ndjson",
+		UnknownDataFormat:    "",
+		CSVDataFormat:        "csv",
+		JSONDataFormat:       "json",
+		XMLDataFormat:        "xml",
+		XLSXDataFormat:       "xlsx",
+		CBORDataFormat:       "cbor",
+		NDJSONDataFormat:     "ndjson",
+		ODSDataFormat:        "ods",
+		FixedWidthDataFormat: "fwf",
 	}[f]
 
 	if !ok {
@@ -88,6 +98,10 @@ func ParseDataFormatString(s string) (df DataFormat, err error) {
 		"ndjson":  NDJSONDataFormat,
 		".jsonl":  NDJSONDataFormat,
 		"jsonl":   NDJSONDataFormat,
+		".ods":    ODSDataFormat,
+		"ods":     ODSDataFormat,
+		".fwf":    FixedWidthDataFormat,
+		"fwf":     FixedWidthDataFormat,
 	}[s]
 	if !ok {
 		err = fmt.Errorf("invalid data format: `%s`", s)
@@ -22,6 +22,10 @@ func ParseFormatConfigMap(f DataFormat, opts map[string]interface{}) (FormatConf
 		return NewJSONOptions(opts)
 	case XLSXDataFormat:
 		return NewXLSXOptions(opts)
+	case ODSDataFormat:
+		return NewODSOptions(opts)
+	case FixedWidthDataFormat:
+		return NewFixedWidthOptions(opts)
 	default:
 		return nil, fmt.Errorf("cannot parse configuration for format: %s", f.String())
 	}
@@ -52,10 +56,10 @@ func NewCSVOptions(opts map[string]interface{}) (*CSVOptions, error) {
 
 	if opts["separator"] != nil {
 		if sep, ok := opts["separator"].(string); ok {
-			if len(sep) != 1 {
+			if len([]rune(sep)) != 1 {
 				return nil, fmt.Errorf("separator must be a single character")
 			}
-			o.Separator = rune(sep[0])
+			o.Separator = []rune(sep)[0]
 		} else {
 			return nil, fmt.Errorf("invalid separator value: %v", opts["separator"])
 		}
@@ -69,9 +73,94 @@ func NewCSVOptions(opts map[string]interface{}) (*CSVOptions, error) {
 		}
 	}
 
+	if opts["quoteStyle"] != nil {
+		qs, ok := opts["quoteStyle"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid quoteStyle value: %v", opts["quoteStyle"])
+		}
+		switch qs {
+		case CSVQuoteMinimal, CSVQuoteAll, CSVQuoteNonNumeric:
+			o.QuoteStyle = qs
+		default:
+			return nil, fmt.Errorf("invalid quoteStyle value: %q", qs)
+		}
+	}
+
+	for key, dst := range map[string]*rune{
+		"quoteChar":  &o.QuoteChar,
+		"escapeChar": &o.EscapeChar,
+		"comment":    &o.Comment,
+	} {
+		if opts[key] != nil {
+			if str, ok := opts[key].(string); ok {
+				if len([]rune(str)) != 1 {
+					return nil, fmt.Errorf("%s must be a single character", key)
+				}
+				*dst = []rune(str)[0]
+			} else {
+				return nil, fmt.Errorf("invalid %s value: %v", key, opts[key])
+			}
+		}
+	}
+
+	if opts["nullTokens"] != nil {
+		switch nt := opts["nullTokens"].(type) {
+		case []string:
+			o.NullTokens = nt
+		case []interface{}:
+			o.NullTokens = make([]string, len(nt))
+			for i, v := range nt {
+				token, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid nullTokens value at index %d: %v", i, v)
+				}
+				o.NullTokens[i] = token
+			}
+		default:
+			return nil, fmt.Errorf("invalid nullTokens value: %v", opts["nullTokens"])
+		}
+	}
+
+	if opts["lineTerminator"] != nil {
+		lt, ok := opts["lineTerminator"].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid lineTerminator value: %v", opts["lineTerminator"])
+		}
+		if lt != "\n" && lt != "\r\n" && lt != "\r" {
+			return nil, fmt.Errorf("lineTerminator must be one of \\n, \\r\\n or \\r")
+		}
+		o.LineTerminator = lt
+	}
+
+	if opts["trimSpace"] != nil {
+		if ts, ok := opts["trimSpace"].(bool); ok {
+			o.TrimSpace = ts
+		} else {
+			return nil, fmt.Errorf("invalid trimSpace value: %v", opts["trimSpace"])
+		}
+	}
+
+	if opts["skipInitialRows"] != nil {
+		n, err := intOption(opts["skipInitialRows"])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid skipInitialRows value: %v", opts["skipInitialRows"])
+		}
+		o.SkipInitialRows = n
+	}
+
 	return o, nil
 }
 
+const (
+	// CSVQuoteMinimal only quotes fields that contain special characters like
+	// separators, quotes or line breaks. This is the default quote style
+	CSVQuoteMinimal = "minimal"
+	// CSVQuoteAll quotes every field
+	CSVQuoteAll = "all"
+	// CSVQuoteNonNumeric quotes all fields that don't hold numbers
+	CSVQuoteNonNumeric = "nonNumeric"
+)
+
 // CSVOptions specifies configuration details for csv files
 // This'll expand in the future to interoperate with okfn csv spec
 type CSVOptions struct {
@@ -88,6 +177,28 @@ type CSVOptions struct {
 	// VariadicFields sets permits records to have a variable number of fields
 	// avoid using this
 	VariadicFields bool `json:"variadicFields"`
+	// QuoteStyle sets which fields writers quote, one of "minimal", "all", or
+	// "nonNumeric". defaults to "minimal"
+	QuoteStyle string `json:"quoteStyle,omitempty"`
+	// QuoteChar is the character used to quote fields. defaults to '"'
+	QuoteChar rune `json:"quoteChar,omitempty"`
+	// EscapeChar escapes quote characters within quoted fields. When unset
+	// quotes are escaped by doubling them ("")
+	EscapeChar rune `json:"escapeChar,omitempty"`
+	// NullTokens lists field values that are read as null, like "NA" or "\N".
+	// Writers use the first token to write null values
+	NullTokens []string `json:"nullTokens,omitempty"`
+	// Comment, if set, is a character that marks lines to ignore when it's the
+	// first character of a line
+	Comment rune `json:"comment,omitempty"`
+	// LineTerminator is the line ending writers use, one of "\n", "\r\n" or
+	// "\r". defaults to "\n". Readers accept all line endings
+	LineTerminator string `json:"lineTerminator,omitempty"`
+	// TrimSpace removes leading & trailing whitespace from field values
+	TrimSpace bool `json:"trimSpace,omitempty"`
+	// SkipInitialRows is a number of lines to skip at the start of the file,
+	// before any header row
+	SkipInitialRows int `json:"skipInitialRows,omitempty"`
 }
 
 // Format announces the CSV Data Format for the FormatConfig interface
@@ -111,7 +222,35 @@ func (o *CSVOptions) Map() map[string]interface{} {
 		opt["variadicFields"] = o.VariadicFields
 	}
 	if o.Separator != rune(0) {
-		opt["separator"] = o.Separator
+		opt["separator"] = string(o.Separator)
+	}
+	if o.QuoteStyle != "" {
+		opt["quoteStyle"] = o.QuoteStyle
+	}
+	if o.QuoteChar != rune(0) {
+		opt["quoteChar"] = string(o.QuoteChar)
+	}
+	if o.EscapeChar != rune(0) {
+		opt["escapeChar"] = string(o.EscapeChar)
+	}
+	if len(o.NullTokens) > 0 {
+		tokens := make([]interface{}, len(o.NullTokens))
+		for i, t := range o.NullTokens {
+			tokens[i] = t
+		}
+		opt["nullTokens"] = tokens
+	}
+	if o.Comment != rune(0) {
+		opt["comment"] = string(o.Comment)
+	}
+	if o.LineTerminator != "" {
+		opt["lineTerminator"] = o.LineTerminator
+	}
+	if o.TrimSpace {
+		opt["trimSpace"] = o.TrimSpace
+	}
+	if o.SkipInitialRows > 0 {
+		opt["skipInitialRows"] = o.SkipInitialRows
 	}
 	return opt
 }
@@ -145,6 +284,27 @@ func (o *JSONOptions) Map() map[string]interface{} {
 // XLSXOptions specifies configuraiton details for the xlsx file format
 type XLSXOptions struct {
 	SheetName string `json:"sheetName,omitempty"`
+	// HeaderRow specifies the sheet has a row of column titles. Writers
+	// generate the header row from schema column titles
+	HeaderRow bool `json:"headerRow,omitempty"`
+	// BoldHeader sets header row text in bold. requires HeaderRow
+	BoldHeader bool `json:"boldHeader,omitempty"`
+	// FreezeHeader freezes the header row in place while scrolling. requires
+	// HeaderRow
+	FreezeHeader bool `json:"freezeHeader,omitempty"`
+	// AutoColumnWidth sizes columns to fit the longest value written
+	AutoColumnWidth bool `json:"autoColumnWidth,omitempty"`
+	// NumberFormats maps column types to excel number format codes, eg:
+	// {"number": "#,##0.00", "date": "yyyy-mm-dd"}. keys are either json
+	// schema types ("integer", "number") or string formats ("date", "date-time")
+	NumberFormats map[string]string `json:"numberFormats,omitempty"`
+	// Description is text for a row written above all other rows of the sheet,
+	// like a dataset's title. Readers skip the description row
+	Description string `json:"description,omitempty"`
+	// SkipInitialRows is a number of sheet rows to skip before any header
+	// row, like titles or notes above a table. Readers skip them after any
+	// description row. Entirely empty rows aren't counted
+	SkipInitialRows int `json:"skipInitialRows,omitempty"`
 }
 
 // NewXLSXOptions creates a XLSXOptions pointer from a map
@@ -162,6 +322,55 @@ func NewXLSXOptions(opts map[string]interface{}) (FormatConfig, error) {
 		}
 	}
 
+	for key, dst := range map[string]*bool{
+		"headerRow":       &o.HeaderRow,
+		"boldHeader":      &o.BoldHeader,
+		"freezeHeader":    &o.FreezeHeader,
+		"autoColumnWidth": &o.AutoColumnWidth,
+	} {
+		if opts[key] != nil {
+			if b, ok := opts[key].(bool); ok {
+				*dst = b
+			} else {
+				return nil, fmt.Errorf("invalid %s value: %v", key, opts[key])
+			}
+		}
+	}
+
+	if opts["numberFormats"] != nil {
+		switch nf := opts["numberFormats"].(type) {
+		case map[string]string:
+			o.NumberFormats = nf
+		case map[string]interface{}:
+			o.NumberFormats = make(map[string]string, len(nf))
+			for t, v := range nf {
+				code, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid numberFormats value for %q: %v", t, v)
+				}
+				o.NumberFormats[t] = code
+			}
+		default:
+			return nil, fmt.Errorf("invalid numberFormats value: %v", opts["numberFormats"])
+		}
+	}
+
+	if opts["description"] != nil {
+		if d, ok := opts["description"].(string); ok {
+			o.Description = d
+		} else {
+			return nil, fmt.Errorf("invalid description value: %v", opts["description"])
+		}
+	}
+
+	if opts["skipInitialRows"] != nil {
+		n, err := intOption(opts["skipInitialRows"])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid skipInitialRows value: %v", opts["skipInitialRows"])
+		}
+		o.SkipInitialRows = n
+	}
+
 	return o, nil
 }
 
@@ -179,6 +388,275 @@ func (o *XLSXOptions) Map() map[string]interface{} {
 	if o.SheetName != "" {
 		opt["sheetName"] = o.SheetName
 	}
+	if o.HeaderRow {
+		opt["headerRow"] = o.HeaderRow
+	}
+	if o.BoldHeader {
+		opt["boldHeader"] = o.BoldHeader
+	}
+	if o.FreezeHeader {
+		opt["freezeHeader"] = o.FreezeHeader
+	}
+	if o.AutoColumnWidth {
+		opt["autoColumnWidth"] = o.AutoColumnWidth
+	}
+	if len(o.NumberFormats) > 0 {
+		nf := make(map[string]interface{}, len(o.NumberFormats))
+		for t, code := range o.NumberFormats {
+			nf[t] = code
+		}
+		opt["numberFormats"] = nf
+	}
+	if o.Description != "" {
+		opt["description"] = o.Description
+	}
+	if o.SkipInitialRows > 0 {
+		opt["skipInitialRows"] = o.SkipInitialRows
+	}
+
+	return opt
+}
+
+// ODSOptions specifies configuration details for the OpenDocument
+// Spreadsheet file format
+type ODSOptions struct {
+	SheetName string `json:"sheetName,omitempty"`
+	// HeaderRow specifies the sheet has a row of column titles. Writers
+	// generate the header row from schema column titles
+	HeaderRow bool `json:"headerRow,omitempty"`
+	// SkipInitialRows is a number of sheet rows to skip before any header
+	// row, like titles or notes above a table. Entirely empty rows aren't
+	// counted
+	SkipInitialRows int `json:"skipInitialRows,omitempty"`
+}
+
+// NewODSOptions creates an ODSOptions pointer from a map
+func NewODSOptions(opts map[string]interface{}) (FormatConfig, error) {
+	o := &ODSOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["sheetName"] != nil {
+		if sheetName, ok := opts["sheetName"].(string); ok {
+			o.SheetName = sheetName
+		} else {
+			return nil, fmt.Errorf("invalid sheetName value: %v", opts["sheetName"])
+		}
+	}
 
+	if opts["headerRow"] != nil {
+		if headerRow, ok := opts["headerRow"].(bool); ok {
+			o.HeaderRow = headerRow
+		} else {
+			return nil, fmt.Errorf("invalid headerRow value: %v", opts["headerRow"])
+		}
+	}
+
+	if opts["skipInitialRows"] != nil {
+		n, err := intOption(opts["skipInitialRows"])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid skipInitialRows value: %v", opts["skipInitialRows"])
+		}
+		o.SkipInitialRows = n
+	}
+
+	return o, nil
+}
+
+// Format announces the ODS data format for the FormatConfig interface
+func (*ODSOptions) Format() DataFormat {
+	return ODSDataFormat
+}
+
+// Map structures ODSOptions as a map of string keys to values
+func (o *ODSOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.SheetName != "" {
+		opt["sheetName"] = o.SheetName
+	}
+	if o.HeaderRow {
+		opt["headerRow"] = o.HeaderRow
+	}
+	if o.SkipInitialRows > 0 {
+		opt["skipInitialRows"] = o.SkipInitialRows
+	}
+	return opt
+}
+
+// FixedWidthColumn specifies the position of a column in fixed-width data.
+// positions are measured in characters
+type FixedWidthColumn struct {
+	// Start is the zero-based index of the column's first character
+	Start int `json:"start"`
+	// Width is the number of characters the column occupies
+	Width int `json:"width"`
+}
+
+const (
+	// FixedWidthTrimBoth removes leading & trailing padding from values
+	FixedWidthTrimBoth = "both"
+	// FixedWidthTrimLeft removes leading padding from values
+	FixedWidthTrimLeft = "left"
+	// FixedWidthTrimRight removes trailing padding from values
+	FixedWidthTrimRight = "right"
+	// FixedWidthTrimNone reads values exactly as they appear
+	FixedWidthTrimNone = "none"
+
+	// FixedWidthAlignAuto right-aligns numbers and left-aligns all other values
+	FixedWidthAlignAuto = "auto"
+	// FixedWidthAlignLeft pads values on the right
+	FixedWidthAlignLeft = "left"
+	// FixedWidthAlignRight pads values on the left
+	FixedWidthAlignRight = "right"
+)
+
+// FixedWidthOptions specifies configuration details for fixed-width text
+type FixedWidthOptions struct {
+	// HeaderRow specifies the first line of the file holds column titles
+	HeaderRow bool `json:"headerRow,omitempty"`
+	// Columns lists column positions. When empty, positions are derived from
+	// "width" keywords on schema column definitions, with each column starting
+	// where the previous one ends
+	Columns []FixedWidthColumn `json:"columns,omitempty"`
+	// Trim sets which padding is removed from values when reading, one of
+	// "both", "left", "right" or "none". defaults to "both"
+	Trim string `json:"trim,omitempty"`
+	// Align sets which side of a value writers pad, one of "auto", "left" or
+	// "right". defaults to "auto"
+	Align string `json:"align,omitempty"`
+	// PadChar is the character used to pad values. defaults to a space
+	PadChar rune `json:"padChar,omitempty"`
+}
+
+// NewFixedWidthOptions creates a FixedWidthOptions pointer from a map
+func NewFixedWidthOptions(opts map[string]interface{}) (*FixedWidthOptions, error) {
+	o := &FixedWidthOptions{}
+	if opts == nil {
+		return o, nil
+	}
+
+	if opts["headerRow"] != nil {
+		if headerRow, ok := opts["headerRow"].(bool); ok {
+			o.HeaderRow = headerRow
+		} else {
+			return nil, fmt.Errorf("invalid headerRow value: %v", opts["headerRow"])
+		}
+	}
+
+	if opts["columns"] != nil {
+		switch cols := opts["columns"].(type) {
+		case []FixedWidthColumn:
+			o.Columns = cols
+		case []interface{}:
+			o.Columns = make([]FixedWidthColumn, len(cols))
+			for i, c := range cols {
+				col, ok := c.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid columns value at index %d: %v", i, c)
+				}
+				start, err := intOption(col["start"])
+				if err != nil {
+					return nil, fmt.Errorf("invalid start value for column %d: %v", i, col["start"])
+				}
+				width, err := intOption(col["width"])
+				if err != nil || width <= 0 {
+					return nil, fmt.Errorf("invalid width value for column %d: %v", i, col["width"])
+				}
+				o.Columns[i] = FixedWidthColumn{Start: start, Width: width}
+			}
+		default:
+			return nil, fmt.Errorf("invalid columns value: %v", opts["columns"])
+		}
+	}
+
+	if opts["trim"] != nil {
+		trim, ok := opts["trim"].(string)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("invalid trim value: %v", opts["trim"])
+		case trim != FixedWidthTrimBoth && trim != FixedWidthTrimLeft && trim != FixedWidthTrimRight && trim != FixedWidthTrimNone:
+			return nil, fmt.Errorf("invalid trim value: %q", trim)
+		}
+		o.Trim = trim
+	}
+
+	if opts["align"] != nil {
+		align, ok := opts["align"].(string)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("invalid align value: %v", opts["align"])
+		case align != FixedWidthAlignAuto && align != FixedWidthAlignLeft && align != FixedWidthAlignRight:
+			return nil, fmt.Errorf("invalid align value: %q", align)
+		}
+		o.Align = align
+	}
+
+	if opts["padChar"] != nil {
+		switch pc := opts["padChar"].(type) {
+		case rune:
+			o.PadChar = pc
+		case string:
+			if len([]rune(pc)) != 1 {
+				return nil, fmt.Errorf("padChar must be a single character")
+			}
+			o.PadChar = []rune(pc)[0]
+		default:
+			return nil, fmt.Errorf("invalid padChar value: %v", opts["padChar"])
+		}
+	}
+
+	return o, nil
+}
+
+// intOption reads an integer from a decoded options value. numbers decoded
+// from JSON are float64 values
+func intOption(v interface{}) (int, error) {
+	switch x := v.(type) {
+	case int:
+		return x, nil
+	case int64:
+		return int(x), nil
+	case float64:
+		if x == float64(int(x)) {
+			return int(x), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid integer value: %v", v)
+}
+
+// Format announces the fixed-width data format for the FormatConfig interface
+func (*FixedWidthOptions) Format() DataFormat {
+	return FixedWidthDataFormat
+}
+
+// Map structures FixedWidthOptions as a map of string keys to values
+func (o *FixedWidthOptions) Map() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	opt := map[string]interface{}{}
+	if o.HeaderRow {
+		opt["headerRow"] = o.HeaderRow
+	}
+	if len(o.Columns) > 0 {
+		cols := make([]interface{}, len(o.Columns))
+		for i, c := range o.Columns {
+			cols[i] = map[string]interface{}{"start": c.Start, "width": c.Width}
+		}
+		opt["columns"] = cols
+	}
+	if o.Trim != "" {
+		opt["trim"] = o.Trim
+	}
+	if o.Align != "" {
+		opt["align"] = o.Align
+	}
+	if o.PadChar != rune(0) {
+		opt["padChar"] = string(o.PadChar)
+	}
 	return opt
 }
@@ -2,7 +2,10 @@ package dataset
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func CompareFormatConfigs(a, b FormatConfig) error {
@@ -31,6 +34,8 @@ func TestParseFormatConfigMap(t *testing.T) {
 		{CSVDataFormat, map[string]interface{}{}, &CSVOptions{}, ""},
 		{JSONDataFormat, map[string]interface{}{}, &JSONOptions{}, ""},
 		{XLSXDataFormat, map[string]interface{}{}, &XLSXOptions{}, ""},
+		{ODSDataFormat, map[string]interface{}{}, &ODSOptions{}, ""},
+		{FixedWidthDataFormat, map[string]interface{}{}, &FixedWidthOptions{}, ""},
 	}
 
 	for i, c := range cases {
@@ -59,10 +64,19 @@ func TestNewCSVOptions(t *testing.T) {
 		{map[string]interface{}{"lazyQuotes": true}, &CSVOptions{LazyQuotes: true}, ""},
 		{map[string]interface{}{"lazyQuotes": "foo"}, nil, "invalid lazyQuotes value: foo"},
 		{map[string]interface{}{"separator": "\t"}, &CSVOptions{Separator: '\t'}, ""},
+		{map[string]interface{}{"separator": "§"}, &CSVOptions{Separator: '§'}, ""},
 		{map[string]interface{}{"separator": "\t\t"}, nil, "separator must be a single character"},
 		{map[string]interface{}{"separator": true}, nil, "invalid separator value: true"},
 		{map[string]interface{}{"variadicFields": true}, &CSVOptions{VariadicFields: true}, ""},
 		{map[string]interface{}{"variadicFields": "foo"}, nil, "invalid variadicFields value: foo"},
+		{map[string]interface{}{"quoteStyle": "all"}, &CSVOptions{QuoteStyle: CSVQuoteAll}, ""},
+		{map[string]interface{}{"quoteStyle": "some"}, nil, `invalid quoteStyle value: "some"`},
+		{map[string]interface{}{"quoteChar": "'"}, &CSVOptions{QuoteChar: '\''}, ""},
+		{map[string]interface{}{"quoteChar": "''"}, nil, "quoteChar must be a single character"},
+		{map[string]interface{}{"escapeChar": 5}, nil, "invalid escapeChar value: 5"},
+		{map[string]interface{}{"nullTokens": []interface{}{"NA", 1}}, nil, "invalid nullTokens value at index 1: 1"},
+		{map[string]interface{}{"lineTerminator": "\n\n"}, nil, `lineTerminator must be one of \n, \r\n or \r`},
+		{map[string]interface{}{"skipInitialRows": -1}, nil, "invalid skipInitialRows value: -1"},
 	}
 
 	for i, c := range cases {
@@ -99,6 +113,29 @@ func TestCSVOptionsMap(t *testing.T) {
 	}
 }
 
+func TestCSVOptionsMapRoundTrip(t *testing.T) {
+	opt := &CSVOptions{
+		HeaderRow:       true,
+		Separator:       ';',
+		QuoteStyle:      CSVQuoteNonNumeric,
+		QuoteChar:       '\'',
+		EscapeChar:      '\\',
+		NullTokens:      []string{"NA", "-"},
+		Comment:         '#',
+		LineTerminator:  "\r\n",
+		TrimSpace:       true,
+		SkipInitialRows: 2,
+	}
+
+	got, err := NewCSVOptions(opt.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(opt, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestNewJSONOptions(t *testing.T) {
 	cases := []struct {
 		opts map[string]interface{}
@@ -147,6 +184,13 @@ func TestNewXLSXOptions(t *testing.T) {
 		{map[string]interface{}{}, &XLSXOptions{}, ""},
 		{map[string]interface{}{"sheetName": "foo"}, &XLSXOptions{SheetName: "foo"}, ""},
 		{map[string]interface{}{"sheetName": true}, nil, "invalid sheetName value: true"},
+		{map[string]interface{}{"headerRow": true, "boldHeader": true, "freezeHeader": true, "autoColumnWidth": true}, &XLSXOptions{HeaderRow: true, BoldHeader: true, FreezeHeader: true, AutoColumnWidth: true}, ""},
+		{map[string]interface{}{"boldHeader": "yes"}, nil, "invalid boldHeader value: yes"},
+		{map[string]interface{}{"numberFormats": map[string]interface{}{"number": "0.00"}}, &XLSXOptions{NumberFormats: map[string]string{"number": "0.00"}}, ""},
+		{map[string]interface{}{"numberFormats": map[string]interface{}{"number": 2}}, nil, `invalid numberFormats value for "number": 2`},
+		{map[string]interface{}{"description": "foo"}, &XLSXOptions{Description: "foo"}, ""},
+		{map[string]interface{}{"skipInitialRows": float64(2)}, &XLSXOptions{SkipInitialRows: 2}, ""},
+		{map[string]interface{}{"skipInitialRows": -1}, nil, "invalid skipInitialRows value: -1"},
 	}
 
 	for i, c := range cases {
@@ -162,8 +206,8 @@ func TestNewXLSXOptions(t *testing.T) {
 				continue
 			}
 
-			if xlsxo.SheetName != c.res.SheetName {
-				t.Errorf("case %d SheetName expected: %s, got: %s", i, xlsxo.SheetName, c.res.SheetName)
+			if !reflect.DeepEqual(xlsxo, c.res) {
+				t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, xlsxo)
 				continue
 			}
 		}
@@ -178,6 +222,8 @@ func TestXLSXOptionsMap(t *testing.T) {
 		{nil, nil},
 		{&XLSXOptions{}, map[string]interface{}{}},
 		{&XLSXOptions{SheetName: "foo"}, map[string]interface{}{"sheetName": "foo"}},
+		{&XLSXOptions{HeaderRow: true, FreezeHeader: true, Description: "foo"}, map[string]interface{}{"headerRow": true, "freezeHeader": true, "description": "foo"}},
+		{&XLSXOptions{HeaderRow: true, SkipInitialRows: 2}, map[string]interface{}{"headerRow": true, "skipInitialRows": 2}},
 	}
 
 	for i, c := range cases {
@@ -189,3 +235,112 @@ func TestXLSXOptionsMap(t *testing.T) {
 		}
 	}
 }
+
+func TestNewODSOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *ODSOptions
+		err  string
+	}{
+		{nil, &ODSOptions{}, ""},
+		{map[string]interface{}{}, &ODSOptions{}, ""},
+		{map[string]interface{}{"sheetName": "foo", "headerRow": true}, &ODSOptions{SheetName: "foo", HeaderRow: true}, ""},
+		{map[string]interface{}{"sheetName": true}, nil, "invalid sheetName value: true"},
+		{map[string]interface{}{"headerRow": "true"}, nil, "invalid headerRow value: true"},
+		{map[string]interface{}{"skipInitialRows": float64(2)}, &ODSOptions{SkipInitialRows: 2}, ""},
+		{map[string]interface{}{"skipInitialRows": -1}, nil, "invalid skipInitialRows value: -1"},
+	}
+
+	for i, c := range cases {
+		got, err := NewODSOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && !reflect.DeepEqual(got, c.res) {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestODSOptionsMap(t *testing.T) {
+	cases := []struct {
+		opt *ODSOptions
+		res map[string]interface{}
+	}{
+		{nil, nil},
+		{&ODSOptions{}, map[string]interface{}{}},
+		{&ODSOptions{SheetName: "foo", HeaderRow: true}, map[string]interface{}{"sheetName": "foo", "headerRow": true}},
+		{&ODSOptions{SkipInitialRows: 1}, map[string]interface{}{"skipInitialRows": 1}},
+	}
+
+	for i, c := range cases {
+		got := c.opt.Map()
+		if !reflect.DeepEqual(got, c.res) {
+			t.Errorf("case %d expected: %v, got: %v", i, c.res, got)
+		}
+	}
+}
+
+func TestNewFixedWidthOptions(t *testing.T) {
+	cases := []struct {
+		opts map[string]interface{}
+		res  *FixedWidthOptions
+		err  string
+	}{
+		{nil, &FixedWidthOptions{}, ""},
+		{map[string]interface{}{}, &FixedWidthOptions{}, ""},
+		{map[string]interface{}{
+			"headerRow": true,
+			"columns": []interface{}{
+				map[string]interface{}{"start": float64(0), "width": float64(3)},
+				map[string]interface{}{"start": 4, "width": 2},
+			},
+			"trim":    "right",
+			"align":   "left",
+			"padChar": "0",
+		}, &FixedWidthOptions{
+			HeaderRow: true,
+			Columns:   []FixedWidthColumn{{Start: 0, Width: 3}, {Start: 4, Width: 2}},
+			Trim:      "right",
+			Align:     "left",
+			PadChar:   '0',
+		}, ""},
+		{map[string]interface{}{"headerRow": "true"}, nil, "invalid headerRow value: true"},
+		{map[string]interface{}{"columns": "0:3"}, nil, "invalid columns value: 0:3"},
+		{map[string]interface{}{"columns": []interface{}{map[string]interface{}{"start": float64(0)}}}, nil, "invalid width value for column 0: <nil>"},
+		{map[string]interface{}{"columns": []interface{}{map[string]interface{}{"start": 1.5, "width": float64(2)}}}, nil, "invalid start value for column 0: 1.5"},
+		{map[string]interface{}{"trim": "middle"}, nil, `invalid trim value: "middle"`},
+		{map[string]interface{}{"align": "center"}, nil, `invalid align value: "center"`},
+		{map[string]interface{}{"padChar": "ab"}, nil, "padChar must be a single character"},
+	}
+
+	for i, c := range cases {
+		got, err := NewFixedWidthOptions(c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.err == "" && !reflect.DeepEqual(got, c.res) {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.res, got)
+		}
+	}
+}
+
+func TestFixedWidthOptionsMap(t *testing.T) {
+	opt := &FixedWidthOptions{
+		HeaderRow: true,
+		Columns:   []FixedWidthColumn{{Start: 0, Width: 3}},
+		PadChar:   '_',
+	}
+	got, err := NewFixedWidthOptions(opt.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opt, got) {
+		t.Errorf("round trip mismatch. expected: %#v, got: %#v", opt, got)
+	}
+	if (*FixedWidthOptions)(nil).Map() != nil {
+		t.Error("expected nil options to map to nil")
+	}
+}
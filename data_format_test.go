@@ -12,6 +12,8 @@ func TestSupportedDataFormats(t *testing.T) {
 		CSVDataFormat,
 		XLSXDataFormat,
 		NDJSONDataFormat,
+		ODSDataFormat,
+		FixedWidthDataFormat,
 	}
 
 	for i, f := range SupportedDataFormats() {
@@ -33,6 +35,8 @@ func TestDataFormatString(t *testing.T) {
 		{XLSXDataFormat, "xlsx"},
 		{CBORDataFormat, "cbor"},
 		{NDJSONDataFormat, "ndjson"},
+		{ODSDataFormat, "ods"},
+		{FixedWidthDataFormat, "fwf"},
 	}
 
 	for i, c := range cases {
@@ -58,6 +62,10 @@ func TestParseDataFormatString(t *testing.T) {
 		{"xml", XMLDataFormat, ""},
 		{".xlsx", XLSXDataFormat, ""},
 		{"xlsx", XLSXDataFormat, ""},
+		{".ods", ODSDataFormat, ""},
+		{"ods", ODSDataFormat, ""},
+		{".fwf", FixedWidthDataFormat, ""},
+		{"fwf", FixedWidthDataFormat, ""},
 		{"cbor", CBORDataFormat, ""},
 		{".cbor", CBORDataFormat, ""},
 		{".ndjson", NDJSONDataFormat, ""},
@@ -0,0 +1,92 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
+	"github.com/qri-io/qfs"
+)
+
+// ArchiveContents describes the members of an archive
+type ArchiveContents struct {
+	// Members lists all files in the archive
+	Members []archive.Member
+	// Data is the member most likely to be the dataset body, empty if no
+	// member has a recognized data format
+	Data string
+	// Readmes lists members that look like readme documents
+	Readmes []string
+}
+
+// Archive lists the members of an archive, picking the member most likely to
+// be the dataset body: the largest file with a recognized data format
+// extension. Hidden files & macOS resource forks are ignored
+func Archive(f archive.Format, r io.Reader) (*ArchiveContents, error) {
+	members, err := archive.Members(f, r)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &ArchiveContents{Members: members}
+	var dataSize int64 = -1
+	for _, m := range members {
+		if isHiddenMember(m.Name) {
+			continue
+		}
+		if isReadmeMember(m.Name) {
+			contents.Readmes = append(contents.Readmes, m.Name)
+			continue
+		}
+		if _, _, err := FormatFromFilename(m.Name); err == nil && m.Size > dataSize {
+			contents.Data = m.Name
+			dataSize = m.Size
+		}
+	}
+	return contents, nil
+}
+
+// ReadmeFromArchive creates a readme component from an archive member, for
+// importing documentation published alongside a dataset body
+func ReadmeFromArchive(f archive.Format, r io.Reader, member string) (*dataset.Readme, error) {
+	rc, err := archive.Open(f, r, member)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading readme: %w", err)
+	}
+
+	format := "md"
+	switch strings.ToLower(path.Ext(member)) {
+	case ".html", ".htm":
+		format = "html"
+	}
+	rm := &dataset.Readme{Format: format}
+	rm.SetScriptFile(qfs.NewMemfileBytes(path.Base(member), data))
+	return rm, nil
+}
+
+// isHiddenMember reports if an archive member is a hidden file or part of a
+// macOS resource fork
+func isHiddenMember(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".")
+}
+
+// isReadmeMember reports if an archive member looks like a readme document
+func isReadmeMember(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	switch path.Ext(base) {
+	case ".md", ".markdown":
+		return true
+	case "", ".txt", ".html", ".htm":
+		return strings.HasPrefix(base, "readme")
+	}
+	return false
+}
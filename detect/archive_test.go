@@ -0,0 +1,158 @@
+package detect
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
+	"github.com/qri-io/qfs"
+)
+
+func zipArchive(t *testing.T, files ...string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for i := 0; i < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(files[i+1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const archiveCSV = "Animal,Sound,Weight\ncat,meow,1.4\ndog,bark,3.7\n"
+
+func TestArchive(t *testing.T) {
+	data := zipArchive(t,
+		"README.md", "# Animals",
+		"__MACOSX/data/._animals.csv", "resource fork with more bytes than the data file, to be ignored",
+		"data/.hidden.csv", "a,b\n",
+		"data/animals.csv", archiveCSV,
+		"data/lookup.json", "[]",
+		"LICENSE", "public domain",
+	)
+
+	got, err := Archive(archive.FmtZip, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Data != "data/animals.csv" {
+		t.Errorf("data member mismatch. expected: %q, got: %q", "data/animals.csv", got.Data)
+	}
+	if diff := cmp.Diff([]string{"README.md"}, got.Readmes); diff != "" {
+		t.Errorf("readmes mismatch (-want +got):\n%s", diff)
+	}
+	if len(got.Members) != 6 {
+		t.Errorf("expected 6 members, got: %d", len(got.Members))
+	}
+
+	rm, err := ReadmeFromArchive(archive.FmtZip, bytes.NewReader(data), "README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rm.Format != "md" {
+		t.Errorf("readme format mismatch. expected: %q, got: %q", "md", rm.Format)
+	}
+	text, err := ioutil.ReadAll(rm.ScriptFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "# Animals" {
+		t.Errorf("readme text mismatch. got: %q", string(text))
+	}
+
+	empty := zipArchive(t, "README.md", "# Nothing here")
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("empty.zip", empty))
+	if err := Structure(ds); err == nil || err.Error() != "archive has no members with a recognized data format" {
+		t.Errorf("expected error detecting archive without data, got: %v", err)
+	}
+}
+
+func TestStructureArchive(t *testing.T) {
+	data := zipArchive(t,
+		"README.md", "# Animals",
+		"data/animals.csv", archiveCSV,
+	)
+
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("animals.zip", data))
+	if err := Structure(ds); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &dataset.Structure{
+		Format:        dataset.CSVDataFormat.String(),
+		Archive:       archive.FmtZip.String(),
+		ArchiveMember: "data/animals.csv",
+		FormatConfig: map[string]interface{}{
+			"headerRow": true,
+		},
+		Schema: mustParseJSONSchema([]byte(`{
+			"items":{
+				"items":[
+					{"title":"animal","type":"string"},
+					{"title":"sound","type":"string"},
+					{"title":"weight","type":"number"}
+				],
+				"type":"array"},
+				"type":"array"
+			}`)),
+	}
+	if diff := cmp.Diff(expect, ds.Structure); diff != "" {
+		t.Errorf("mismatched resulting structure (-want +got):\n%s", diff)
+	}
+
+	got, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, got) {
+		t.Error("expected body to be readable after detection")
+	}
+}
+
+func TestStructureTarMember(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, f := range [][2]string{{"data/animals.csv", archiveCSV}, {"notes.txt", "trailing member"}} {
+		if err := tw.WriteHeader(&tar.Header{Name: f[0], Mode: 0644, Size: int64(len(f[1]))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f[1]))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// members named by the structure are streamed from the archive
+	ds := &dataset.Dataset{Structure: &dataset.Structure{Archive: "tar", ArchiveMember: "data/animals.csv"}}
+	ds.SetBodyFile(qfs.NewMemfileBytes("animals.tar", data))
+	if err := Structure(ds); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.Format != dataset.CSVDataFormat.String() {
+		t.Errorf("format mismatch. expected: %q, got: %q", dataset.CSVDataFormat, ds.Structure.Format)
+	}
+
+	got, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, got) {
+		t.Error("expected body to be readable after detection")
+	}
+	if err := ds.BodyFile().Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
 )
 
 const (
@@ -18,23 +19,37 @@ const (
 
 // CBORSchema determines the field names and types of an io.Reader of CBOR-formatted data, returning a json schema
 func CBORSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
-	rd := bufio.NewReader(data)
-	bd, err := rd.ReadByte()
-	n++
+	schema, _, n, err = CBORSchemaWithOptions(resource, data, nil)
+	return
+}
+
+// CBORSchemaWithOptions determines the schema of an io.Reader of CBOR-formatted data using inference options,
+// sampling entries of the top level array or map to describe their properties & items
+func CBORSchemaWithOptions(resource *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
+	tr := dsio.NewTrackedReader(data)
+	rd := bufio.NewReader(tr)
+	peek, err := rd.Peek(1)
 	if err != nil && err != io.EOF {
 		log.Debugf(err.Error())
-		err = fmt.Errorf("error reading data: %s", err.Error())
-		return
+		return nil, nil, tr.BytesRead(), fmt.Errorf("error reading data: %s", err.Error())
+	}
+	var bd byte
+	if len(peek) > 0 {
+		bd = peek[0]
 	}
 
 	switch {
 	case bd >= cborBaseArray && bd < cborBaseMap, bd == cborBdIndefiniteArray:
-		return dataset.BaseSchemaArray, n, nil
+		schema = dataset.BaseSchemaArray
 	case bd >= cborBaseMap && bd < cborBaseTag, bd == cborBdIndefiniteMap:
-		return dataset.BaseSchemaObject, n, nil
+		schema = dataset.BaseSchemaObject
 	default:
 		err = fmt.Errorf("invalid top-level type for CBOR data. cbor datasets must begin with either an array or map")
 		log.Debugf(err.Error())
-		return
+		return nil, nil, tr.BytesRead(), err
 	}
+
+	st := &dataset.Structure{Format: dataset.CBORDataFormat.String(), Schema: schema}
+	schema, report, err = EntrySchema(st, rd, opts)
+	return schema, report, tr.BytesRead(), err
 }
@@ -0,0 +1,166 @@
+package detect
+
+import (
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset/vals"
+)
+
+// DefaultEnumLimit is the largest number of distinct values a string column
+// can have for constraint inference to propose an enum when options don't
+// set a limit
+const DefaultEnumLimit = 10
+
+// csvNullTokens are CSV cell values constraint inference reads as null,
+// including empty cells. Tokens found in the data are added to the CSV
+// nullTokens format config, so readers read them as null too
+var csvNullTokens = map[string]bool{
+	"":     true,
+	"NA":   true,
+	"N/A":  true,
+	"n/a":  true,
+	"NULL": true,
+	"null": true,
+	"None": true,
+	"nil":  true,
+	`\N`:   true,
+}
+
+// constraintTally tracks the ranges & distinct values of values at a
+// position, for proposing validation keywords
+type constraintTally struct {
+	numbers  int
+	min, max float64
+
+	strings        int
+	minLen, maxLen int
+	// values counts distinct strings, it's nil once there are more than limit
+	values map[string]int
+	limit  int
+}
+
+func newConstraintTally(enumLimit int) *constraintTally {
+	return &constraintTally{
+		values: map[string]int{},
+		limit:  enumLimit,
+	}
+}
+
+// addNumber tallies a numeric value
+func (c *constraintTally) addNumber(f float64) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return
+	}
+	if c.numbers == 0 || f < c.min {
+		c.min = f
+	}
+	if c.numbers == 0 || f > c.max {
+		c.max = f
+	}
+	c.numbers++
+}
+
+// addString tallies a string value
+func (c *constraintTally) addString(s string) {
+	l := utf8.RuneCountInString(s)
+	if c.strings == 0 || l < c.minLen {
+		c.minLen = l
+	}
+	if c.strings == 0 || l > c.maxLen {
+		c.maxLen = l
+	}
+	c.strings++
+	if c.values != nil {
+		c.values[s]++
+		if len(c.values) > c.limit {
+			c.values = nil
+		}
+	}
+}
+
+// merge adds the counts of another tally to this one
+func (c *constraintTally) merge(o *constraintTally) {
+	if o.numbers > 0 {
+		if c.numbers == 0 || o.min < c.min {
+			c.min = o.min
+		}
+		if c.numbers == 0 || o.max > c.max {
+			c.max = o.max
+		}
+		c.numbers += o.numbers
+	}
+	if o.strings > 0 {
+		if c.strings == 0 || o.minLen < c.minLen {
+			c.minLen = o.minLen
+		}
+		if c.strings == 0 || o.maxLen > c.maxLen {
+			c.maxLen = o.maxLen
+		}
+		c.strings += o.strings
+	}
+	if c.values == nil {
+		return
+	}
+	if o.values == nil {
+		c.values = nil
+		return
+	}
+	for s, n := range o.values {
+		c.values[s] += n
+	}
+	if len(c.values) > c.limit {
+		c.values = nil
+	}
+}
+
+// keywords proposes JSON schema validation keywords for values of types.
+// Numbers get minimum & maximum. Strings without a format get minLength &
+// maxLength, and columns of only strings get an enum when there are at most
+// limit distinct values that repeat on average. nullEnum adds null to enums
+// of nullable types
+func (c *constraintTally) keywords(types []vals.Type, f vals.Format, nullEnum bool) map[string]interface{} {
+	kw := map[string]interface{}{}
+	numeric, nullable, onlyStrings := false, false, true
+	for _, t := range types {
+		switch t {
+		case vals.TypeInteger, vals.TypeNumber:
+			numeric = true
+			onlyStrings = false
+		case vals.TypeNull:
+			nullable = true
+		case vals.TypeString:
+		default:
+			onlyStrings = false
+		}
+	}
+
+	if numeric && c.numbers > 0 {
+		kw["minimum"] = c.min
+		kw["maximum"] = c.max
+	}
+
+	if types[0] != vals.TypeString || f != vals.FormatNone || c.strings == 0 {
+		return kw
+	}
+	kw["minLength"] = float64(c.minLen)
+	kw["maxLength"] = float64(c.maxLen)
+
+	if onlyStrings && c.values != nil && len(c.values)*2 <= c.strings {
+		strs := make([]string, 0, len(c.values))
+		for s := range c.values {
+			strs = append(strs, s)
+		}
+		sort.Strings(strs)
+		enum := make([]interface{}, 0, len(strs)+1)
+		for _, s := range strs {
+			enum = append(enum, s)
+		}
+		if nullEnum && nullable {
+			enum = append(enum, nil)
+		}
+		kw["enum"] = enum
+	}
+	return kw
+}
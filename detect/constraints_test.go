@@ -0,0 +1,148 @@
+package detect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestCSVSchemaConstraints(t *testing.T) {
+	data := "id,size,color,note,score\n" +
+		"1,small,red,a,1.5\n" +
+		"2,large,blue,,NA\n" +
+		"3,small,red,ccc,-2\n" +
+		"4,large,,dd,\n" +
+		"5,small,red,e,3\n"
+
+	st := &dataset.Structure{Format: "csv"}
+	sch, _, _, err := CSVSchemaWithOptions(st, strings.NewReader(data), &Options{Constraints: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := mustParseJSONSchema([]byte(`{"type":"array","items":{"type":"array","items":[
+		{"title":"id","type":"integer","minimum":1,"maximum":5},
+		{"title":"size","type":"string","minLength":5,"maxLength":5,"enum":["large","small"]},
+		{"title":"color","type":["string","null"],"minLength":3,"maxLength":4,"enum":["blue","red",null]},
+		{"title":"note","type":["string","null"],"minLength":1,"maxLength":3},
+		{"title":"score","type":["number","null"],"minimum":-2,"maximum":3}
+	]}}`))
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]interface{}{"", "NA"}, st.FormatConfig["nullTokens"]); diff != "" {
+		t.Errorf("null tokens mismatch (-want +got):\n%s", diff)
+	}
+
+	// detected structures validate the data they were detected from
+	st.Schema = sch
+	r, err := dsio.NewEntryReader(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := dsio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsch, err := st.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := jsch.Validate(context.Background(), entries).Errs; errs != nil && len(*errs) > 0 {
+		t.Errorf("unexpected validation errors: %v", *errs)
+	}
+
+	// constraints are opt-in
+	st = &dataset.Structure{Format: "csv"}
+	sch, _, _, err = CSVSchemaWithOptions(st, strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = mustParseJSONSchema([]byte(`{"type":"array","items":{"type":"array","items":[
+		{"title":"id","type":"integer"},
+		{"title":"size","type":"string"},
+		{"title":"color","type":"string"},
+		{"title":"note","type":"string"},
+		{"title":"score","type":"number"}
+	]}}`))
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := st.FormatConfig["nullTokens"]; ok {
+		t.Error("expected no null tokens without constraints")
+	}
+}
+
+func TestCSVSchemaConstraintsSample(t *testing.T) {
+	buf := &strings.Builder{}
+	buf.WriteString("id,size\n")
+	rows := DefaultSampleSize + 500
+	for i := 0; i < rows; i++ {
+		size := "small"
+		if i%2 == 1 {
+			size = "large"
+		}
+		fmt.Fprintf(buf, "%d,%s\n", i, size)
+	}
+	data := buf.String()
+
+	// the sample doesn't cover every row, so ranges & enums could be wrong
+	st := &dataset.Structure{Format: "csv"}
+	sch, report, _, err := CSVSchemaWithOptions(st, strings.NewReader(data), &Options{Constraints: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Complete {
+		t.Error("expected an incomplete report")
+	}
+	expect := mustParseJSONSchema([]byte(`{"type":"array","items":{"type":"array","items":[
+		{"title":"id","type":"integer"},
+		{"title":"size","type":"string"}
+	]}}`))
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("sampled schema mismatch (-want +got):\n%s", diff)
+	}
+
+	st = &dataset.Structure{Format: "csv"}
+	sch, _, _, err = CSVSchemaWithOptions(st, strings.NewReader(data), &Options{Constraints: true, ScanAll: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = mustParseJSONSchema([]byte(fmt.Sprintf(`{"type":"array","items":{"type":"array","items":[
+		{"title":"id","type":"integer","minimum":0,"maximum":%d},
+		{"title":"size","type":"string","minLength":5,"maxLength":5,"enum":["large","small"]}
+	]}}`, rows-1)))
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("scanned schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONSchemaConstraints(t *testing.T) {
+	data := `[{"kind":"a","n":3,"tag":null},{"kind":"b","n":10,"tag":"x"},{"kind":"a","n":-1,"tag":"x"},{"kind":"a","n":2.5,"tag":"x"}]`
+	sch, _, _, err := JSONSchemaWithOptions(&dataset.Structure{}, strings.NewReader(data), &Options{Constraints: true, EnumLimit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := mustParseJSONSchema([]byte(`{"type":"array","items":{"type":"object","required":["kind","n","tag"],"properties":{
+		"kind":{"type":"string","minLength":1,"maxLength":1,"enum":["a","b"]},
+		"n":{"type":"number","minimum":-1,"maximum":10},
+		"tag":{"type":["string","null"],"minLength":1,"maxLength":1,"enum":["x",null]}
+	}}}`))
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	sch, _, _, err = JSONSchemaWithOptions(&dataset.Structure{}, strings.NewReader(data), &Options{Constraints: true, EnumLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kind := sch["items"].(map[string]interface{})["properties"].(map[string]interface{})["kind"].(map[string]interface{})
+	if _, ok := kind["enum"]; ok {
+		t.Error("expected no enum for values above the enum limit")
+	}
+}
@@ -0,0 +1,214 @@
+package detect
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+)
+
+// csvSniffSize is the number of bytes of CSV data examined to detect a dialect
+const csvSniffSize = 64 * 1024
+
+var (
+	// csvSeparators are candidate field separators, in order of preference
+	// when they split records equally well. tabs rarely appear in field
+	// values, so a consistent tab split beats commas within fields
+	csvSeparators = []rune{'\t', ',', ';', '|'}
+	// csvQuoteChars are candidate quote characters, in order of preference
+	csvQuoteChars = []rune{'"', '\''}
+)
+
+// CSVDialect detects the separator, quote & escape characters of a sample of
+// CSV data, and whether the sample needs lazy quote parsing. Separators are
+// scored by how consistently they split records into the same number of
+// fields. Options keep default values (comma separator, double quotes
+// escaped by doubling) unless the sample suggests otherwise
+func CSVDialect(sample []byte) *dataset.CSVOptions {
+	opts := &dataset.CSVOptions{}
+	quote := sniffCSVQuote(sample)
+	escape := sniffCSVEscape(sample, quote)
+
+	var (
+		sep        = ','
+		bestScore  float64
+		bestFields int
+	)
+	for _, cand := range csvSeparators {
+		score, fields := csvSeparatorScore(sample, cand, quote, escape)
+		if fields < 2 {
+			continue
+		}
+		if score > bestScore || (score == bestScore && fields > bestFields) {
+			sep, bestScore, bestFields = cand, score, fields
+		}
+	}
+
+	if sep != ',' {
+		opts.Separator = sep
+	}
+	if quote != '"' {
+		opts.QuoteChar = quote
+	}
+	opts.EscapeChar = escape
+	_, opts.LazyQuotes = csvRecordShape(sample, sep, quote, escape)
+	return opts
+}
+
+// readCSVSample reads up to csvSniffSize bytes from r, returning the sample,
+// a reader of the complete data and whether the sample is all of the data
+func readCSVSample(r io.Reader) (sample []byte, data io.Reader, complete bool, err error) {
+	sample = make([]byte, csvSniffSize)
+	n, err := io.ReadFull(r, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, false, err
+	}
+	complete = err != nil
+	sample = sample[:n]
+	return sample, io.MultiReader(bytes.NewReader(sample), r), complete, nil
+}
+
+// csvSeparatorScore gives the share of records that have the most common
+// field count when split by sep, and that field count
+func csvSeparatorScore(sample []byte, sep, quote, escape rune) (score float64, fields int) {
+	counts, _ := csvRecordShape(sample, sep, quote, escape)
+	if len(counts) == 0 {
+		return 0, 0
+	}
+	tally := map[int]int{}
+	for _, c := range counts {
+		tally[c]++
+	}
+	modal := 0
+	for c, n := range tally {
+		if n > tally[modal] || (n == tally[modal] && c > modal) {
+			modal = c
+		}
+	}
+	return float64(tally[modal]) / float64(len(counts)), modal
+}
+
+// csvRecordShape splits a sample into records, giving the number of fields in
+// each non-empty record and whether any quotes would need lazy parsing. A
+// trailing record that may have been cut off by the end of the sample is
+// ignored
+func csvRecordShape(sample []byte, sep, quote, escape rune) (counts []int, bareQuotes bool) {
+	var (
+		fields     = 1
+		fieldStart = true
+		quoted     = false
+		empty      = true
+	)
+	endRecord := func() {
+		if !empty {
+			counts = append(counts, fields)
+		}
+		fields, fieldStart, empty = 1, true, true
+	}
+
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		i += size
+
+		if quoted {
+			switch {
+			case escape != 0 && r == escape && i < len(sample):
+				_, size := utf8.DecodeRune(sample[i:])
+				i += size
+			case r == quote:
+				next, size := utf8.DecodeRune(sample[i:])
+				if i < len(sample) && next == quote {
+					i += size
+				} else {
+					quoted = false
+					if i < len(sample) && next != sep && next != '\n' && next != '\r' {
+						bareQuotes = true
+					}
+				}
+			}
+			continue
+		}
+
+		switch {
+		case r == '\n' || r == '\r':
+			endRecord()
+		case r == sep:
+			fields++
+			fieldStart = true
+			empty = false
+		case r == ' ' && fieldStart:
+		case r == quote && fieldStart:
+			quoted = true
+			fieldStart = false
+			empty = false
+		case r == quote:
+			bareQuotes = true
+		default:
+			fieldStart = false
+			empty = false
+		}
+	}
+
+	// a final record without a line ending may be cut off, unless it's the
+	// only record
+	if !empty && !quoted && len(counts) == 0 {
+		counts = append(counts, fields)
+	}
+	return counts, bareQuotes
+}
+
+// sniffCSVQuote picks the quote character that most often wraps whole fields
+func sniffCSVQuote(sample []byte) rune {
+	quote, best := '"', 0
+	for _, q := range csvQuoteChars {
+		n := 0
+		for _, sep := range csvSeparators {
+			n += bytes.Count(sample, []byte(string(sep)+string(q)))
+			n += bytes.Count(sample, []byte(string(q)+string(sep)))
+		}
+		n += bytes.Count(sample, []byte("\n"+string(q)))
+		n += bytes.Count(sample, []byte(string(q)+"\n"))
+		if n > best {
+			quote, best = q, n
+		}
+	}
+	return quote
+}
+
+// sniffCSVEscape reports a backslash escape character if the sample escapes
+// quotes with backslashes rather than doubling them
+func sniffCSVEscape(sample []byte, quote rune) rune {
+	escaped := []byte(`\` + string(quote))
+	doubled := []byte(string(quote) + string(quote))
+	// \" at the end of a field is more likely a field ending in a backslash
+	n := 0
+	for i := bytes.Index(sample, escaped); i >= 0; {
+		end := i + len(escaped)
+		if end < len(sample) && !isCSVBoundary(rune(sample[end])) {
+			n++
+		}
+		next := bytes.Index(sample[end:], escaped)
+		if next < 0 {
+			break
+		}
+		i = end + next
+	}
+	if n > 0 && n > bytes.Count(sample, doubled) {
+		return '\\'
+	}
+	return 0
+}
+
+// isCSVBoundary reports if r ends a field for any candidate separator
+func isCSVBoundary(r rune) bool {
+	if r == '\n' || r == '\r' {
+		return true
+	}
+	for _, sep := range csvSeparators {
+		if r == sep {
+			return true
+		}
+	}
+	return false
+}
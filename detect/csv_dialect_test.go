@@ -0,0 +1,103 @@
+package detect
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestCSVDialect(t *testing.T) {
+	cases := []struct {
+		description string
+		sample      string
+		expect      *dataset.CSVOptions
+	}{
+		{"comma", "a,b,c\n1,2,3\n4,5,6\n", &dataset.CSVOptions{}},
+		{"semicolon with decimal commas", "name;price;qty\nfoo;1,5;2\nbar;2,25;10\nbaz;3;1\n", &dataset.CSVOptions{Separator: ';'}},
+		{"tab", "name\tcity, state\nalice\tPortland, OR\nbob\tAustin, TX\n", &dataset.CSVOptions{Separator: '\t'}},
+		{"pipe", "id|note\n1|hello\n2|goodbye\n", &dataset.CSVOptions{Separator: '|'}},
+		{"quoted separators", "a,b\n\"x;y;z\",1\n\"p;q;r\",2\n", &dataset.CSVOptions{}},
+		{"single quotes", "'name','note'\n'alice','hi, there'\n'bob','bye, now'\n", &dataset.CSVOptions{QuoteChar: '\''}},
+		{"backslash escapes", "id,quote\n1,\"she said \\\"hi\\\" to me\"\n2,\"a \\\"b\\\" c\"\n", &dataset.CSVOptions{EscapeChar: '\\'}},
+		{"doubled quotes", "id,quote\n1,\"she said \"\"hi\"\" to me\"\n", &dataset.CSVOptions{}},
+		{"bare quotes", "item,size\ntv,55\" screen\nmonitor,27\" screen\n", &dataset.CSVOptions{LazyQuotes: true}},
+		{"single column", "one\ntwo\nthree\n", &dataset.CSVOptions{}},
+		{"empty", "", &dataset.CSVOptions{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			got := CSVDialect([]byte(c.sample))
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSVSchemaDialect(t *testing.T) {
+	data := []byte("name;price;note\nfoo;1,5;'semi;colon'\nbar;2,25;'it''s'\nbaz;3;'x'\n")
+	st := &dataset.Structure{Format: "csv"}
+	sch, _, err := CSVSchema(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectConfig := map[string]interface{}{
+		"headerRow": true,
+		"separator": ";",
+		"quoteChar": "'",
+	}
+	if diff := cmp.Diff(expectConfig, st.FormatConfig); diff != "" {
+		t.Errorf("format config mismatch (-want +got):\n%s", diff)
+	}
+
+	expectSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "price", "type": "string"},
+				map[string]interface{}{"title": "note", "type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectSchema, sch); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCSVSchemaLazyQuotesPastSample(t *testing.T) {
+	b := &strings.Builder{}
+	b.WriteString("id,note\n")
+	for i := 0; b.Len() < csvSniffSize; i++ {
+		fmt.Fprintf(b, "%d,plain\n", i)
+	}
+	// a bare quote the sample doesn't reach
+	b.WriteString("-1,5\" pipe\n")
+	data := b.String()
+
+	st := &dataset.Structure{Format: "csv"}
+	sch, _, err := CSVSchema(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.FormatConfig["lazyQuotes"] != true {
+		t.Errorf("expected lazyQuotes for data longer than the sample, got config: %v", st.FormatConfig)
+	}
+
+	st.Schema = sch
+	r, err := dsio.NewEntryReader(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dsio.ReadAll(r); err != nil {
+		t.Errorf("reading with detected options: %v", err)
+	}
+}
@@ -1,10 +1,10 @@
 package detect
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -12,7 +12,9 @@ import (
 
 	logger "github.com/ipfs/go-log"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
 	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
 )
 
@@ -43,18 +45,57 @@ func Structure(ds *dataset.Dataset) error {
 	if body == nil {
 		return dataset.ErrNoBody
 	}
-	// use a TeeReader that writes to a buffer to preserve data
-	buf := &bytes.Buffer{}
+	// use a TeeReader that writes to a buffer to preserve data. buffers spill
+	// to disk when reading archives requires more than a sample of the body
+	buf := dsio.NewSpillBuffer(0)
+	defer buf.Close()
 	tr := io.TeeReader(body, buf)
-	var df dataset.DataFormat
+	var (
+		df   dataset.DataFormat
+		data io.Reader = tr
+		name           = body.FileName()
+	)
 
-	df, comp, err := FormatFromFilename(body.FileName())
+	// archived bodies are read from a member of the archive, either named by
+	// the structure or picked by examining the archive's members
+	af, member, err := archiveMember(ds.Structure, name)
 	if err != nil {
-		log.Debug(err.Error())
-		return fmt.Errorf("invalid data format: %w", err)
+		return err
+	}
+	if af != archive.FmtNone {
+		rc, err := openArchiveMember(af, tr, buf, member)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		member = rc.member
+		data, name = rc, member
+	}
+
+	df, comp, err := FormatFromFilename(name)
+	if err != nil {
+		// filenames like "data" or "upload.bin" can still be read when the
+		// structure gives a data format
+		if ds.Structure == nil || ds.Structure.DataFormat() == dataset.UnknownDataFormat {
+			log.Debug(err.Error())
+			return fmt.Errorf("invalid data format: %w", err)
+		}
+		df = ds.Structure.DataFormat()
 	}
 
-	guessedStructure, _, err := FromReader(df, comp, tr)
+	// without a compression extension use any compression the structure gives,
+	// falling back to detecting compression from the leading bytes of the body
+	if comp == compression.FmtNone {
+		if ds.Structure != nil && ds.Structure.Compression != "" {
+			if comp, err = compression.ParseFormat(ds.Structure.Compression); err != nil {
+				return err
+			}
+		} else if comp, data, err = compression.DetectFormat(data); err != nil {
+			return err
+		}
+	}
+
+	guessedStructure, _, err := FromReader(df, comp, data)
 	if err != nil {
 		log.Debug(err.Error())
 		return fmt.Errorf("determining dataset structure: %w", err)
@@ -67,9 +108,16 @@ func Structure(ds *dataset.Dataset) error {
 	if ds.Structure.Format == "" {
 		ds.Structure.Format = guessedStructure.Format
 	}
+	if ds.Structure.Archive == "" && af != archive.FmtNone {
+		ds.Structure.Archive = af.String()
+		ds.Structure.ArchiveMember = member
+	}
 	if ds.Structure.Compression == "" {
 		ds.Structure.Compression = guessedStructure.Compression
 	}
+	if ds.Structure.Encoding == "" {
+		ds.Structure.Encoding = guessedStructure.Encoding
+	}
 	if ds.Structure.FormatConfig == nil && ds.Structure.Format == guessedStructure.Format {
 		ds.Structure.FormatConfig = guessedStructure.FormatConfig
 	}
@@ -84,10 +132,91 @@ func Structure(ds *dataset.Dataset) error {
 	if sizef, ok := body.(qfs.SizeFile); ok {
 		size = sizef.Size()
 	}
-	ds.SetBodyFile(qfs.NewMemfileReaderSize(body.FileName(), io.MultiReader(buf, body), size))
+	replay, err := buf.NewReader()
+	if err != nil {
+		return err
+	}
+	restored := replayReader{Reader: io.MultiReader(replay, body), closers: []io.Closer{replay, body}}
+	ds.SetBodyFile(qfs.NewMemfileReaderSize(body.FileName(), restored, size))
 	return nil
 }
 
+// archiveReader reads a member of an archive
+type archiveReader struct {
+	io.ReadCloser
+	member string
+}
+
+// openArchiveMember opens a member of an archive read from r, which tees
+// into buf. Tar members are streamed from r when the member is known. zip
+// archives & tar archives without a member are read in full into buf,
+// listing members to pick one
+func openArchiveMember(af archive.Format, r io.Reader, buf *dsio.SpillBuffer, member string) (*archiveReader, error) {
+	if member != "" && af != archive.FmtZip {
+		rc, err := archive.Open(af, r, member)
+		if err != nil {
+			return nil, err
+		}
+		return &archiveReader{rc, member}, nil
+	}
+
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return nil, err
+	}
+	if member == "" {
+		sr, err := buf.NewReader()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := Archive(af, sr)
+		sr.Close()
+		if err != nil {
+			return nil, err
+		}
+		if contents.Data == "" {
+			return nil, fmt.Errorf("archive has no members with a recognized data format")
+		}
+		member = contents.Data
+	}
+
+	sr, err := buf.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	rc, err := archive.Open(af, sr, member)
+	if err != nil {
+		sr.Close()
+		return nil, err
+	}
+	return &archiveReader{replayReader{Reader: rc, closers: []io.Closer{rc, sr}}, member}, nil
+}
+
+// replayReader reads bytes replayed from a buffer, closing every closer
+type replayReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes each closer, returning the first error
+func (r replayReader) Close() (err error) {
+	for _, c := range r.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// archiveMember gives the archive format & member of a body from it's
+// structure, falling back to the body filename extension
+func archiveMember(st *dataset.Structure, filename string) (archive.Format, string, error) {
+	if st != nil && st.Archive != "" {
+		f, err := archive.ParseFormat(st.Archive)
+		return f, st.ArchiveMember, err
+	}
+	return archive.FormatFromFilename(filename), "", nil
+}
+
 // needsFormatConfig returns true if a given structure needs a FormatConfig
 // field and doesn't have one
 // This only returns true for formats that are known to need a FormatConfig,
@@ -97,6 +226,12 @@ func needsFormatConfig(st *dataset.Structure) bool {
 	case dataset.XLSXDataFormat.String():
 		// XLSX should always have a format config
 		return st.FormatConfig == nil
+	case dataset.ODSDataFormat.String():
+		// ODS should always have a format config
+		return st.FormatConfig == nil
+	case dataset.FixedWidthDataFormat.String():
+		// fixed-width data needs column positions
+		return st.FormatConfig == nil
 	case dataset.CSVDataFormat.String():
 		// CSVs should always have a format config
 		return st.FormatConfig == nil
@@ -122,18 +257,47 @@ func FromFile(path string) (st *dataset.Structure, err error) {
 		return nil, err
 	}
 
-	st, _, err = FromReader(format, comp, f)
+	var data io.Reader = f
+	if comp == compression.FmtNone {
+		if comp, data, err = compression.DetectFormat(f); err != nil {
+			return nil, err
+		}
+	}
+
+	st, _, err = FromReader(format, comp, data)
 	return st, err
 }
 
 // FromReader detects a dataset structure from a reader and data format, returning a detected dataset
-// structure, the number of bytes read from the reader, and any error
+// structure, the number of bytes read from the reader, and any error. Compressed data is decompressed
+// before detection, and the number of bytes read counts decompressed bytes
 func FromReader(format dataset.DataFormat, comp compression.Format, data io.Reader) (st *dataset.Structure, n int, err error) {
+	st, _, n, err = FromReaderWithOptions(format, comp, data, nil)
+	return
+}
+
+// FromReaderWithOptions detects a dataset structure like FromReader, using
+// inference options for the schema. Tabular formats also give a report of the
+// values each column's type is based on
+func FromReaderWithOptions(format dataset.DataFormat, comp compression.Format, data io.Reader, opts *Options) (st *dataset.Structure, report *Report, n int, err error) {
 	st = &dataset.Structure{
 		Format:      format.String(),
 		Compression: comp.String(),
 	}
-	st.Schema, n, err = Schema(st, data)
+	if comp != compression.FmtNone {
+		dr, err := compression.Decompressor(comp.String(), data)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		defer dr.Close()
+		data = dr
+	}
+	if isTextFormat(format) {
+		if data, err = detectEncoding(st, data); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	st.Schema, report, n, err = SchemaWithOptions(st, data, opts)
 	return
 }
 
@@ -143,7 +307,7 @@ func FromReader(format dataset.DataFormat, comp compression.Format, data io.Read
 func FormatFromFilename(path string) (dataset.DataFormat, compression.Format, error) {
 	ext := filepath.Ext(path)
 
-	compFmt, e := compression.ParseFormat(strings.TrimPrefix(ext, "."))
+	compFmt, e := compression.ParseExtension(ext)
 	if e == nil {
 		ext = filepath.Ext(strings.TrimSuffix(path, ext))
 	} else {
@@ -161,6 +325,10 @@ func FormatFromFilename(path string) (dataset.DataFormat, compression.Format, er
 		return dataset.XMLDataFormat, compFmt, nil
 	case ".xlsx":
 		return dataset.XLSXDataFormat, compFmt, nil
+	case ".ods":
+		return dataset.ODSDataFormat, compFmt, nil
+	case ".fwf":
+		return dataset.FixedWidthDataFormat, compFmt, nil
 	case ".jsonl":
 		return dataset.NDJSONDataFormat, compFmt, nil
 	case ".ndjson":
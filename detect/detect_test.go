@@ -31,8 +31,7 @@ func TestStructure(t *testing.T) {
 	expect := &dataset.Structure{
 		Format: dataset.CSVDataFormat.String(),
 		FormatConfig: map[string]interface{}{
-			"headerRow":  true,
-			"lazyQuotes": true,
+			"headerRow": true,
 		},
 		Schema: mustParseJSONSchema([]byte(`{
 			"items":{
@@ -117,6 +116,59 @@ func TestStructure(t *testing.T) {
 	}
 }
 
+func TestStructureDetectCompression(t *testing.T) {
+	body := &bytes.Buffer{}
+	w, err := compression.Compressor(compression.FmtGZip.String(), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("Animal,Sound,Weight\ncat,meow,1.4\ndog,bark,3.7\n"))
+	w.Close()
+
+	ds := &dataset.Dataset{
+		Structure: &dataset.Structure{Format: dataset.CSVDataFormat.String()},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("upload.bin", body.Bytes()))
+	if err := Structure(ds); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &dataset.Structure{
+		Format:      dataset.CSVDataFormat.String(),
+		Compression: compression.FmtGZip.String(),
+		FormatConfig: map[string]interface{}{
+			"headerRow": true,
+		},
+		Schema: mustParseJSONSchema([]byte(`{
+			"items":{
+				"items":[
+					{"title":"animal","type":"string"},
+					{"title":"sound","type":"string"},
+					{"title":"weight","type":"number"}
+				],
+				"type":"array"},
+				"type":"array"
+			}`)),
+	}
+	if diff := cmp.Diff(expect, ds.Structure); diff != "" {
+		t.Errorf("mismatched resulting structure (-want +got):\n%s", diff)
+	}
+
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body.Bytes(), data) {
+		t.Error("expected body file to be readable from the start after detection")
+	}
+
+	ds = &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("upload.bin", body.Bytes()))
+	if err := Structure(ds); err == nil {
+		t.Error("expected a body without a data format to error")
+	}
+}
+
 func TestFromFile(t *testing.T) {
 	cases := []struct {
 		inpath, dspath string
@@ -129,8 +181,8 @@ func TestFromFile(t *testing.T) {
 		{"testdata/daily_wind_2011.csv", "testdata/daily_wind_2011.structure.json", ""},
 		{"testdata/sitemap_array.json", "testdata/sitemap_array.structure.json", ""},
 		{"testdata/sitemap_object.json", "testdata/sitemap_object.structure.json", ""},
-		{"testdata/array.json", "testdata/sitemap_array.structure.json", ""},
-		{"testdata/object.json", "testdata/sitemap_object.structure.json", ""},
+		{"testdata/array.json", "testdata/array.structure.json", ""},
+		{"testdata/object.json", "testdata/object.structure.json", ""},
 
 		{"testdata/invalid.cbor", "", "invalid top-level type for CBOR data. cbor datasets must begin with either an array or map"},
 		{"testdata/cbor_object.cbor", "testdata/cbor_object.structure.json", ""},
@@ -192,6 +244,8 @@ func TestFormatFromFilename(t *testing.T) {
 		{"foo/bar/baz.json", dataset.JSONDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.xml", dataset.XMLDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.xlsx", dataset.XLSXDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.ods", dataset.ODSDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.fwf", dataset.FixedWidthDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.cbor", dataset.CBORDataFormat, compression.FmtNone, ""},
 
 		{"foo/bar/baz.csv.zst", dataset.CSVDataFormat, compression.FmtZStandard, ""},
@@ -200,6 +254,13 @@ func TestFormatFromFilename(t *testing.T) {
 		{"foo/bar/baz.cbor", dataset.CBORDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.jsonl", dataset.NDJSONDataFormat, compression.FmtNone, ""},
 		{"foo/bar/baz.ndjson", dataset.NDJSONDataFormat, compression.FmtNone, ""},
+		{"foo/bar/baz.csv.gz", dataset.CSVDataFormat, compression.FmtGZip, ""},
+		{"foo/bar/baz.csv.xz", dataset.CSVDataFormat, compression.FmtXZ, ""},
+		{"foo/bar/baz.csv.bz2", dataset.CSVDataFormat, compression.FmtBZip2, ""},
+		{"foo/bar/baz.ndjson.lz4", dataset.NDJSONDataFormat, compression.FmtLZ4, ""},
+		{"foo/bar/baz.ndjson.sz", dataset.NDJSONDataFormat, compression.FmtSnappy, ""},
+		{"foo/bar/baz.ndjson.snappy", dataset.NDJSONDataFormat, compression.FmtSnappy, ""},
+		{"foo/bar/baz.json.br", dataset.JSONDataFormat, compression.FmtBrotli, ""},
 
 		{"foo/bar/baz.xml.blarg", dataset.UnknownDataFormat, compression.FmtNone, "unsupported file type: '.blarg'"},
 		{"foo/bar/baz", dataset.UnknownDataFormat, compression.FmtNone, "no file extension provided"},
@@ -1,19 +1,16 @@
 package detect
 
 import (
-	"encoding/csv"
-	"encoding/json"
+	"bytes"
 	"fmt"
 	"io"
 	"regexp"
 	"sort"
-	"strings"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/dataset/dsio/replacecr"
 	"github.com/qri-io/dataset/vals"
-	"github.com/qri-io/varName"
 )
 
 var (
@@ -22,125 +19,272 @@ var (
 
 // Schema determines the schema of a given reader for a given structure
 func Schema(r *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	schema, _, n, err = SchemaWithOptions(r, data, nil)
+	return
+}
+
+// SchemaWithOptions determines the schema of a given reader for a given
+// structure using inference options. Formats that infer column or entry types
+// also give a report of the values those types are based on
+func SchemaWithOptions(r *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
 	if r.DataFormat() == dataset.UnknownDataFormat {
 		err = fmt.Errorf("dataset format must be specified to determine schema")
 		log.Infof(err.Error())
 		return
 	}
+	if err = opts.check(); err != nil {
+		return
+	}
 
 	switch r.DataFormat() {
 	case dataset.CBORDataFormat:
-		return CBORSchema(r, data)
+		return CBORSchemaWithOptions(r, data, opts)
 	case dataset.JSONDataFormat:
-		return JSONSchema(r, data)
+		return JSONSchemaWithOptions(r, data, opts)
 	case dataset.CSVDataFormat:
-		return CSVSchema(r, data)
+		return CSVSchemaWithOptions(r, data, opts)
 	case dataset.XLSXDataFormat:
-		return XLSXSchema(r, data)
+		return XLSXSchemaWithOptions(r, data, opts)
+	case dataset.ODSDataFormat:
+		return ODSSchemaWithOptions(r, data, opts)
+	case dataset.FixedWidthDataFormat:
+		return FixedWidthSchemaWithOptions(r, data, opts)
 	case dataset.NDJSONDataFormat:
-		return NDJSONSchema(r, data)
+		return NDJSONSchemaWithOptions(r, data, opts)
 	default:
 		err = fmt.Errorf("%q is not supported for field detection", r.Format)
-		return
 	}
-}
-
-type field struct {
-	Title string    `json:"title,omitempty"`
-	Type  vals.Type `json:"type,omitempty"`
+	return
 }
 
 // CSVSchema determines the field names and types of an io.Reader of CSV-formatted data, returning a json schema
 func CSVSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
-	tr := dsio.NewTrackedReader(data)
-	r := csv.NewReader(replacecr.Reader(tr))
-	r.FieldsPerRecord = -1
-	r.TrimLeadingSpace = true
-	r.LazyQuotes = true
+	schema, _, n, err = CSVSchemaWithOptions(resource, data, nil)
+	return
+}
 
-	opt := map[string]interface{}{
-		// TODO - for now we're going to assume lazy quotes. we should scan the entire file
-		// for unescaped quotes & only set this to true if that's the case.
-		"lazyQuotes": true,
+// CSVSchemaWithOptions determines the field names and types of an io.Reader
+// of CSV-formatted data using inference options, returning a json schema and
+// a report of the values each field's type is based on
+func CSVSchemaWithOptions(resource *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
+	if err = opts.check(); err != nil {
+		return nil, nil, 0, err
 	}
-	resource.FormatConfig = opt
 
-	header, err := r.Read()
+	tr := dsio.NewTrackedReader(data)
+	sample, src, complete, err := readCSVSample(replacecr.Reader(tr))
 	if err != nil {
-		return nil, tr.BytesRead(), err
+		return nil, nil, tr.BytesRead(), err
 	}
 
-	fields := make([]*field, len(header))
-	types := make([]map[vals.Type]int, len(header))
-
-	for i := range fields {
-		fields[i] = &field{
-			Title: fmt.Sprintf("field_%d", i+1),
-			Type:  vals.TypeUnknown,
-		}
-		types[i] = map[vals.Type]int{}
+	dialect := CSVDialect(sample)
+	if !complete {
+		// quotes past the sample are unchecked, keep reading them leniently
+		dialect.LazyQuotes = true
 	}
+	opt := dialect.Map()
+	resource.FormatConfig = opt
 
-	if possibleCsvHeaderRow(header) {
-		for i, f := range fields {
-			f.Title = varName.CreateVarNameFromString(header[i])
-			f.Type = vals.TypeUnknown
-		}
-		opt["headerRow"] = true
-	} else {
-		for i, cell := range header {
-			types[i][vals.ParseType([]byte(cell))]++
-		}
+	// read leniently to detect as much as possible, the detected dialect
+	// records whether readers need lazy quotes
+	r := dsio.NewCSVRecordReader(src, &dataset.CSVOptions{
+		Separator:      dialect.Separator,
+		QuoteChar:      dialect.QuoteChar,
+		EscapeChar:     dialect.EscapeChar,
+		LazyQuotes:     true,
+		VariadicFields: true,
+		TrimSpace:      true,
+	})
+
+	var nullTokens map[string]bool
+	if opts.constraints() {
+		nullTokens = csvNullTokens
 	}
 
-	count := 0
-	for {
+	next := func() ([]string, error) {
 		rec, err := r.Read()
-		// max out at 2000 reads
-		if count > 2000 {
-			break
-		}
 		if err != nil {
-			if err.Error() == "EOF" {
-				break
+			if err == io.EOF {
+				return nil, err
 			}
-			return nil, tr.BytesRead(), fmt.Errorf("error reading csv file: %s", err.Error())
+			return nil, fmt.Errorf("error reading csv file: %s", err.Error())
 		}
+		// readers reuse record slices
+		return append([]string(nil), rec...), nil
+	}
+	table, err := inferTable(next, opts, nullTokens, false)
+	if err != nil {
+		return nil, nil, tr.BytesRead(), err
+	}
 
-		if len(rec) == len(types) {
-			for i, cell := range rec {
-				types[i][vals.ParseType([]byte(cell))]++
-			}
-			count++
+	// skipped lines come before the header, so header rows above the last
+	// are skipped too
+	records := table.header.Skip
+	if table.header.Rows > 1 {
+		records += table.header.Rows - 1
+	}
+	if skip := csvRecordLines(sample, records); skip > 0 {
+		opt["skipInitialRows"] = skip
+	}
+	if table.header.Rows > 0 {
+		opt["headerRow"] = true
+	}
+	if table.ragged {
+		opt["variadicFields"] = true
+	}
+	if len(table.nullTokens) > 0 {
+		opt["nullTokens"] = table.nullTokens
+	}
+
+	return table.schema, table.report, tr.BytesRead(), nil
+}
+
+// csvRecordLines gives the number of lines before a record of a csv sample,
+// counting the blank lines csv readers pass over. records before it are
+// expected to fit on one line
+func csvRecordLines(sample []byte, record int) int {
+	lines := 0
+	for record > 0 && len(sample) > 0 {
+		line := sample
+		if i := bytes.IndexByte(sample, '\n'); i >= 0 {
+			line, sample = sample[:i], sample[i+1:]
 		} else {
-			opt["variadicFields"] = true
+			sample = nil
+		}
+		lines++
+		if len(bytes.TrimRight(line, "\r")) > 0 {
+			record--
 		}
 	}
+	return lines
+}
 
-	for i, tally := range types {
-		// Iterate keys in a deterministic manner.
-		keys := getKeys(tally)
-		for _, typ := range keys {
-			count := tally[typ]
-			if count > tally[fields[i].Type] {
-				fields[i].Type = typ
-			}
+// tableInference is the result of inferring a schema from rows of text cells
+type tableInference struct {
+	schema map[string]interface{}
+	report *Report
+	// header gives the junk & header rows found above the table
+	header HeaderLayout
+	// ragged is true if any row has a different number of cells than the table
+	ragged bool
+	// nullTokens are the null tokens found in cells, sorted
+	nullTokens []interface{}
+}
+
+// inferTable infers a tabular schema from rows of text cells read with next,
+// which returns io.EOF after the last row. Junk & header rows are detected
+// from the leading rows, rows below the header are tallied up to the sample
+// size. nullTokens are cell values that read as null, which may be nil.
+// sparse rows leave out trailing empty cells, like spreadsheet rows
+func inferTable(next func() ([]string, error), opts *Options, nullTokens map[string]bool, sparse bool) (*tableInference, error) {
+	var (
+		lead [][]string
+		eof  bool
+	)
+	for len(lead) < headerSampleRows {
+		row, err := next()
+		if err == io.EOF {
+			eof = true
+			break
+		} else if err != nil {
+			return nil, err
 		}
+		lead = append(lead, row)
+	}
+	if len(lead) == 0 {
+		return nil, io.EOF
 	}
 
-	// TODO - lol what a hack. fix everything, put it in jsonschema.
-	items, err := json.Marshal(fields)
-	if err != nil {
-		return nil, tr.BytesRead(), fmt.Errorf("error marshaling csv fields to json: %s", err.Error())
+	table := &tableInference{
+		header: DetectHeader(lead),
+		report: &Report{},
+	}
+	// tables are as wide as their header, or the rows below it
+	width := tableWidth(lead[table.header.Skip:], sparse)
+	if h := table.header; h.Rows > 0 && !sparse {
+		width = len(lead[h.Skip+h.Rows-1])
+	}
+	titles := columnTitles(table.header, width)
+	tallies := make([]*columnTally, width)
+	for i := range tallies {
+		tallies[i] = newColumnTally(opts, nullTokens)
 	}
-	schstr := fmt.Sprintf(`{"type":"array","items":{"type":"array","items":%s}}`, string(items))
 
-	sch := map[string]interface{}{}
-	if err := json.Unmarshal([]byte(schstr), &sch); err != nil {
-		return nil, tr.BytesRead(), err
+	limit := opts.sampleSize()
+	tallyRow := func(row []string) {
+		table.report.Rows++
+		for sparse && len(row) < len(tallies) {
+			row = append(row, "")
+		}
+		if len(row) != len(tallies) {
+			table.ragged = true
+			return
+		}
+		for i, cell := range row {
+			tallies[i].add(cell)
+		}
+	}
+	// header rows don't count towards the sample size
+	body := lead[table.header.Skip+table.header.Rows:]
+	for len(body) > 0 && table.report.Rows != limit {
+		tallyRow(body[0])
+		body = body[1:]
+	}
+	for len(body) == 0 && !eof && (limit < 0 || table.report.Rows < limit) {
+		row, err := next()
+		if err == io.EOF {
+			eof = true
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		tallyRow(row)
+	}
+	if len(body) == 0 && !eof {
+		// data that ends right at the sample size was still read completely
+		_, err := next()
+		eof = err == io.EOF
+	}
+	table.report.Complete = eof && len(body) == 0
+
+	items := make([]interface{}, len(tallies))
+	tokens := map[string]bool{}
+	for i, tally := range tallies {
+		col, colReport := tally.column(titles[i], opts, table.report.Complete)
+		items[i] = col
+		table.report.Columns = append(table.report.Columns, colReport)
+		// null tokens & empty cells are only null in nullable columns
+		if !colReport.nullable() {
+			continue
+		}
+		for token := range tally.tokens {
+			tokens[token] = true
+		}
+		for cell := range tally.empty {
+			tokens[cell] = true
+		}
+	}
+
+	if len(tokens) > 0 {
+		sorted := make([]string, 0, len(tokens))
+		for token := range tokens {
+			sorted = append(sorted, token)
+		}
+		sort.Strings(sorted)
+		table.nullTokens = make([]interface{}, len(sorted))
+		for i, token := range sorted {
+			table.nullTokens[i] = token
+		}
 	}
 
-	return sch, tr.BytesRead(), nil
+	table.schema = map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}
+	return table, nil
 }
 
 func getKeys(m map[vals.Type]int) []vals.Type {
@@ -153,29 +297,3 @@ func getKeys(m map[vals.Type]int) []vals.Type {
 	})
 	return keys
 }
-
-// PossibleHeaderRow makes an educated guess about weather or not this csv file has a header row.
-// If this returns true, a determination about weather this data contains a header row should be
-// made by comparing with the destination schema.
-// This is because it's not totally possible to determine if csv data has a header row based on the
-// data alone.
-// For example, if all columns are a string data type, and all fields in the first row
-// are provided, it isn't possible to distinguish between a header row and an entry
-func possibleCsvHeaderRow(header []string) bool {
-	for _, rawCol := range header {
-		col := strings.TrimSpace(rawCol)
-		if _, err := vals.ParseInteger([]byte(col)); err == nil {
-			// if the row contains valid numeric data, we out.
-			return false
-		} else if _, err := vals.ParseNumber([]byte(col)); err == nil {
-			return false
-		} else if col == "" {
-			// empty columns can't be headers
-			return false
-		} else if col == "true" || col == "false" {
-			// true & false are keywords, and cannot be headers
-			return false
-		}
-	}
-	return true
-}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/validate"
 )
 
 var egCorruptCsvData = []byte(`
@@ -154,3 +157,66 @@ func runTestCase(t *testing.T, description string, input []byte, expect map[stri
 		t.Errorf("mismatch for \"%s\" (-want +got):\n%s\n", description, diff)
 	}
 }
+
+// bodyErrors reads a body with a detected structure & validates it against
+// the detected schema
+func bodyErrors(t *testing.T, st *dataset.Structure, data []byte) []validate.EntryError {
+	t.Helper()
+	r, err := dsio.NewEntryReader(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := validate.EntryErrors(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return errs
+}
+
+func TestCSVSchemaFormatsValidate(t *testing.T) {
+	data := []byte(`updated,local,alarm,clock
+2021-06-01T12:30:00Z,2011-01-01 10:00:00,07:30:00Z,10:00
+2021-06-02T08:00:00-07:00,2011-01-02T10:00,22:15:00+02:00,10:00:00
+`)
+	st, _, err := FromReader(dataset.CSVDataFormat, compression.FmtNone, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := bodyErrors(t, st, data); len(errs) != 0 {
+		t.Errorf("expected detected body to validate, got: %v", errs)
+	}
+}
+
+func TestCSVSchemaFormats(t *testing.T) {
+	data := []byte(`id,born,updated,alarm,email,site,note,mixed,local
+123e4567-e89b-12d3-a456-426614174000,25/12/1990,2021-06-01T12:30:00Z,07:30:00Z,a@example.com,https://a.example.com,hi,2021-06-01,2011-01-01 10:00:00
+223e4567-e89b-12d3-a456-426614174000,1/2/1985,2021-06-02T08:00:00.5Z,22:15:00+02:00,b@example.org,http://b.example.org/x,,hello,2011-01-02T10:00
+323e4567-e89b-12d3-a456-426614174000,,2021-06-03T00:00:00-07:00,,c@example.net,ftp://c.example.net,bye,,2011-01-03T10:00:00Z
+`)
+	st := &dataset.Structure{Format: "csv"}
+	got, _, err := CSVSchema(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "id", "type": "string", "format": "uuid"},
+				map[string]interface{}{"title": "born", "type": "string", "format": "date", "dateLayout": "D/M/YYYY"},
+				map[string]interface{}{"title": "updated", "type": "string", "format": "date-time"},
+				map[string]interface{}{"title": "alarm", "type": "string", "format": "time"},
+				map[string]interface{}{"title": "email", "type": "string", "format": "email"},
+				map[string]interface{}{"title": "site", "type": "string", "format": "uri"},
+				map[string]interface{}{"title": "note", "type": "string"},
+				map[string]interface{}{"title": "mixed", "type": "string"},
+				map[string]interface{}{"title": "local", "type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
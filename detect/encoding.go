@@ -0,0 +1,107 @@
+package detect
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// encodingSampleSize is the number of bytes examined to guess the character
+// encoding of text data
+const encodingSampleSize = 64 * 1024
+
+// Encoding guesses the character encoding of a sample of text from byte
+// statistics, returning the IANA name of the encoding. Byte order marks are
+// trusted when present. Valid UTF-8 without a byte order mark returns an empty
+// string, as UTF-8 is the default encoding for structures. Single-byte text
+// that isn't valid UTF-8 is assumed to be Windows-1252 if it uses any bytes
+// in the 0x80-0x9F range (which are control characters in Latin-1), otherwise
+// ISO-8859-1
+func Encoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return "UTF-8"
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return "UTF-16LE"
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return "UTF-16BE"
+	}
+
+	// UTF-16 encoded text that's mostly ASCII has a zero in every other byte
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b == 0 {
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+	if pairs := len(sample) / 2; pairs > 0 {
+		if oddZeros > pairs/4 && evenZeros*10 < oddZeros {
+			return "UTF-16LE"
+		}
+		if evenZeros > pairs/4 && oddZeros*10 < evenZeros {
+			return "UTF-16BE"
+		}
+	}
+
+	if utf8.Valid(sample) {
+		return ""
+	}
+
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9F {
+			return "windows-1252"
+		}
+	}
+	return "ISO-8859-1"
+}
+
+// trimPartialRune drops a multi-byte UTF-8 character that's been cut off at
+// the end of a sample
+func trimPartialRune(sample []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(sample); i++ {
+		if tail := sample[len(sample)-i:]; utf8.RuneStart(tail[0]) {
+			if !utf8.FullRune(tail) {
+				return sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	return sample
+}
+
+// isTextFormat reports if a data format is encoded as text, and can use a
+// character encoding
+func isTextFormat(df dataset.DataFormat) bool {
+	switch df {
+	case dataset.CSVDataFormat, dataset.JSONDataFormat, dataset.NDJSONDataFormat, dataset.FixedWidthDataFormat:
+		return true
+	}
+	return false
+}
+
+// detectEncoding sets the character encoding of a structure from a sample of
+// data, returning a reader of UTF-8 text that includes the sampled bytes
+func detectEncoding(st *dataset.Structure, data io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(data, encodingSampleSize)
+	sample, err := br.Peek(encodingSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	if len(sample) == encodingSampleSize {
+		sample = trimPartialRune(sample)
+	}
+	st.Encoding = Encoding(sample)
+	if st.Encoding == "" {
+		return br, nil
+	}
+	return dsio.NewTextDecoder(st.Encoding, br)
+}
@@ -0,0 +1,50 @@
+package detect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+)
+
+func TestEncoding(t *testing.T) {
+	cases := []struct {
+		description string
+		sample      []byte
+		expect      string
+	}{
+		{"empty", []byte{}, ""},
+		{"ascii", []byte("a,b,c\n1,2,3\n"), ""},
+		{"utf-8", []byte("city\nZürich\n東京\n"), ""},
+		{"utf-8 bom", []byte("\xef\xbb\xbfcity\nZürich\n"), "UTF-8"},
+		{"utf-16le bom", []byte("\xff\xfec\x00i\x00t\x00y\x00"), "UTF-16LE"},
+		{"utf-16be bom", []byte("\xfe\xff\x00c\x00i\x00t\x00y"), "UTF-16BE"},
+		{"utf-16le", []byte("c\x00i\x00t\x00y\x00\n\x00"), "UTF-16LE"},
+		{"utf-16be", []byte("\x00c\x00i\x00t\x00y\x00\n"), "UTF-16BE"},
+		{"latin-1", []byte("city\nZ\xfcrich\ncaf\xe9\n"), "ISO-8859-1"},
+		{"windows-1252", []byte("note\n\x93quoted\x94 \x96 5\x80\n"), "windows-1252"},
+	}
+
+	for _, c := range cases {
+		if got := Encoding(c.sample); got != c.expect {
+			t.Errorf("%s: expected %q, got %q", c.description, c.expect, got)
+		}
+	}
+}
+
+func TestFromReaderEncoding(t *testing.T) {
+	data := []byte("ville,nombre\nZ\xfcrich,1\nGen\xe8ve,2\n")
+	st, _, err := FromReader(dataset.CSVDataFormat, compression.FmtNone, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Encoding != "ISO-8859-1" {
+		t.Errorf("expected encoding %q, got %q", "ISO-8859-1", st.Encoding)
+	}
+
+	items := st.Schema["items"].(map[string]interface{})["items"].([]interface{})
+	if title := items[0].(map[string]interface{})["title"]; title != "ville" {
+		t.Errorf("expected first column title %q, got %q", "ville", title)
+	}
+}
@@ -0,0 +1,218 @@
+package detect
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// FixedWidthSchema determines column positions, names and types of an
+// io.Reader of fixed-width text, returning a json schema. Proposed column
+// positions are written to the structure's FormatConfig
+func FixedWidthSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	schema, _, n, err = FixedWidthSchemaWithOptions(resource, data, nil)
+	return
+}
+
+// FixedWidthSchemaWithOptions determines column positions, names and types
+// of an io.Reader of fixed-width text using inference options, returning a
+// json schema and a report of the values each column's type is based on.
+// Column positions are proposed from the first sample of lines, even when
+// options scan every line
+func FixedWidthSchemaWithOptions(resource *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
+	if err = opts.check(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	tr := dsio.NewTrackedReader(data)
+	rdr := bufio.NewReader(tr)
+	readLine := func() (string, error) {
+		for {
+			line, err := rdr.ReadString('\n')
+			if line = strings.TrimRight(line, "\r\n"); line != "" {
+				return line, nil
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	limit := opts.sampleSize()
+	sampleSize := limit
+	if sampleSize < 0 {
+		sampleSize = DefaultSampleSize
+	}
+
+	var (
+		lines []string
+		eof   bool
+	)
+	for len(lines) < sampleSize {
+		line, err := readLine()
+		if err == io.EOF {
+			eof = true
+			break
+		} else if err != nil {
+			return nil, nil, tr.BytesRead(), fmt.Errorf("error reading fixed-width data: %s", err.Error())
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, nil, tr.BytesRead(), fmt.Errorf("no fixed-width data to detect")
+	}
+
+	positions := FixedWidthColumnBoundaries(lines)
+	rows := make([][]string, len(lines))
+	for i, line := range lines {
+		rows[i] = fixedWidthCells(line, positions)
+	}
+
+	fwOpts := &dataset.FixedWidthOptions{Columns: positions}
+	tallies := make([]*columnTally, len(positions))
+	for i := range tallies {
+		tallies[i] = newColumnTally(opts, nil)
+	}
+
+	// fixed-width readers can't skip lines above the table, so only a single
+	// header row is detected
+	body := rows
+	var header HeaderLayout
+	if len(rows) > 1 {
+		header = detectHeader(rows, 0, 1)
+	}
+	if header.Rows > 0 {
+		fwOpts.HeaderRow = true
+		body = rows[1:]
+	}
+	titles := columnTitles(header, len(positions))
+
+	report = &Report{}
+	tallyRow := func(row []string) {
+		for i, cell := range row {
+			tallies[i].add(cell)
+		}
+		report.Rows++
+	}
+	for _, row := range body {
+		tallyRow(row)
+	}
+	// the header row doesn't count towards the sample size
+	for !eof && (limit < 0 || report.Rows < limit) {
+		line, err := readLine()
+		if err == io.EOF {
+			eof = true
+			break
+		} else if err != nil {
+			return nil, nil, tr.BytesRead(), fmt.Errorf("error reading fixed-width data: %s", err.Error())
+		}
+		tallyRow(fixedWidthCells(line, positions))
+	}
+	if !eof {
+		// data that ends right at the sample size was still read completely
+		_, err := readLine()
+		eof = err == io.EOF
+	}
+	report.Complete = eof
+
+	items := make([]interface{}, len(tallies))
+	for i, tally := range tallies {
+		col, colReport := tally.column(titles[i], opts, report.Complete)
+		items[i] = col
+		report.Columns = append(report.Columns, colReport)
+	}
+
+	resource.FormatConfig = fwOpts.Map()
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}, report, tr.BytesRead(), nil
+}
+
+// FixedWidthColumnBoundaries proposes column positions for lines of
+// fixed-width text by looking for whitespace that's aligned across all lines.
+// Each transition from a fully-blank character position to a position with
+// text in any line starts a new column, and columns extend to the start of
+// the next. This handles both left-aligned text & right-aligned numbers.
+// Single-character gaps with letters on both sides in any line are treated
+// as spaces between words of a value, not column boundaries
+func FixedWidthColumnBoundaries(lines []string) []dataset.FixedWidthColumn {
+	rows := make([][]rune, len(lines))
+	width := 0
+	for i, line := range lines {
+		rows[i] = []rune(line)
+		if len(rows[i]) > width {
+			width = len(rows[i])
+		}
+	}
+
+	blank := make([]bool, width)
+	for i := range blank {
+		blank[i] = true
+	}
+	for _, row := range rows {
+		for i, r := range row {
+			if r != ' ' && r != '\t' {
+				blank[i] = false
+			}
+		}
+	}
+
+	// fill single-character gaps that separate words
+	for i := 1; i < width-1; i++ {
+		if blank[i] && !blank[i-1] && !blank[i+1] {
+			for _, row := range rows {
+				if i+1 < len(row) && unicode.IsLetter(row[i-1]) && unicode.IsLetter(row[i+1]) {
+					blank[i] = false
+					break
+				}
+			}
+		}
+	}
+
+	var starts []int
+	for i := range blank {
+		if !blank[i] && (i == 0 || blank[i-1]) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) == 0 {
+		return []dataset.FixedWidthColumn{{Start: 0, Width: width}}
+	}
+
+	// leading whitespace belongs to the first column
+	starts[0] = 0
+	cols := make([]dataset.FixedWidthColumn, len(starts))
+	for i, start := range starts {
+		end := width
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		cols[i] = dataset.FixedWidthColumn{Start: start, Width: end - start}
+	}
+	return cols
+}
+
+func fixedWidthCells(line string, cols []dataset.FixedWidthColumn) []string {
+	chars := []rune(line)
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		if c.Start >= len(chars) {
+			continue
+		}
+		end := c.Start + c.Width
+		if end > len(chars) {
+			end = len(chars)
+		}
+		cells[i] = strings.TrimSpace(string(chars[c.Start:end]))
+	}
+	return cells
+}
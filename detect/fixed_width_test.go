@@ -0,0 +1,76 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestFixedWidthColumnBoundaries(t *testing.T) {
+	lines := []string{
+		"name        count  price  ok",
+		"apple           3   1.25  true",
+		"banana         12         false",
+		"cherry pie    140   12.5  true",
+	}
+	expect := []dataset.FixedWidthColumn{
+		{Start: 0, Width: 12},
+		{Start: 12, Width: 7},
+		{Start: 19, Width: 7},
+		{Start: 26, Width: 5},
+	}
+	got := FixedWidthColumnBoundaries(lines)
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	got = FixedWidthColumnBoundaries([]string{"abc", "de"})
+	if diff := cmp.Diff([]dataset.FixedWidthColumn{{Start: 0, Width: 3}}, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFixedWidthSchema(t *testing.T) {
+	data := "name        count  price  ok\n" +
+		"apple           3   1.25  true\n" +
+		"banana         12         false\n" +
+		"cherry pie    140   12.5  true\n"
+
+	st := &dataset.Structure{Format: "fwf"}
+	sch, n, err := FixedWidthSchema(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(data) {
+		t.Errorf("expected %d bytes read, got: %d", len(data), n)
+	}
+
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "count", "type": "integer"},
+				map[string]interface{}{"title": "price", "type": "number"},
+				map[string]interface{}{"title": "ok", "type": "boolean"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	opts, err := dataset.NewFixedWidthOptions(st.FormatConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.HeaderRow {
+		t.Error("expected header row to be detected")
+	}
+	if len(opts.Columns) != 4 {
+		t.Errorf("expected 4 columns, got: %d", len(opts.Columns))
+	}
+}
@@ -0,0 +1,79 @@
+package detect
+
+import (
+	"strings"
+
+	"github.com/qri-io/dataset/vals"
+)
+
+// formatTally counts the string formats of values in a column
+type formatTally struct {
+	// strings is the number of non-empty string values
+	strings int
+	formats map[vals.Format]int
+	// layouts counts the dates each date layout can read
+	layouts map[string]int
+}
+
+func newFormatTally() *formatTally {
+	return &formatTally{
+		formats: map[vals.Format]int{},
+		layouts: map[string]int{},
+	}
+}
+
+// add tallies the format of a string value
+func (t *formatTally) add(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	t.strings++
+	f := vals.ParseFormat([]byte(value))
+	// date-times & times are read as-is, so only strict RFC 3339 values
+	// will validate against their format
+	if (f == vals.FormatDateTime || f == vals.FormatTime) && !vals.IsRFC3339(f, value) {
+		f = vals.FormatNone
+	}
+	t.formats[f]++
+	if f == vals.FormatDate {
+		for _, l := range vals.MatchDateLayouts(value) {
+			t.layouts[l]++
+		}
+	}
+}
+
+// format gives the format shared by all string values, and for dates the
+// most preferred layout that reads every date. Dates in the ISO layout have
+// no dateLayout, because it's the JSON schema default. Date-times & times
+// must all be strict RFC 3339 values
+func (t *formatTally) format() (f vals.Format, dateLayout string) {
+	for f, n := range t.formats {
+		if f == vals.FormatNone || n != t.strings {
+			continue
+		}
+		if f != vals.FormatDate {
+			return f, ""
+		}
+		for _, l := range vals.DateLayouts {
+			if t.layouts[l] == n {
+				if l == vals.ISODateLayout {
+					return f, ""
+				}
+				return f, l
+			}
+		}
+	}
+	return vals.FormatNone, ""
+}
+
+// merge adds the counts of another tally to this one
+func (t *formatTally) merge(o *formatTally) {
+	t.strings += o.strings
+	for f, n := range o.formats {
+		t.formats[f] += n
+	}
+	for l, n := range o.layouts {
+		t.layouts[l] += n
+	}
+}
@@ -0,0 +1,299 @@
+package detect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset/vals"
+	"github.com/qri-io/varName"
+)
+
+const (
+	// headerSampleRows is the number of leading rows examined to find junk &
+	// header rows
+	headerSampleRows = 100
+	// maxJunkRows is the largest number of leading rows skipped as titles,
+	// notes or spacing above a table
+	maxJunkRows = 10
+	// maxHeaderRows is the largest number of header rows merged into column
+	// titles
+	maxHeaderRows = 3
+	// minProfileValues is the number of values a column needs below a row to
+	// give evidence about it
+	minProfileValues = 3
+)
+
+// HeaderLayout describes where a table starts in the leading rows of tabular
+// data
+type HeaderLayout struct {
+	// Skip is the number of rows before the table, like titles or notes
+	Skip int
+	// Rows is the number of header rows, zero when the table has no header
+	Rows int
+	// Titles are column titles merged from header rows, nil when the table
+	// has no header
+	Titles []string
+}
+
+// DetectHeader finds junk & header rows in the leading rows of a table. Rows
+// above the table with at most one non-empty cell are junk. Header rows are
+// found by comparing each cell to the type profile of the rows below it:
+// cells that don't match their column's type, empty cells in columns that
+// are rarely empty, and unseen values in low-cardinality columns are evidence
+// of a header, cells that read like the rows below are evidence of data. When
+// rows below give no evidence either way, rows are headers if every cell is
+// a non-empty, non-numeric, non-boolean value. Numbers in a row above another
+// header row, like years that group columns, are titles. Up to maxHeaderRows
+// consecutive header rows are merged into compound titles
+func DetectHeader(rows [][]string) HeaderLayout {
+	return detectHeader(rows, maxJunkRows, maxHeaderRows)
+}
+
+func detectHeader(rows [][]string, maxJunk, maxHeader int) HeaderLayout {
+	if len(rows) > headerSampleRows {
+		rows = rows[:headerSampleRows]
+	}
+	// short rows are padded, spreadsheet rows leave out trailing empty cells
+	width := tableWidth(rows, true)
+	padded := make([][]string, len(rows))
+	for i, row := range rows {
+		padded[i] = make([]string, width)
+		for j := 0; j < width && j < len(row); j++ {
+			padded[i][j] = strings.TrimSpace(row[j])
+		}
+	}
+
+	layout := HeaderLayout{}
+	for layout.Skip < maxJunk && layout.Skip < len(padded)-1 && isJunkRow(padded[layout.Skip]) {
+		layout.Skip++
+	}
+
+	for layout.Rows < maxHeader {
+		i := layout.Skip + layout.Rows
+		if i >= len(padded) || !isHeaderRow(padded[i], padded[i+1:], layout.Rows > 0) {
+			break
+		}
+		layout.Rows++
+	}
+	if layout.Rows > 0 {
+		layout.Titles = mergeHeaderRows(padded[layout.Skip : layout.Skip+layout.Rows])
+	}
+	return layout
+}
+
+// tableWidth gives the most common number of cells in rows, or the largest
+// number of cells when longest is set
+func tableWidth(rows [][]string, longest bool) int {
+	counts := map[int]int{}
+	width := 0
+	for _, row := range rows {
+		n := len(row)
+		counts[n]++
+		if longest && n > width || !longest && (counts[n] > counts[width] || counts[n] == counts[width] && n > width) {
+			width = n
+		}
+	}
+	return width
+}
+
+// isJunkRow reports if a row has too few values to belong to a table, like a
+// title, a note or a blank line
+func isJunkRow(row []string) bool {
+	values := 0
+	for _, cell := range row {
+		if cell != "" {
+			values++
+		}
+	}
+	return values == 0 || values == 1 && len(row) >= 3
+}
+
+// isHeaderRow compares a row to the rows below it to decide if it holds
+// column titles. continued is set for rows below another header row, where
+// upper rows may span columns & leave cells empty. Upper rows often title
+// groups of columns with numbers like years, so when the row below is a
+// header too, numbers above its titles are evidence of a header
+func isHeaderRow(row []string, body [][]string, continued bool) bool {
+	grouped := !continued && len(body) > 0 && isHeaderRow(body[0], body[1:], true)
+	score, evidence, against := 0, 0, 0
+	for i, cell := range row {
+		e := cellEvidence(cell, newHeaderProfile(body, i))
+		if grouped && isNumberCell(cell) && body[0][i] != "" && !isNumberCell(body[0][i]) {
+			e = 1
+		}
+		score += e
+		if e != 0 {
+			evidence++
+		}
+		if e < 0 {
+			against++
+		}
+	}
+	if continued && against > 0 {
+		// rows below a header that read like data in any column are data
+		return false
+	}
+	if evidence > 0 {
+		return score > 0
+	}
+	// continuation rows need evidence, without it they're data
+	return !continued && lexicalHeaderRow(row)
+}
+
+// headerProfile summarizes the values of a column below candidate header rows
+type headerProfile struct {
+	cells, empty int
+	types        map[vals.Type]int
+	values       map[string]bool
+	formats      *formatTally
+}
+
+func newHeaderProfile(body [][]string, col int) *headerProfile {
+	p := &headerProfile{
+		types:   map[vals.Type]int{},
+		values:  map[string]bool{},
+		formats: newFormatTally(),
+	}
+	for _, row := range body {
+		p.cells++
+		cell := row[col]
+		if cell == "" {
+			p.empty++
+			continue
+		}
+		typ := vals.ParseType([]byte(cell))
+		if typ == vals.TypeInteger {
+			typ = vals.TypeNumber
+		}
+		p.types[typ]++
+		p.values[cell] = true
+		if typ == vals.TypeString {
+			p.formats.add(cell)
+		}
+	}
+	return p
+}
+
+// dominant gives the type of most non-empty values, TypeUnknown if no type
+// has a majority
+func (p *headerProfile) dominant() vals.Type {
+	for _, typ := range getKeys(p.types) {
+		if p.types[typ]*2 > p.cells-p.empty {
+			return typ
+		}
+	}
+	return vals.TypeUnknown
+}
+
+// cellEvidence scores a candidate header cell against the values below it:
+// 1 if the cell reads like a title, -1 if it reads like data, 0 if the
+// column can't tell
+func cellEvidence(cell string, p *headerProfile) int {
+	filled := p.cells - p.empty
+	if filled < minProfileValues {
+		return 0
+	}
+	if cell == "" {
+		// titles leave cells empty in columns that rarely are
+		if p.empty*10 < p.cells {
+			return 1
+		}
+		return 0
+	}
+
+	typ := vals.ParseType([]byte(cell))
+	if typ == vals.TypeInteger {
+		typ = vals.TypeNumber
+	}
+	switch p.dominant() {
+	case vals.TypeNumber, vals.TypeBoolean:
+		if typ == p.dominant() {
+			return -1
+		}
+		return 1
+	case vals.TypeString:
+		if typ != vals.TypeString || p.values[cell] {
+			return -1
+		}
+		if f, _ := p.formats.format(); f != vals.FormatNone {
+			if vals.ParseFormat([]byte(cell)) == f {
+				return -1
+			}
+			return 1
+		}
+		// a new value in a column that repeats a few values
+		if len(p.values)*2 <= filled {
+			return 1
+		}
+	}
+	return 0
+}
+
+// isNumberCell reports if a cell reads as a number
+func isNumberCell(cell string) bool {
+	_, err := vals.ParseNumber([]byte(cell))
+	return err == nil
+}
+
+// lexicalHeaderRow reports if every cell of a row could be a title: not
+// empty, not a number & not a boolean keyword
+func lexicalHeaderRow(row []string) bool {
+	for _, cell := range row {
+		if cell == "" || cell == "true" || cell == "false" {
+			return false
+		}
+		if isNumberCell(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeHeaderRows merges header rows into one title per column. Upper rows
+// often title a group of columns once, so their cells span empty cells to
+// the right. Titles join each row's cell from top to bottom
+func mergeHeaderRows(rows [][]string) []string {
+	if len(rows) == 0 {
+		return nil
+	}
+	parts := make([][]string, len(rows[0]))
+	for r, row := range rows {
+		span := ""
+		for i, cell := range row {
+			if cell == "" && r < len(rows)-1 {
+				cell = span
+			} else {
+				span = cell
+			}
+			if cell != "" {
+				parts[i] = append(parts[i], cell)
+			}
+		}
+	}
+
+	titles := make([]string, len(parts))
+	for i, p := range parts {
+		titles[i] = strings.Join(p, " ")
+	}
+	return titles
+}
+
+// columnTitles converts header titles to column names, numbering columns
+// without a usable title. varName drops leading digits, so compound titles
+// that start with a number, like a year spanning columns, are prefixed
+func columnTitles(header HeaderLayout, width int) []string {
+	names := make([]string, width)
+	for i := range names {
+		if i < len(header.Titles) {
+			title := header.Titles[i]
+			if header.Rows > 1 && startsWithNumberRegex.MatchString(title) {
+				title = "col " + title
+			}
+			names[i] = varName.CreateVarNameFromString(title)
+		}
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("field_%d", i+1)
+		}
+	}
+	return names
+}
@@ -0,0 +1,249 @@
+package detect
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestDetectHeader(t *testing.T) {
+	cases := []struct {
+		description string
+		rows        [][]string
+		expect      HeaderLayout
+	}{
+		{"numbers below titles",
+			[][]string{{"id", "score"}, {"1", "2.5"}, {"2", "3"}, {"3", "4"}},
+			HeaderLayout{Rows: 1, Titles: []string{"id", "score"}}},
+		{"no header",
+			[][]string{{"1", "2.5"}, {"2", "3"}, {"3", "4"}, {"4", "5"}},
+			HeaderLayout{}},
+		{"all strings",
+			[][]string{{"size", "color"}, {"small", "red"}, {"large", "blue"}, {"small", "red"}, {"large", "red"}},
+			HeaderLayout{Rows: 1, Titles: []string{"size", "color"}}},
+		{"all strings without header",
+			[][]string{{"small", "red"}, {"large", "blue"}, {"small", "red"}, {"large", "red"}},
+			HeaderLayout{}},
+		{"dates below titles",
+			[][]string{{"name", "joined"}, {"ann", "2020-01-02"}, {"bob", "2020-03-04"}, {"cat", "2021-05-06"}},
+			HeaderLayout{Rows: 1, Titles: []string{"name", "joined"}}},
+		{"title & blank rows",
+			[][]string{{"Quarterly report", "", ""}, {"", "", ""}, {"region", "sales", "units"}, {"north", "10.5", "3"}, {"south", "20", "4"}, {"east", "7.25", "1"}},
+			HeaderLayout{Skip: 2, Rows: 1, Titles: []string{"region", "sales", "units"}}},
+		{"two header rows",
+			[][]string{{"", "2019", "", "2020", ""}, {"region", "q1", "q2", "q1", "q2"}, {"north", "1", "2", "3", "4"}, {"south", "5", "6", "7", "8"}, {"east", "9", "10", "11", "12"}},
+			HeaderLayout{Rows: 2, Titles: []string{"region", "2019 q1", "2019 q2", "2020 q1", "2020 q2"}}},
+		{"lexical fallback",
+			[][]string{{"Animal", "Sound", "Weight"}, {"cat", "meow", "1.4"}, {"dog", "bark", "3.7"}},
+			HeaderLayout{Rows: 1, Titles: []string{"Animal", "Sound", "Weight"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			got := DetectHeader(c.rows)
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSVSchemaHeaderRows(t *testing.T) {
+	cases := []struct {
+		description string
+		data        string
+		config      map[string]interface{}
+		titles      []string
+		entries     []interface{}
+	}{
+		{"spanning years",
+			"Sales by quarter,,,,\n\n,2019,,2020,\nregion,q1,q2,q1,q2\nnorth,1,2,3,4\nsouth,5,6,7,8\neast,9,10,11,12\n",
+			map[string]interface{}{"headerRow": true, "skipInitialRows": 3},
+			[]string{"region", "col_2019_q1", "col_2019_q2", "col_2020_q1", "col_2020_q2"},
+			[]interface{}{
+				[]interface{}{"north", int64(1), int64(2), int64(3), int64(4)},
+				[]interface{}{"south", int64(5), int64(6), int64(7), int64(8)},
+				[]interface{}{"east", int64(9), int64(10), int64(11), int64(12)},
+			},
+		},
+		{"repeated years",
+			"Monthly report\n\nregion,2020,2020\n,q1,q2\nnorth,1,2\nsouth,3,4\neast,5,6\n",
+			map[string]interface{}{"headerRow": true, "skipInitialRows": 3},
+			[]string{"region", "col_2020_q1", "col_2020_q2"},
+			[]interface{}{
+				[]interface{}{"north", int64(1), int64(2)},
+				[]interface{}{"south", int64(3), int64(4)},
+				[]interface{}{"east", int64(5), int64(6)},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			st := &dataset.Structure{Format: "csv"}
+			sch, report, _, err := CSVSchemaWithOptions(st, strings.NewReader(c.data), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(c.config, st.FormatConfig); diff != "" {
+				t.Errorf("format config mismatch (-want +got):\n%s", diff)
+			}
+			if report.Rows != len(c.entries) {
+				t.Errorf("expected %d rows examined, got: %d", len(c.entries), report.Rows)
+			}
+
+			var titles []string
+			for _, col := range sch["items"].(map[string]interface{})["items"].([]interface{}) {
+				titles = append(titles, col.(map[string]interface{})["title"].(string))
+			}
+			if diff := cmp.Diff(c.titles, titles); diff != "" {
+				t.Errorf("titles mismatch (-want +got):\n%s", diff)
+			}
+
+			st.Schema = sch
+			r, err := dsio.NewEntryReader(st, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dsio.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.entries, got); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXLSXSchema(t *testing.T) {
+	f := excelize.NewFile()
+	for i, row := range [][]interface{}{
+		{"Inventory"},
+		{"item", "count", "price"},
+		{"apple", 3, 1.25},
+		{"pear", 5, 0.5},
+		{"plum", 7},
+	} {
+		f.SetSheetRow("Sheet1", fmt.Sprintf("A%d", i+1), &row)
+	}
+	buf := &bytes.Buffer{}
+	if err := f.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	st := &dataset.Structure{Format: "xlsx"}
+	sch, report, _, err := XLSXSchemaWithOptions(st, bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectConfig := map[string]interface{}{"headerRow": true, "skipInitialRows": 1}
+	if diff := cmp.Diff(expectConfig, st.FormatConfig); diff != "" {
+		t.Errorf("format config mismatch (-want +got):\n%s", diff)
+	}
+	expectReport := &Report{
+		Rows:     3,
+		Complete: true,
+		Columns: []*ColumnReport{
+			{Title: "item", Type: []string{"string"}, Counts: map[string]int{"string": 3}, Confidence: 1},
+			{Title: "count", Type: []string{"integer"}, Counts: map[string]int{"integer": 3}, Confidence: 1},
+			{Title: "price", Type: []string{"number"}, Counts: map[string]int{"number": 2, "null": 1}, Confidence: 2.0 / 3},
+		},
+	}
+	if diff := cmp.Diff(expectReport, report); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+
+	st.Schema = sch
+	r, err := dsio.NewEntryReader(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := dsio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"apple", int64(3), 1.25},
+		[]interface{}{"pear", int64(5), 0.5},
+		[]interface{}{"plum", int64(7)},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestODSSchema(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewODSWriter(&dataset.Structure{Format: "ods", Schema: sheetTextSchema}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range []interface{}{
+		[]interface{}{"Inventory"},
+		[]interface{}{"item", "count", "price"},
+		[]interface{}{"apple", int64(3), 1.25},
+		[]interface{}{"pear", int64(5), 0.5},
+		[]interface{}{"plum", int64(7)},
+	} {
+		if err := w.WriteEntry(dsio.Entry{Value: row}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	st := &dataset.Structure{Format: "ods"}
+	sch, report, _, err := SchemaWithOptions(st, bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectConfig := map[string]interface{}{"headerRow": true, "skipInitialRows": 1}
+	if diff := cmp.Diff(expectConfig, st.FormatConfig); diff != "" {
+		t.Errorf("format config mismatch (-want +got):\n%s", diff)
+	}
+	expectReport := &Report{
+		Rows:     3,
+		Complete: true,
+		Columns: []*ColumnReport{
+			{Title: "item", Type: []string{"string"}, Counts: map[string]int{"string": 3}, Confidence: 1},
+			{Title: "count", Type: []string{"integer"}, Counts: map[string]int{"integer": 3}, Confidence: 1},
+			{Title: "price", Type: []string{"number"}, Counts: map[string]int{"number": 2, "null": 1}, Confidence: 2.0 / 3},
+		},
+	}
+	if diff := cmp.Diff(expectReport, report); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+
+	st.Schema = sch
+	r, err := dsio.NewEntryReader(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := dsio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"apple", int64(3), 1.25},
+		[]interface{}{"pear", int64(5), 0.5},
+		[]interface{}{"plum", int64(7)},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,59 +1,70 @@
 package detect
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
 )
 
 // JSONSchema determines the field names and types of an io.Reader of JSON-formatted data, returning a json schema
-// This is currently a suuuuuuuuper simple interpretation that spits out a generic schema that'll work. In the future
-// we can do all sorts of stuff here to make better inferences about the shape of a dataset, but for now, this'll work,
-// and we'll instead focus on making it easier for users to provide hand-built schemas
+// The top level type is determined from the first non-whitespace character, and entries of the top level array or
+// object are sampled to describe their properties & items
 func JSONSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
-	var (
-		count = 0
-		buf   = make([]byte, 100)
-	)
+	schema, _, n, err = JSONSchemaWithOptions(resource, data, nil)
+	return
+}
+
+// JSONSchemaWithOptions determines the schema of an io.Reader of JSON-formatted data using inference options,
+// returning a json schema and a report of the entries it's based on
+func JSONSchemaWithOptions(resource *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
+	tr := dsio.NewTrackedReader(data)
+	br := bufio.NewReader(tr)
 
 	for {
-		count, err = data.Read(buf)
-		n += count
-		if err != nil {
-			if err == io.EOF {
-				// possible that data length is less than 100 bytes,
-				// if we've read more than 0 bytes, we should check it
-				if count > 0 {
-					err = nil
-				} else {
-					err = fmt.Errorf("invalid json data")
-					return
-				}
-			} else {
-				log.Debugf(err.Error())
-				err = fmt.Errorf("error reading data: %s", err.Error())
-				return
-			}
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return nil, nil, tr.BytesRead(), fmt.Errorf("invalid json data")
+		} else if err != nil {
+			log.Debugf(err.Error())
+			return nil, nil, tr.BytesRead(), fmt.Errorf("error reading data: %s", err.Error())
 		}
 
-		for _, b := range buf {
-			switch b {
-			case '[':
-				return dataset.BaseSchemaArray, n, nil
-			case '{':
-				return dataset.BaseSchemaObject, n, nil
-			case ' ', '\t', '\n', '\r':
-				continue
-			default:
-				err = fmt.Errorf("invalid json data")
-				return
-			}
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '[':
+			schema = dataset.BaseSchemaArray
+		case '{':
+			schema = dataset.BaseSchemaObject
+		default:
+			return nil, nil, tr.BytesRead(), fmt.Errorf("invalid json data")
 		}
+		break
+	}
+
+	if err := br.UnreadByte(); err != nil {
+		return nil, nil, tr.BytesRead(), err
 	}
+	st := &dataset.Structure{Format: dataset.JSONDataFormat.String(), Schema: schema}
+	schema, report, err = EntrySchema(st, br, opts)
+	return schema, report, tr.BytesRead(), err
 }
 
-// NDJSONSchema returns an array identity schema
+// NDJSONSchema determines the schema of newline-delimited JSON, sampling entries to describe their properties &
+// items
 func NDJSONSchema(resource *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
-	return dataset.BaseSchemaArray, 0, nil
+	schema, _, n, err = NDJSONSchemaWithOptions(resource, data, nil)
+	return
+}
+
+// NDJSONSchemaWithOptions determines the schema of newline-delimited JSON using inference options, returning a json
+// schema and a report of the entries it's based on
+func NDJSONSchemaWithOptions(resource *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
+	tr := dsio.NewTrackedReader(data)
+	st := &dataset.Structure{Format: dataset.NDJSONDataFormat.String(), Schema: dataset.BaseSchemaArray}
+	schema, report, err = EntrySchema(st, tr, opts)
+	return schema, report, tr.BytesRead(), err
 }
@@ -0,0 +1,258 @@
+package detect
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/tabular"
+)
+
+const (
+	// keyEstimateTolerance is the share of rows a HyperLogLog estimate of
+	// distinct values can fall short of while columns may still be unique.
+	// 16-bit precision sketches have a standard error well under 1%
+	keyEstimateTolerance = 0.02
+	// keysPerPass is the largest number of candidates confirmed in a single
+	// pass over the data. every row adds a hash per candidate to memory
+	keysPerPass = 16
+)
+
+// KeyCandidate is a set of columns whose values identify every row
+type KeyCandidate struct {
+	// Columns are the titles of key columns, in schema order
+	Columns []string `json:"columns"`
+	// Indexes are the positions of key columns
+	Indexes []int `json:"indexes"`
+}
+
+// KeyCandidates proposes columns & combinations of columns of tabular data
+// that are unique & non-null in every row. A first pass rules out columns
+// with null or empty values & estimates each column's distinct values with
+// HyperLogLog sketches, ruling out combinations of columns that can't have
+// a distinct value for every row. Remaining candidates are confirmed in
+// further passes of a few candidates at a time, starting with single columns
+// & moving on to composites of up to MaxKeyColumns columns only when no
+// smaller key exists. Every row is read regardless of sample size, and data
+// is read once per pass from its current offset, so it must be seekable.
+// Candidates are ordered by column position, data without rows or keys gives
+// no candidates
+func KeyCandidates(st *dataset.Structure, data io.ReadSeeker, opts *Options) ([]KeyCandidate, error) {
+	if err := opts.check(); err != nil {
+		return nil, err
+	}
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+	start, err := data.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rows     int
+		nulls    = make([]bool, len(cols))
+		sketches = make([]*hyperloglog.Sketch, len(cols))
+	)
+	for i := range sketches {
+		sketches[i] = hyperloglog.New16()
+	}
+	err = eachRow(st, data, start, func(row []interface{}) bool {
+		rows++
+		for i := range cols {
+			if v, ok := keyValue(row, i); ok {
+				sketches[i].Insert([]byte(v))
+			} else {
+				nulls[i] = true
+			}
+		}
+		return true
+	})
+	if err != nil || rows == 0 {
+		return nil, err
+	}
+
+	estimates := make([]float64, len(cols))
+	var usable []int
+	for i := range cols {
+		if !nulls[i] {
+			estimates[i] = float64(sketches[i].Estimate())
+			usable = append(usable, i)
+		}
+	}
+
+	least := float64(rows) * (1 - keyEstimateTolerance)
+	for size := 1; size <= opts.maxKeyColumns() && size <= len(usable); size++ {
+		var (
+			keys, chunk [][]int
+			confirmErr  error
+		)
+		// confirm candidates in chunks, bounding the memory each pass holds
+		confirm := func() bool {
+			found, err := confirmKeys(st, data, start, chunk)
+			if err != nil {
+				confirmErr = err
+				return false
+			}
+			keys = append(keys, found...)
+			chunk = chunk[:0]
+			return true
+		}
+		combinations(usable, size, func(idx []int) bool {
+			est := 1.0
+			for _, i := range idx {
+				// columns with a single value never help identify rows
+				if size > 1 && estimates[i] < 2 {
+					return true
+				}
+				est *= estimates[i]
+			}
+			if est < least {
+				return true
+			}
+			chunk = append(chunk, append([]int(nil), idx...))
+			return len(chunk) < keysPerPass || confirm()
+		})
+		if confirmErr == nil && len(chunk) > 0 {
+			confirm()
+		}
+		if confirmErr != nil {
+			return nil, confirmErr
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		found := make([]KeyCandidate, len(keys))
+		for i, idx := range keys {
+			found[i] = KeyCandidate{Indexes: idx}
+			for _, c := range idx {
+				found[i].Columns = append(found[i].Columns, cols[c].Title)
+			}
+		}
+		return found, nil
+	}
+	return nil, nil
+}
+
+// PrimaryKey detects keys of tabular data with KeyCandidates & records the
+// columns of the first candidate in the structure's PrimaryKey, giving the
+// key's column titles. The structure is unchanged when data has no key
+func PrimaryKey(st *dataset.Structure, data io.ReadSeeker, opts *Options) ([]string, error) {
+	keys, err := KeyCandidates(st, data, opts)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	st.PrimaryKey = keys[0].Columns
+	return st.PrimaryKey, nil
+}
+
+// confirmKeys reads every row to find the candidates that have a distinct
+// value in each row. Rows are compared by a 64-bit hash of their key values,
+// a collision rules out a candidate with a chance of about n²/2⁶⁵ for n rows
+func confirmKeys(st *dataset.Structure, data io.ReadSeeker, start int64, candidates [][]int) ([][]int, error) {
+	seen := make([]map[uint64]struct{}, len(candidates))
+	for i := range seen {
+		seen[i] = map[uint64]struct{}{}
+	}
+	remaining := len(candidates)
+
+	h := fnv.New64a()
+	err := eachRow(st, data, start, func(row []interface{}) bool {
+		for i, idx := range candidates {
+			if seen[i] == nil {
+				continue
+			}
+			h.Reset()
+			for _, c := range idx {
+				v, _ := keyValue(row, c)
+				h.Write([]byte(v))
+				h.Write([]byte{0})
+			}
+			key := h.Sum64()
+			if _, dup := seen[i][key]; dup {
+				seen[i] = nil
+				remaining--
+				continue
+			}
+			seen[i][key] = struct{}{}
+		}
+		return remaining > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var keys [][]int
+	for i, idx := range candidates {
+		if seen[i] != nil {
+			keys = append(keys, idx)
+		}
+	}
+	return keys, nil
+}
+
+// eachRow reads tabular rows from start, calling fn with each row until fn
+// returns false
+func eachRow(st *dataset.Structure, data io.ReadSeeker, start int64, fn func(row []interface{}) bool) error {
+	if _, err := data.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	r, err := dsio.NewEntryReader(st, data)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		row, ok := ent.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("entry %d is not a row: %v", ent.Index, ent.Value)
+		}
+		if !fn(row) {
+			return nil
+		}
+	}
+}
+
+// keyValue gives the text of a row's value at a column, ok is false for
+// missing, null & empty values, which can't identify a row
+func keyValue(row []interface{}, col int) (v string, ok bool) {
+	if col >= len(row) || row[col] == nil {
+		return "", false
+	}
+	v = fmt.Sprintf("%v", row[col])
+	return v, strings.TrimSpace(v) != ""
+}
+
+// combinations calls fn with each combination of size elements of set, in
+// order, until fn returns false
+func combinations(set []int, size int, fn func(idx []int) bool) {
+	idx := make([]int, 0, size)
+	var walk func(from int) bool
+	walk = func(from int) bool {
+		if len(idx) == size {
+			return fn(idx)
+		}
+		for i := from; i <= len(set)-(size-len(idx)); i++ {
+			idx = append(idx, set[i])
+			if !walk(i + 1) {
+				return false
+			}
+			idx = idx[:len(idx)-1]
+		}
+		return true
+	}
+	walk(0)
+}
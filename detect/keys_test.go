@@ -0,0 +1,130 @@
+package detect
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestKeyCandidates(t *testing.T) {
+	cases := []struct {
+		description string
+		data        string
+		opts        *Options
+		expect      []KeyCandidate
+	}{
+		{"single column",
+			"id,name,code\n1,a,x\n2,a,y\n3,b,z\n", nil,
+			[]KeyCandidate{{Columns: []string{"id"}, Indexes: []int{0}}, {Columns: []string{"code"}, Indexes: []int{2}}}},
+		{"composite",
+			"year,region,total\n2019,n,5\n2019,s,5\n2020,n,5\n2020,s,6\n", nil,
+			[]KeyCandidate{{Columns: []string{"year", "region"}, Indexes: []int{0, 1}}}},
+		{"composite over limit",
+			"year,region,total\n2019,n,5\n2019,s,5\n2020,n,5\n2020,s,6\n", &Options{MaxKeyColumns: 1},
+			nil},
+		{"nulls",
+			"id,code\n1,a\n,b\n3,c\n", nil,
+			[]KeyCandidate{{Columns: []string{"code"}, Indexes: []int{1}}}},
+		{"duplicate rows",
+			"a,b\n1,x\n1,x\n", nil,
+			nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			st := &dataset.Structure{Format: "csv"}
+			sch, _, err := CSVSchema(st, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			st.Schema = sch
+
+			got, err := KeyCandidates(st, strings.NewReader(c.data), c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKeyCandidatesManyColumns(t *testing.T) {
+	// columns c0-c29 each repeat one value, only the last column is a key.
+	// candidates are confirmed a few at a time, the key is in a later pass
+	const cols, rows = 31, 200
+	b := &strings.Builder{}
+	for c := 0; c < cols; c++ {
+		if c > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(b, "c%d", c)
+	}
+	b.WriteString("\n")
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				b.WriteString(",")
+			}
+			v := r
+			if c < cols-1 && r == c+1 {
+				v = c
+			}
+			fmt.Fprintf(b, "%d", v)
+		}
+		b.WriteString("\n")
+	}
+	data := b.String()
+
+	st := &dataset.Structure{Format: "csv"}
+	sch, _, err := CSVSchema(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Schema = sch
+
+	got, err := KeyCandidates(st, strings.NewReader(data), &Options{MaxKeyColumns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []KeyCandidate{{Columns: []string{"c30"}, Indexes: []int{30}}}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrimaryKey(t *testing.T) {
+	b := &strings.Builder{}
+	b.WriteString("day,sensor,reading\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(b, "%d,s%d,%d\n", i/4, i%4, i%7)
+	}
+	data := b.String()
+
+	st := &dataset.Structure{Format: "csv"}
+	sch, _, err := CSVSchema(st, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Schema = sch
+
+	got, err := PrimaryKey(st, strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"day", "sensor"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(expect, st.PrimaryKey); diff != "" {
+		t.Errorf("structure primary key mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := KeyCandidates(st, strings.NewReader(data), &Options{MaxKeyColumns: -1}); err == nil {
+		t.Error("expected invalid options to error")
+	}
+}
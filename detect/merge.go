@@ -0,0 +1,348 @@
+package detect
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/qri-io/dataset/tabular"
+)
+
+// Conflict describes a difference between schemas that merging couldn't
+// resolve
+type Conflict struct {
+	// Column is the title of the merged column
+	Column string `json:"column"`
+	// Message describes the conflict
+	Message string `json:"message"`
+}
+
+// String implements the stringer interface
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s", c.Column, c.Message)
+}
+
+// MergeSchemas merges tabular schemas detected from several sources of the
+// same kind of data, like monthly exports, into one schema that describes
+// every source. Columns are aligned by title, ignoring case, spacing &
+// punctuation, and are ordered by first appearance. Types widen from integer
+// to number to string, columns missing from any source are nullable, and
+// validation keywords widen to cover every source, dropping keywords that
+// not every source has. Columns that mix objects or arrays with other types
+// can't be widened, they list every type & are reported as conflicts
+func MergeSchemas(schemas ...map[string]interface{}) (merged map[string]interface{}, conflicts []Conflict, err error) {
+	var (
+		order   []string
+		columns = map[string]*mergeColumn{}
+	)
+	for i, sch := range schemas {
+		if _, _, err := tabular.ColumnsFromJSONSchema(sch); err != nil {
+			return nil, nil, fmt.Errorf("schema %d: %w", i, err)
+		}
+		items := sch["items"].(map[string]interface{})["items"].([]interface{})
+
+		seen := map[string]int{}
+		for j, item := range items {
+			col, _ := item.(map[string]interface{})
+			title, _ := col["title"].(string)
+			if title == "" {
+				title = fmt.Sprintf("field_%d", j+1)
+			}
+
+			key := normalizeTitle(title)
+			if seen[key]++; seen[key] > 1 {
+				conflicts = append(conflicts, Conflict{
+					Column:  title,
+					Message: fmt.Sprintf("schema %d has %d columns with this title, aligning them by position", i, seen[key]),
+				})
+				key = fmt.Sprintf("%s#%d", key, seen[key])
+			}
+
+			mc, ok := columns[key]
+			if !ok {
+				mc = &mergeColumn{title: title}
+				columns[key] = mc
+				order = append(order, key)
+			}
+			mc.sources = append(mc.sources, i)
+			mc.schemas = append(mc.schemas, col)
+		}
+	}
+
+	items := make([]interface{}, len(order))
+	for i, key := range order {
+		mc := columns[key]
+		col, conflict := mc.merge(len(schemas))
+		if conflict != "" {
+			conflicts = append(conflicts, Conflict{Column: mc.title, Message: conflict})
+		}
+		items[i] = col
+	}
+
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}, conflicts, nil
+}
+
+// normalizeTitle reduces a column title to lowercase letters & digits
+func normalizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, title)
+}
+
+// mergeColumn collects the definitions of one column across schemas
+type mergeColumn struct {
+	title string
+	// sources are the indexes of schemas that have the column, schemas are
+	// the column's definition in each of them
+	sources []int
+	schemas []map[string]interface{}
+}
+
+// merge combines column definitions from total schemas into one, giving a
+// description of any conflict it couldn't resolve
+func (mc *mergeColumn) merge(total int) (col map[string]interface{}, conflict string) {
+	types, nullable, conflict := mc.mergeTypes()
+	if len(mc.sources) < total {
+		nullable = true
+	}
+	if nullable && !containsString(types, "null") {
+		types = append(types, "null")
+	}
+
+	col = map[string]interface{}{"title": mc.title}
+	if len(types) == 1 {
+		col["type"] = types[0]
+	} else {
+		kw := make([]interface{}, len(types))
+		for i, t := range types {
+			kw[i] = t
+		}
+		col["type"] = kw
+	}
+
+	numeric := types[0] == "integer" || types[0] == "number"
+	for key, val := range mc.schemas[0] {
+		switch key {
+		case "title", "type":
+		case "description":
+			col[key] = val
+		case "minimum", "maximum":
+			if numeric {
+				mc.mergeBound(col, key)
+			}
+		case "minLength", "maxLength":
+			if types[0] == "string" {
+				mc.mergeBound(col, key)
+			}
+		case "enum":
+			if types[0] == "string" {
+				mc.mergeEnum(col, nullable)
+			}
+		default:
+			// other keywords like format only hold if every source agrees
+			if mc.agree(key) {
+				col[key] = val
+			}
+		}
+	}
+	return col, conflict
+}
+
+// mergeTypes widens the column's types across schemas. Columns with the same
+// types in every schema keep them, numbers widen integers, and other scalar
+// mixes widen to string. Objects & arrays mixed with other types give a list
+// of every type & a conflict
+func (mc *mergeColumn) mergeTypes() (types []string, nullable bool, conflict string) {
+	var (
+		first   []string
+		same    = true
+		union   []string
+		sources = map[string][]int{}
+	)
+	for i, sch := range mc.schemas {
+		var ts []string
+		for _, t := range schemaTypes(sch["type"]) {
+			if t == "null" {
+				nullable = true
+				continue
+			}
+			ts = append(ts, t)
+			if !containsString(union, t) {
+				union = append(union, t)
+			}
+			sources[t] = append(sources[t], mc.sources[i])
+		}
+		if len(ts) == 0 {
+			continue
+		}
+		if first == nil {
+			first = ts
+		} else if !sameStrings(first, ts) {
+			same = false
+		}
+	}
+
+	switch {
+	case first == nil:
+		// columns that are only ever null or untyped read as text
+		if nullable {
+			return []string{"null"}, true, ""
+		}
+		return []string{"string"}, false, ""
+	case same:
+		return first, nullable, ""
+	case onlyStrings(union, "integer", "number"):
+		return []string{"number"}, nullable, ""
+	case !containsString(union, "object") && !containsString(union, "array"):
+		return []string{"string"}, nullable, ""
+	}
+
+	desc := make([]string, len(union))
+	for i, t := range union {
+		desc[i] = fmt.Sprintf("%s in schemas %v", t, sources[t])
+	}
+	return union, nullable, fmt.Sprintf("can't merge types: %s", strings.Join(desc, ", "))
+}
+
+// mergeBound widens a minimum or maximum keyword to cover every schema,
+// dropping it if any schema doesn't set it
+func (mc *mergeColumn) mergeBound(col map[string]interface{}, key string) {
+	var bound float64
+	for i, sch := range mc.schemas {
+		f, ok := numberValue(sch[key])
+		if !ok {
+			return
+		}
+		if i == 0 {
+			bound = f
+		} else if key == "minimum" || key == "minLength" {
+			bound = math.Min(bound, f)
+		} else {
+			bound = math.Max(bound, f)
+		}
+	}
+	col[key] = bound
+}
+
+// mergeEnum combines the enums of every schema in order of appearance,
+// dropping the enum if any schema doesn't set one
+func (mc *mergeColumn) mergeEnum(col map[string]interface{}, nullable bool) {
+	var (
+		enum    []interface{}
+		hasNull bool
+	)
+	for _, sch := range mc.schemas {
+		vals, ok := sch["enum"].([]interface{})
+		if !ok {
+			return
+		}
+		for _, v := range vals {
+			if v == nil {
+				hasNull = true
+				continue
+			}
+			if !containsValue(enum, v) {
+				enum = append(enum, v)
+			}
+		}
+	}
+	// nullable columns accept null as a value too
+	if hasNull || nullable {
+		enum = append(enum, nil)
+	}
+	col["enum"] = enum
+}
+
+// agree reports if every schema sets the same value for a keyword
+func (mc *mergeColumn) agree(key string) bool {
+	for _, sch := range mc.schemas[1:] {
+		if !reflect.DeepEqual(mc.schemas[0][key], sch[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaTypes reads a JSON schema "type" keyword value
+func schemaTypes(kw interface{}) []string {
+	switch x := kw.(type) {
+	case string:
+		return []string{x}
+	case []string:
+		return x
+	case []interface{}:
+		types := make([]string, 0, len(x))
+		for _, v := range x {
+			if t, ok := v.(string); ok {
+				types = append(types, t)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// numberValue reads a numeric keyword value
+func numberValue(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+func containsString(strs []string, s string) bool {
+	for _, x := range strs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyStrings reports if every string in strs is one of allowed
+func onlyStrings(strs []string, allowed ...string) bool {
+	for _, s := range strs {
+		if !containsString(allowed, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameStrings reports if two lists hold the same strings in any order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range a {
+		if !containsString(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(vals []interface{}, v interface{}) bool {
+	for _, x := range vals {
+		if reflect.DeepEqual(x, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func tabularSchema(cols ...map[string]interface{}) map[string]interface{} {
+	items := make([]interface{}, len(cols))
+	for i, c := range cols {
+		items[i] = c
+	}
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}
+}
+
+func TestMergeSchemas(t *testing.T) {
+	cases := []struct {
+		description string
+		schemas     []map[string]interface{}
+		expect      map[string]interface{}
+		conflicts   []Conflict
+	}{
+		{"identical",
+			[]map[string]interface{}{
+				tabularSchema(map[string]interface{}{"title": "id", "type": "integer"}),
+				tabularSchema(map[string]interface{}{"title": "id", "type": "integer"}),
+			},
+			tabularSchema(map[string]interface{}{"title": "id", "type": "integer"}),
+			nil},
+		{"widen integer to number",
+			[]map[string]interface{}{
+				tabularSchema(map[string]interface{}{"title": "price", "type": "integer", "minimum": float64(1), "maximum": float64(5)}),
+				tabularSchema(map[string]interface{}{"title": "price", "type": "number", "minimum": 0.5, "maximum": float64(3)}),
+			},
+			tabularSchema(map[string]interface{}{"title": "price", "type": "number", "minimum": 0.5, "maximum": float64(5)}),
+			nil},
+		{"widen scalars to string",
+			[]map[string]interface{}{
+				tabularSchema(map[string]interface{}{"title": "code", "type": "integer", "minimum": float64(1)}),
+				tabularSchema(map[string]interface{}{"title": "code", "type": "string", "minLength": float64(2)}),
+				tabularSchema(map[string]interface{}{"title": "code", "type": "boolean"}),
+			},
+			tabularSchema(map[string]interface{}{"title": "code", "type": "string"}),
+			nil},
+		{"align titles & add columns",
+			[]map[string]interface{}{
+				tabularSchema(
+					map[string]interface{}{"title": "Total Sales", "type": "number"},
+					map[string]interface{}{"title": "region", "type": "string"},
+				),
+				tabularSchema(
+					map[string]interface{}{"title": "region", "type": "string"},
+					map[string]interface{}{"title": "total_sales", "type": "number"},
+					map[string]interface{}{"title": "units", "type": "integer"},
+				),
+			},
+			tabularSchema(
+				map[string]interface{}{"title": "Total Sales", "type": "number"},
+				map[string]interface{}{"title": "region", "type": "string"},
+				map[string]interface{}{"title": "units", "type": []interface{}{"integer", "null"}},
+			),
+			nil},
+		{"formats & enums",
+			[]map[string]interface{}{
+				tabularSchema(
+					map[string]interface{}{"title": "day", "type": "string", "format": "date", "dateLayout": "M/D/YYYY"},
+					map[string]interface{}{"title": "size", "type": "string", "enum": []interface{}{"large", "small"}},
+				),
+				tabularSchema(
+					map[string]interface{}{"title": "day", "type": "string", "format": "date", "dateLayout": "D/M/YYYY"},
+					map[string]interface{}{"title": "size", "type": []interface{}{"string", "null"}, "enum": []interface{}{"medium", "small", nil}},
+				),
+			},
+			tabularSchema(
+				map[string]interface{}{"title": "day", "type": "string", "format": "date"},
+				map[string]interface{}{"title": "size", "type": []interface{}{"string", "null"}, "enum": []interface{}{"large", "small", "medium", nil}},
+			),
+			nil},
+		{"conflicting types",
+			[]map[string]interface{}{
+				tabularSchema(map[string]interface{}{"title": "tags", "type": "array"}),
+				tabularSchema(map[string]interface{}{"title": "tags", "type": "string"}),
+			},
+			tabularSchema(map[string]interface{}{"title": "tags", "type": []interface{}{"array", "string"}}),
+			[]Conflict{{Column: "tags", Message: "can't merge types: array in schemas [0], string in schemas [1]"}}},
+		{"duplicate titles",
+			[]map[string]interface{}{
+				tabularSchema(
+					map[string]interface{}{"title": "a", "type": "integer"},
+					map[string]interface{}{"title": "A", "type": "string"},
+				),
+			},
+			tabularSchema(
+				map[string]interface{}{"title": "a", "type": "integer"},
+				map[string]interface{}{"title": "A", "type": "string"},
+			),
+			[]Conflict{{Column: "A", Message: "schema 0 has 2 columns with this title, aligning them by position"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			got, conflicts, err := MergeSchemas(c.schemas...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("schema mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(c.conflicts, conflicts); diff != "" {
+				t.Errorf("conflicts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, _, err := MergeSchemas(dataset.BaseSchemaObject); err == nil {
+		t.Error("expected merging a non-tabular schema to error")
+	}
+}
+
+func TestMergeDetectedSchemas(t *testing.T) {
+	months := []string{
+		"id,amount\n1,5\n2,7\n3,9\n",
+		"id,amount,note\n4,5.5,ok\n5,6,late\n6,8.25,ok\n",
+	}
+	var schemas []map[string]interface{}
+	for _, data := range months {
+		sch, _, err := CSVSchema(&dataset.Structure{Format: "csv"}, strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		schemas = append(schemas, sch)
+	}
+
+	got, conflicts, err := MergeSchemas(schemas...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := tabularSchema(
+		map[string]interface{}{"title": "id", "type": "integer"},
+		map[string]interface{}{"title": "amount", "type": "number"},
+		map[string]interface{}{"title": "note", "type": []interface{}{"string", "null"}},
+	)
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if len(conflicts) > 0 {
+		t.Errorf("unexpected conflicts: %v", conflicts)
+	}
+}
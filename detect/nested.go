@@ -0,0 +1,357 @@
+package detect
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+)
+
+// propertyName matches object keys that read as names
+var propertyName = regexp.MustCompile(`^[\pL_$@][\pL\pN_$@ .-]*$`)
+
+// valueTally counts the types of values found at one position in nested
+// data, with tallies for the properties of objects & the items of arrays
+// found at that position
+type valueTally struct {
+	count       int
+	types       map[vals.Type]int
+	formats     *formatTally
+	constraints *constraintTally
+
+	// objects is the number of objects tallied, props tallies each key's values
+	objects int
+	props   map[string]*valueTally
+	// items tallies the elements of all arrays
+	items *valueTally
+	// positions tallies array elements by position, only when rows is set.
+	// minLen & maxLen are the shortest & longest array lengths
+	rows           bool
+	positions      []*valueTally
+	minLen, maxLen int
+}
+
+// newValueTally creates a tally, string values with at most enumLimit
+// distinct values are counted for enum constraints
+func newValueTally(enumLimit int) *valueTally {
+	return &valueTally{
+		types:       map[vals.Type]int{},
+		formats:     newFormatTally(),
+		constraints: newConstraintTally(enumLimit),
+		props:       map[string]*valueTally{},
+		minLen:      -1,
+	}
+}
+
+// child creates a tally for values nested within tallied values
+func (t *valueTally) child() *valueTally {
+	return newValueTally(t.constraints.limit)
+}
+
+// add tallies a value decoded by a dsio reader. Objects & arrays at depth
+// maxDepth or deeper are counted without examining their contents
+func (t *valueTally) add(v interface{}, depth, maxDepth int) {
+	t.count++
+	switch x := v.(type) {
+	case nil:
+		t.types[vals.TypeNull]++
+	case bool:
+		t.types[vals.TypeBoolean]++
+	case int:
+		t.addInteger(float64(x))
+	case int64:
+		// readers decode integers as int64
+		t.addInteger(float64(x))
+	case float32:
+		t.addNumber(float64(x))
+	case float64:
+		t.addNumber(x)
+	case string:
+		t.types[vals.TypeString]++
+		t.formats.add(x)
+		t.constraints.addString(x)
+	case map[string]interface{}:
+		t.types[vals.TypeObject]++
+		t.objects++
+		if depth >= maxDepth {
+			return
+		}
+		for key, val := range x {
+			t.prop(key).add(val, depth+1, maxDepth)
+		}
+	case []interface{}:
+		t.types[vals.TypeArray]++
+		if t.minLen < 0 || len(x) < t.minLen {
+			t.minLen = len(x)
+		}
+		if len(x) > t.maxLen {
+			t.maxLen = len(x)
+		}
+		if depth >= maxDepth {
+			return
+		}
+		if t.items == nil {
+			t.items = t.child()
+		}
+		for i, el := range x {
+			t.items.add(el, depth+1, maxDepth)
+			if t.rows {
+				for len(t.positions) <= i {
+					t.positions = append(t.positions, t.child())
+				}
+				t.positions[i].add(el, depth+1, maxDepth)
+			}
+		}
+	default:
+		// bytes & other encoded values are written as strings
+		t.types[vals.TypeString]++
+	}
+}
+
+// addNumber tallies a floating point value. some readers decode every number
+// as a float, whole numbers are integers just as JSON schema treats them
+func (t *valueTally) addNumber(f float64) {
+	if f == math.Trunc(f) && !math.IsInf(f, 0) {
+		t.addInteger(f)
+		return
+	}
+	t.types[vals.TypeNumber]++
+	t.constraints.addNumber(f)
+}
+
+// addInteger tallies a whole number
+func (t *valueTally) addInteger(f float64) {
+	t.types[vals.TypeInteger]++
+	t.constraints.addNumber(f)
+}
+
+// prop gets the tally for an object key
+func (t *valueTally) prop(key string) *valueTally {
+	p, ok := t.props[key]
+	if !ok {
+		p = t.child()
+		t.props[key] = p
+	}
+	return p
+}
+
+// merge adds the counts of another tally to this one
+func (t *valueTally) merge(o *valueTally) {
+	t.count += o.count
+	for typ, n := range o.types {
+		t.types[typ] += n
+	}
+	t.formats.merge(o.formats)
+	t.constraints.merge(o.constraints)
+	t.objects += o.objects
+	for key, p := range o.props {
+		t.prop(key).merge(p)
+	}
+	if o.items != nil {
+		if t.items == nil {
+			t.items = t.child()
+		}
+		t.items.merge(o.items)
+	}
+	if o.minLen >= 0 && (t.minLen < 0 || o.minLen < t.minLen) {
+		t.minLen = o.minLen
+	}
+	if o.maxLen > t.maxLen {
+		t.maxLen = o.maxLen
+	}
+}
+
+// mapLike reports if tallied objects use keys as values rather than names.
+// Objects are map-like when every key looks like a value, or when there are
+// at least mapKeys distinct keys that each appear in fewer than half of the
+// objects
+func (t *valueTally) mapLike(mapKeys int) bool {
+	if len(t.props) < 2 {
+		return false
+	}
+	dataKeys := true
+	for key := range t.props {
+		if !isDataKey(key) {
+			dataKeys = false
+			break
+		}
+	}
+	if dataKeys {
+		return true
+	}
+	if len(t.props) < mapKeys {
+		return false
+	}
+	for _, p := range t.props {
+		if p.count*2 >= t.objects {
+			return false
+		}
+	}
+	return true
+}
+
+// isDataKey reports if an object key looks like a value: a number, a string
+// with a format, or a string that couldn't be a property name
+func isDataKey(key string) bool {
+	switch vals.ParseType([]byte(key)) {
+	case vals.TypeInteger, vals.TypeNumber:
+		return true
+	}
+	if vals.ParseFormat([]byte(key)) != vals.FormatNone {
+		return true
+	}
+	return !propertyName.MatchString(key)
+}
+
+// schema describes tallied values as a JSON schema. Every observed type is
+// listed, most common first. Objects list their properties, with keys found
+// in every object required. Map-like objects describe all values with
+// additionalProperties instead. Arrays describe their elements with items,
+// and arrays tallied as rows of equal length describe each position. complete
+// is true when every entry was tallied
+func (t *valueTally) schema(opts *Options, complete bool) map[string]interface{} {
+	types := []vals.Type{vals.TypeNull}
+	if t.types[vals.TypeNull] < t.count {
+		types, _ = inferTypes(t.types, t.count, 1)
+	}
+	sch := map[string]interface{}{"type": typeKeyword(types)}
+
+	f, layout := vals.FormatNone, ""
+	if t.types[vals.TypeString] > 0 {
+		f, layout = t.formats.format()
+	}
+	if f != vals.FormatNone {
+		sch["format"] = f.String()
+		if layout != "" {
+			sch["dateLayout"] = layout
+		}
+	}
+	if opts.constraints() && complete {
+		for key, val := range t.constraints.keywords(types, f, true) {
+			sch[key] = val
+		}
+	}
+
+	if len(t.props) > 0 {
+		if t.mapLike(opts.mapKeys()) {
+			values := t.child()
+			for _, p := range t.props {
+				values.merge(p)
+			}
+			sch["additionalProperties"] = values.schema(opts, complete)
+		} else {
+			keys := make([]string, 0, len(t.props))
+			for key := range t.props {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			props := make(map[string]interface{}, len(keys))
+			var required []interface{}
+			for _, key := range keys {
+				p := t.props[key]
+				props[key] = p.schema(opts, complete)
+				if p.count == t.objects {
+					required = append(required, key)
+				}
+			}
+			sch["properties"] = props
+			if len(required) > 0 {
+				sch["required"] = required
+			}
+		}
+	}
+
+	if t.items != nil && t.items.count > 0 {
+		if t.rows && t.minLen == t.maxLen && t.types[vals.TypeArray] == t.count {
+			cols := make([]interface{}, len(t.positions))
+			for i, p := range t.positions {
+				col := p.schema(opts, complete)
+				col["title"] = fmt.Sprintf("field_%d", i+1)
+				cols[i] = col
+			}
+			sch["items"] = cols
+		} else {
+			sch["items"] = t.items.schema(opts, complete)
+		}
+	}
+	return sch
+}
+
+// EntrySchema infers a JSON schema from sampled entries of nested data,
+// describing the properties & items of objects & arrays. The structure must
+// have a data format & a schema that gives the top level type, like
+// dataset.BaseSchemaArray. Entries that are arrays of equal length are
+// described as tabular rows, with a schema for each position. If no entries
+// can be read the structure's schema is returned unchanged
+func EntrySchema(st *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, err error) {
+	if err = opts.check(); err != nil {
+		return nil, nil, err
+	}
+	r, err := dsio.NewEntryReader(st, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	tlt, err := dsio.GetTopLevelType(st)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the top level tally is a single array or object
+	top := newValueTally(opts.enumLimit())
+	top.count = 1
+	if tlt == "object" {
+		top.types[vals.TypeObject] = 1
+		top.objects = 1
+	} else {
+		top.types[vals.TypeArray] = 1
+		top.items = top.child()
+		top.items.rows = true
+	}
+
+	add := func(ent *dsio.Entry) {
+		if tlt == "object" {
+			top.prop(ent.Key).add(ent.Value, 1, opts.maxDepth())
+		} else {
+			top.items.add(ent.Value, 1, opts.maxDepth())
+		}
+		report.Rows++
+	}
+
+	// readers can give a missing value at the end of truncated data as null,
+	// so entries are described once the following read is known. entries
+	// that can't be read are left to validation, describing everything
+	// before them
+	report = &Report{}
+	limit := opts.sampleSize()
+	var pending *dsio.Entry
+	for {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if pending != nil && (err == io.EOF || pending.Value != nil) {
+				add(pending)
+			}
+			report.Complete = err == io.EOF
+			break
+		}
+		if pending != nil {
+			add(pending)
+			if report.Rows == limit {
+				break
+			}
+		}
+		pending = &ent
+	}
+
+	if report.Rows == 0 {
+		return st.Schema, report, nil
+	}
+	return top.schema(opts, report.Complete), report, nil
+}
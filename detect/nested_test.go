@@ -0,0 +1,147 @@
+package detect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestJSONSchemaWithOptions(t *testing.T) {
+	cases := []struct {
+		description string
+		data        string
+		opts        *Options
+		expect      string
+	}{
+		{"nested objects",
+			`[{"a":1,"b":{"c":"x","d":[1,2.5]}},{"a":2,"b":{"c":null,"d":[]},"e":true}]`,
+			nil,
+			`{"type":"array","items":{"type":"object","required":["a","b"],"properties":{
+				"a":{"type":"integer"},
+				"b":{"type":"object","required":["c","d"],"properties":{
+					"c":{"type":["string","null"]},
+					"d":{"type":"array","items":{"type":"number"}}
+				}},
+				"e":{"type":"boolean"}
+			}}}`},
+		{"unions",
+			`[1,"a","b",null,{"x":1}]`,
+			nil,
+			`{"type":"array","items":{"type":["string","null","integer","object"],"properties":{"x":{"type":"integer"}},"required":["x"]}}`},
+		{"tabular rows",
+			`[[1,"a",true],[2,"b",null]]`,
+			nil,
+			`{"type":"array","items":{"type":"array","items":[
+				{"title":"field_1","type":"integer"},
+				{"title":"field_2","type":"string"},
+				{"title":"field_3","type":["boolean","null"]}
+			]}}`},
+		{"ragged rows",
+			`[[1,2],[3]]`,
+			nil,
+			`{"type":"array","items":{"type":"array","items":{"type":"integer"}}}`},
+		{"depth limit",
+			`[{"a":{"b":{"c":1}}}]`,
+			&Options{MaxDepth: 2},
+			`{"type":"array","items":{"type":"object","required":["a"],"properties":{"a":{"type":"object"}}}}`},
+		{"keyed top level object",
+			`{"2019":{"n":1},"2020":{"n":2}}`,
+			nil,
+			`{"type":"object","additionalProperties":{"type":"object","required":["n"],"properties":{"n":{"type":"integer"}}}}`},
+		{"map-like values",
+			`[{"id":1,"counts":{"ant":1,"bee":2}},{"id":2,"counts":{"cat":3,"dog":4}},{"id":3,"counts":{"eel":5,"fox":6}}]`,
+			&Options{MapKeys: 6},
+			`{"type":"array","items":{"type":"object","required":["counts","id"],"properties":{
+				"id":{"type":"integer"},
+				"counts":{"type":"object","additionalProperties":{"type":"integer"}}
+			}}}`},
+		{"sparse keys below threshold",
+			`[{"id":1,"counts":{"ant":1,"bee":2}},{"id":2,"counts":{"cat":3,"dog":4}},{"id":3,"counts":{"eel":5,"fox":6}}]`,
+			nil,
+			`{"type":"array","items":{"type":"object","required":["counts","id"],"properties":{
+				"id":{"type":"integer"},
+				"counts":{"type":"object","properties":{
+					"ant":{"type":"integer"},
+					"bee":{"type":"integer"},
+					"cat":{"type":"integer"},
+					"dog":{"type":"integer"},
+					"eel":{"type":"integer"},
+					"fox":{"type":"integer"}
+				}}
+			}}}`},
+		{"formats",
+			`[{"when":"2021-01-02","site":"https://example.com"}]`,
+			nil,
+			`{"type":"array","items":{"type":"object","required":["site","when"],"properties":{
+				"when":{"type":"string","format":"date"},
+				"site":{"type":"string","format":"uri"}
+			}}}`},
+		{"sample size",
+			`[{"a":1},{"a":"x"}]`,
+			&Options{SampleSize: 1},
+			`{"type":"array","items":{"type":"object","required":["a"],"properties":{"a":{"type":"integer"}}}}`},
+		{"truncated",
+			`[{"a":1},{"a":`,
+			nil,
+			`{"type":"array","items":{"type":"object","required":["a"],"properties":{"a":{"type":"integer"}}}}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			got, _, _, err := JSONSchemaWithOptions(&dataset.Structure{}, strings.NewReader(c.data), c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(mustParseJSONSchema([]byte(c.expect)), got); diff != "" {
+				t.Errorf("schema mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEntrySchemaValidatesSample(t *testing.T) {
+	data := `[{"name":"a","tags":["x"],"meta":{"size":1}},{"name":"b","tags":[],"meta":{"size":2.5,"note":null}}]`
+	sch, report, _, err := JSONSchemaWithOptions(&dataset.Structure{}, strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 2 || !report.Complete {
+		t.Errorf("expected 2 complete rows, got: %d, complete: %t", report.Rows, report.Complete)
+	}
+
+	st := &dataset.Structure{Format: "json", Schema: sch}
+	jsch, err := st.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := jsch.ValidateBytes(context.Background(), []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) > 0 {
+		t.Errorf("expected inferred schema to validate sampled data, got: %v", errs)
+	}
+}
+
+func TestNDJSONSchemaWithOptions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(buf, `{"i":%d,"s":"v%d"}`+"\n", i, i)
+	}
+	got, report, _, err := NDJSONSchemaWithOptions(&dataset.Structure{}, buf, &Options{SampleSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := mustParseJSONSchema([]byte(`{"type":"array","items":{"type":"object","required":["i","s"],"properties":{"i":{"type":"integer"},"s":{"type":"string"}}}}`))
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if report.Rows != 5 || !report.Complete {
+		t.Errorf("expected 5 complete rows, got: %d, complete: %t", report.Rows, report.Complete)
+	}
+}
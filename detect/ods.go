@@ -0,0 +1,60 @@
+package detect
+
+import (
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// ODSSchema determines column names and types of an OpenDocument
+// spreadsheet, returning a json schema
+func ODSSchema(r *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
+	schema, _, n, err = ODSSchemaWithOptions(r, data, nil)
+	return
+}
+
+// ODSSchemaWithOptions determines column names and types of an OpenDocument
+// spreadsheet using inference options, like XLSXSchemaWithOptions. The sheet
+// named by the structure's format config is examined, or the first sheet if
+// none is set. Rows above the table & header rows are written to the format
+// config
+func ODSSchemaWithOptions(resource *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
+	if err = opts.check(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	odsOpts := &dataset.ODSOptions{}
+	if resource.FormatConfig != nil {
+		fcg, err := dataset.ParseFormatConfigMap(dataset.ODSDataFormat, resource.FormatConfig)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		odsOpts.SheetName = fcg.(*dataset.ODSOptions).SheetName
+	}
+
+	tr := dsio.NewTrackedReader(data)
+	st := &dataset.Structure{
+		Format:       dataset.ODSDataFormat.String(),
+		FormatConfig: odsOpts.Map(),
+		Schema:       sheetTextSchema,
+	}
+	r, err := dsio.NewODSReader(st, tr)
+	if err != nil {
+		return nil, nil, tr.BytesRead(), err
+	}
+	defer r.Close()
+
+	table, err := inferTable(sheetRows(r, "ods"), opts, nil, true)
+	if err != nil {
+		return nil, nil, tr.BytesRead(), err
+	}
+
+	odsOpts.SkipInitialRows = table.header.Skip
+	if table.header.Rows > 1 {
+		odsOpts.SkipInitialRows += table.header.Rows - 1
+	}
+	odsOpts.HeaderRow = table.header.Rows > 0
+	resource.FormatConfig = odsOpts.Map()
+	return table.schema, table.report, tr.BytesRead(), nil
+}
@@ -0,0 +1,141 @@
+package detect
+
+import (
+	"fmt"
+)
+
+// DefaultSampleSize is the number of rows schema inference examines when
+// options don't set a sample size
+const DefaultSampleSize = 2000
+
+// DefaultMaxDepth is the number of levels of nested objects & arrays schema
+// inference describes when options don't set a depth
+const DefaultMaxDepth = 8
+
+// DefaultMapKeys is the number of distinct keys objects need before schema
+// inference considers keys that rarely repeat to be map-like
+const DefaultMapKeys = 20
+
+// DefaultMaxKeyColumns is the largest number of columns key detection
+// combines into a composite key when options don't set a limit
+const DefaultMaxKeyColumns = 3
+
+// Options configure schema inference
+type Options struct {
+	// SampleSize is the number of rows to examine, DefaultSampleSize if zero
+	SampleSize int
+	// ScanAll examines every row, ignoring SampleSize
+	ScanAll bool
+	// MinConfidence is the share of a column's values, between 0 and 1, that
+	// must match the column's most common type for it to be the only type.
+	// Columns below this threshold get a union of every observed type. Zero
+	// always picks a single type. When set, columns with empty cells are
+	// nullable, and CSV empty cells are added to the nullTokens format config
+	MinConfidence float64
+	// MaxDepth limits how many levels of nested objects & arrays in JSON,
+	// NDJSON & CBOR entries are described, DefaultMaxDepth if zero. Values
+	// deeper than the limit only have a type
+	MaxDepth int
+	// MapKeys is the number of distinct keys objects at the same position
+	// need before they're map-like because keys rarely repeat, DefaultMapKeys
+	// if zero. Objects keyed by numbers, formatted strings like dates & URLs, or
+	// other strings that can't be names are always map-like
+	MapKeys int
+	// Constraints proposes validation keywords from observed values: columns
+	// with empty cells or null tokens like "NA" are nullable, numbers get
+	// minimum & maximum, strings get minLength & maxLength, and low
+	// cardinality strings get an enum. CSV null tokens found in the data are
+	// added to the format config. Ranges & enums only describe the rows that
+	// were examined, so they're only proposed when every row is, with ScanAll
+	// or data that fits in the sample. Report.Complete tells if they were
+	Constraints bool
+	// EnumLimit is the largest number of distinct values a string column can
+	// have to get an enum, DefaultEnumLimit if zero
+	EnumLimit int
+	// MaxKeyColumns is the largest number of columns key detection combines
+	// into a composite key, DefaultMaxKeyColumns if zero
+	MaxKeyColumns int
+}
+
+// check confirms options are valid
+func (o *Options) check() error {
+	if o == nil {
+		return nil
+	}
+	if o.SampleSize < 0 {
+		return fmt.Errorf("invalid sampleSize value: %d", o.SampleSize)
+	}
+	if o.MinConfidence < 0 || o.MinConfidence > 1 {
+		return fmt.Errorf("invalid minConfidence value: %v", o.MinConfidence)
+	}
+	if o.MaxDepth < 0 {
+		return fmt.Errorf("invalid maxDepth value: %d", o.MaxDepth)
+	}
+	if o.MapKeys < 0 {
+		return fmt.Errorf("invalid mapKeys value: %d", o.MapKeys)
+	}
+	if o.EnumLimit < 0 {
+		return fmt.Errorf("invalid enumLimit value: %d", o.EnumLimit)
+	}
+	if o.MaxKeyColumns < 0 {
+		return fmt.Errorf("invalid maxKeyColumns value: %d", o.MaxKeyColumns)
+	}
+	return nil
+}
+
+// sampleSize gives the number of rows to examine, or -1 for all rows
+func (o *Options) sampleSize() int {
+	switch {
+	case o == nil || o.SampleSize == 0 && !o.ScanAll:
+		return DefaultSampleSize
+	case o.ScanAll:
+		return -1
+	default:
+		return o.SampleSize
+	}
+}
+
+// minConfidence gives the configured confidence threshold
+func (o *Options) minConfidence() float64 {
+	if o == nil {
+		return 0
+	}
+	return o.MinConfidence
+}
+
+// maxDepth gives the number of nested levels to describe
+func (o *Options) maxDepth() int {
+	if o == nil || o.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return o.MaxDepth
+}
+
+// mapKeys gives the number of distinct keys map-like objects need
+func (o *Options) mapKeys() int {
+	if o == nil || o.MapKeys == 0 {
+		return DefaultMapKeys
+	}
+	return o.MapKeys
+}
+
+// constraints reports if options propose validation keywords
+func (o *Options) constraints() bool {
+	return o != nil && o.Constraints
+}
+
+// enumLimit gives the largest number of distinct values for an enum
+func (o *Options) enumLimit() int {
+	if o == nil || o.EnumLimit == 0 {
+		return DefaultEnumLimit
+	}
+	return o.EnumLimit
+}
+
+// maxKeyColumns gives the largest number of columns in a composite key
+func (o *Options) maxKeyColumns() int {
+	if o == nil || o.MaxKeyColumns == 0 {
+		return DefaultMaxKeyColumns
+	}
+	return o.MaxKeyColumns
+}
@@ -0,0 +1,216 @@
+package detect
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset/vals"
+)
+
+// Report describes the values schema inference examined to build a schema
+type Report struct {
+	// Rows is the number of rows examined, not counting any header row
+	Rows int `json:"rows"`
+	// Complete is true when every row of the data was examined
+	Complete bool `json:"complete"`
+	// Columns describes each column, in schema order
+	Columns []*ColumnReport `json:"columns"`
+}
+
+// ColumnReport describes the values observed in one column
+type ColumnReport struct {
+	Title string `json:"title"`
+	// Type lists the column's inferred types, most common first
+	Type []string `json:"type"`
+	// Counts is the number of values of each type. empty values are "null"
+	Counts map[string]int `json:"counts"`
+	// Confidence is the share of values that match the column's first type
+	Confidence float64 `json:"confidence"`
+}
+
+// columnTally counts the types, string formats & constraints of values in a
+// column
+type columnTally struct {
+	total       int
+	types       map[vals.Type]int
+	formats     *formatTally
+	constraints *constraintTally
+	// nullTokens are cell values read as null, tokens counts the null tokens
+	// found in the column
+	nullTokens map[string]bool
+	tokens     map[string]int
+	// empty counts other empty cells, which nullable columns read as null
+	empty map[string]int
+}
+
+// newColumnTally creates a tally for a column of text cells. nullTokens are
+// cell values that read as null, which may be nil
+func newColumnTally(opts *Options, nullTokens map[string]bool) *columnTally {
+	return &columnTally{
+		types:       map[vals.Type]int{},
+		formats:     newFormatTally(),
+		constraints: newConstraintTally(opts.enumLimit()),
+		nullTokens:  nullTokens,
+		tokens:      map[string]int{},
+		empty:       map[string]int{},
+	}
+}
+
+// add tallies the type of a cell, and the format of string cells. empty
+// cells & null tokens are null
+func (t *columnTally) add(cell string) {
+	t.total++
+	if t.nullTokens[cell] {
+		t.types[vals.TypeNull]++
+		t.tokens[cell]++
+		return
+	}
+	// string columns read every other cell as-is, including empty cells
+	t.constraints.addString(cell)
+	if strings.TrimSpace(cell) == "" {
+		t.types[vals.TypeNull]++
+		t.empty[cell]++
+		return
+	}
+	typ := vals.ParseType([]byte(cell))
+	t.types[typ]++
+	switch typ {
+	case vals.TypeString:
+		t.formats.add(cell)
+	case vals.TypeInteger, vals.TypeNumber:
+		if f, err := vals.ParseNumber([]byte(cell)); err == nil {
+			t.constraints.addNumber(f)
+		}
+	}
+}
+
+// column describes a tallied column as a JSON schema column definition &
+// a report. complete is true when every row was tallied
+func (t *columnTally) column(title string, opts *Options, complete bool) (map[string]interface{}, *ColumnReport) {
+	types, confidence := t.infer(opts.minConfidence())
+	// null tokens found in any column are read as null in every column, so
+	// columns with nulls are nullable whenever a column could be
+	nullable := opts.constraints() || opts.minConfidence() > 0
+	if nullable && t.types[vals.TypeNull] > 0 && !hasType(types, vals.TypeNull) {
+		types = append(types, vals.TypeNull)
+	}
+
+	col := map[string]interface{}{
+		"title": title,
+		"type":  typeKeyword(types),
+	}
+	f, layout := vals.FormatNone, ""
+	if types[0] == vals.TypeString {
+		f, layout = t.formats.format()
+	}
+	if f != vals.FormatNone {
+		col["format"] = f.String()
+		if layout != "" {
+			col["dateLayout"] = layout
+		}
+	}
+	if opts.constraints() && complete {
+		// null tokens are read as null, while other empty cells of string
+		// columns are read as empty strings
+		for key, val := range t.constraints.keywords(types, f, len(t.tokens) > 0) {
+			col[key] = val
+		}
+	}
+	return col, t.report(title, types, confidence)
+}
+
+// infer picks the types of a column. The most common non-null type is the
+// column's type, with integers widened to numbers when both are present.
+// If fewer than minConfidence of all values match that type, every other
+// observed type is added in order of frequency. Columns without non-null
+// values are strings
+func (t *columnTally) infer(minConfidence float64) (types []vals.Type, confidence float64) {
+	return inferTypes(t.types, t.total, minConfidence)
+}
+
+// inferTypes picks types from counts of observed types, see columnTally.infer
+func inferTypes(observed map[vals.Type]int, total int, minConfidence float64) (types []vals.Type, confidence float64) {
+	counts := make(map[vals.Type]int, len(observed))
+	for typ, n := range observed {
+		counts[typ] = n
+	}
+	if counts[vals.TypeNumber] > 0 && counts[vals.TypeInteger] > 0 {
+		counts[vals.TypeNumber] += counts[vals.TypeInteger]
+		delete(counts, vals.TypeInteger)
+	}
+
+	primary := vals.TypeUnknown
+	for _, typ := range getKeys(counts) {
+		if typ != vals.TypeNull && counts[typ] > counts[primary] {
+			primary = typ
+		}
+	}
+	if primary == vals.TypeUnknown {
+		primary = vals.TypeString
+	}
+	if total > 0 {
+		confidence = float64(counts[primary]) / float64(total)
+	}
+
+	types = []vals.Type{primary}
+	if minConfidence > 0 && confidence < minConfidence {
+		var rest []vals.Type
+		for _, typ := range getKeys(counts) {
+			if typ != primary && counts[typ] > 0 {
+				rest = append(rest, typ)
+			}
+		}
+		sort.SliceStable(rest, func(i, j int) bool { return counts[rest[i]] > counts[rest[j]] })
+		types = append(types, rest...)
+	}
+	return types, confidence
+}
+
+// report describes a column's tallied values & inferred types
+func (t *columnTally) report(title string, types []vals.Type, confidence float64) *ColumnReport {
+	col := &ColumnReport{
+		Title:      title,
+		Type:       make([]string, len(types)),
+		Counts:     make(map[string]int, len(t.types)),
+		Confidence: confidence,
+	}
+	for i, typ := range types {
+		col.Type[i] = typ.String()
+	}
+	for typ, n := range t.types {
+		col.Counts[typ.String()] = n
+	}
+	return col
+}
+
+// nullable reports if a column's types include null
+func (c *ColumnReport) nullable() bool {
+	for _, typ := range c.Type {
+		if typ == vals.TypeNull.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// typeKeyword gives the JSON schema "type" keyword value for a list of types
+func typeKeyword(types []vals.Type) interface{} {
+	if len(types) == 1 {
+		return types[0].String()
+	}
+	kw := make([]interface{}, len(types))
+	for i, typ := range types {
+		kw[i] = typ.String()
+	}
+	return kw
+}
+
+// hasType reports if a list of types includes t
+func hasType(types []vals.Type, t vals.Type) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package detect
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+)
+
+func TestCSVSchemaWithOptions(t *testing.T) {
+	// the "rare" column is all integers until a string after the default
+	// sample size
+	buf := &bytes.Buffer{}
+	buf.WriteString("id,score,rare\n")
+	rows := DefaultSampleSize + 10
+	for i := 0; i < rows; i++ {
+		score := fmt.Sprintf("%d", i)
+		if i%4 == 0 {
+			score = ""
+		}
+		rare := "1"
+		if i == rows-1 {
+			rare = "n/a"
+		}
+		fmt.Fprintf(buf, "%d,%s,%s\n", i, score, rare)
+	}
+	data := buf.Bytes()
+
+	columnTypes := func(sch map[string]interface{}) []interface{} {
+		var types []interface{}
+		for _, col := range sch["items"].(map[string]interface{})["items"].([]interface{}) {
+			types = append(types, col.(map[string]interface{})["type"])
+		}
+		return types
+	}
+
+	cases := []struct {
+		description string
+		opts        *Options
+		types       []interface{}
+		rows        int
+		complete    bool
+	}{
+		{"defaults", nil, []interface{}{"integer", "integer", "integer"}, DefaultSampleSize, false},
+		{"sample size", &Options{SampleSize: 10}, []interface{}{"integer", "integer", "integer"}, 10, false},
+		{"scan all", &Options{ScanAll: true}, []interface{}{"integer", "integer", "integer"}, rows, true},
+		{"sample covers data", &Options{SampleSize: rows}, []interface{}{"integer", "integer", "integer"}, rows, true},
+		{"min confidence", &Options{ScanAll: true, MinConfidence: 0.9},
+			[]interface{}{"integer", []interface{}{"integer", "null"}, "integer"}, rows, true},
+		{"strict confidence", &Options{ScanAll: true, MinConfidence: 1},
+			[]interface{}{"integer", []interface{}{"integer", "null"}, []interface{}{"integer", "string"}}, rows, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			st := &dataset.Structure{Format: "csv"}
+			sch, report, _, err := CSVSchemaWithOptions(st, bytes.NewReader(data), c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.types, columnTypes(sch)); diff != "" {
+				t.Errorf("type mismatch (-want +got):\n%s", diff)
+			}
+			if report.Rows != c.rows {
+				t.Errorf("expected %d rows examined, got: %d", c.rows, report.Rows)
+			}
+			if report.Complete != c.complete {
+				t.Errorf("expected complete to be %t", c.complete)
+			}
+		})
+	}
+}
+
+func TestCSVSchemaReport(t *testing.T) {
+	data := "a,b,c,d\n1,x,1.5,\n2,,2,\n,y,3,\n4,z,true,\n"
+	st := &dataset.Structure{Format: "csv"}
+	sch, report, _, err := CSVSchemaWithOptions(st, strings.NewReader(data), &Options{MinConfidence: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &Report{
+		Rows:     4,
+		Complete: true,
+		Columns: []*ColumnReport{
+			{Title: "a", Type: []string{"integer", "null"}, Counts: map[string]int{"integer": 3, "null": 1}, Confidence: 0.75},
+			{Title: "b", Type: []string{"string", "null"}, Counts: map[string]int{"string": 3, "null": 1}, Confidence: 0.75},
+			{Title: "c", Type: []string{"number", "boolean"}, Counts: map[string]int{"integer": 2, "number": 1, "boolean": 1}, Confidence: 0.75},
+			{Title: "d", Type: []string{"string", "null"}, Counts: map[string]int{"null": 4}, Confidence: 0},
+		},
+	}
+	if diff := cmp.Diff(expect, report); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+
+	items := sch["items"].(map[string]interface{})["items"].([]interface{})
+	if diff := cmp.Diff([]interface{}{"number", "boolean"}, items[2].(map[string]interface{})["type"]); diff != "" {
+		t.Errorf("schema type mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCSVSchemaUnionValidates(t *testing.T) {
+	data := []byte("a,b,c\n1,x,1\n2,,2\n,y,3\n4,z,\n")
+	st, _, _, err := FromReaderWithOptions(dataset.CSVDataFormat, compression.FmtNone, bytes.NewReader(data), &Options{MinConfidence: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{""}, st.FormatConfig["nullTokens"]); diff != "" {
+		t.Errorf("nullTokens mismatch (-want +got):\n%s", diff)
+	}
+	if errs := bodyErrors(t, st, data); len(errs) != 0 {
+		t.Errorf("expected detected body to validate, got: %v", errs)
+	}
+}
+
+func TestFixedWidthSchemaWithOptions(t *testing.T) {
+	data := "name    count\n" +
+		"apple       3\n" +
+		"banana       \n" +
+		"cherry     12\n" +
+		"date     many\n"
+
+	st := &dataset.Structure{Format: "fwf"}
+	sch, report, _, err := FixedWidthSchemaWithOptions(st, strings.NewReader(data), &Options{MinConfidence: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "count", "type": []interface{}{"integer", "null", "string"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if report.Rows != 4 || !report.Complete {
+		t.Errorf("expected 4 complete rows, got: %d, complete: %t", report.Rows, report.Complete)
+	}
+}
+
+func TestOptionsCheck(t *testing.T) {
+	cases := []struct {
+		opts *Options
+		err  string
+	}{
+		{&Options{SampleSize: -1}, "invalid sampleSize value: -1"},
+		{&Options{MinConfidence: 1.5}, "invalid minConfidence value: 1.5"},
+	}
+	for _, c := range cases {
+		st := &dataset.Structure{Format: "csv"}
+		_, _, _, err := SchemaWithOptions(st, strings.NewReader("a\n1\n"), c.opts)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("expected error %q, got: %v", c.err, err)
+		}
+	}
+}
@@ -1,13 +1,90 @@
 package detect
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
 )
 
-// XLSXSchema determines any schema information for an excel spreadsheet
-// TODO (b5): currently unimplemented
+// sheetTextSchema reads every cell of a spreadsheet as a string
+var sheetTextSchema = map[string]interface{}{
+	"type": "array",
+	"items": map[string]interface{}{
+		"type":  "array",
+		"items": []interface{}{},
+	},
+}
+
+// XLSXSchema determines column names and types of an excel spreadsheet,
+// returning a json schema
 func XLSXSchema(r *dataset.Structure, data io.Reader) (schema map[string]interface{}, n int, err error) {
-	return dataset.BaseSchemaArray, 0, nil
+	schema, _, n, err = XLSXSchemaWithOptions(r, data, nil)
+	return
+}
+
+// XLSXSchemaWithOptions determines column names and types of an excel
+// spreadsheet using inference options, returning a json schema and a report
+// of the values each column's type is based on. The sheet named by the
+// structure's format config is examined, or the first sheet if none is set.
+// Rows above the table & header rows are written to the format config
+func XLSXSchemaWithOptions(resource *dataset.Structure, data io.Reader, opts *Options) (schema map[string]interface{}, report *Report, n int, err error) {
+	if err = opts.check(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	xlsxOpts := &dataset.XLSXOptions{}
+	if resource.FormatConfig != nil {
+		fcg, err := dataset.ParseFormatConfigMap(dataset.XLSXDataFormat, resource.FormatConfig)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		xlsxOpts.SheetName = fcg.(*dataset.XLSXOptions).SheetName
+	}
+
+	tr := dsio.NewTrackedReader(data)
+	st := &dataset.Structure{
+		Format:       dataset.XLSXDataFormat.String(),
+		FormatConfig: xlsxOpts.Map(),
+		Schema:       sheetTextSchema,
+	}
+	r, err := dsio.NewXLSXReader(st, tr)
+	if err != nil {
+		return nil, nil, tr.BytesRead(), err
+	}
+	defer r.Close()
+
+	table, err := inferTable(sheetRows(r, "xlsx"), opts, nil, true)
+	if err != nil {
+		return nil, nil, tr.BytesRead(), err
+	}
+
+	xlsxOpts.SkipInitialRows = table.header.Skip
+	if table.header.Rows > 1 {
+		xlsxOpts.SkipInitialRows += table.header.Rows - 1
+	}
+	xlsxOpts.HeaderRow = table.header.Rows > 0
+	resource.FormatConfig = xlsxOpts.Map()
+	return table.schema, table.report, tr.BytesRead(), nil
+}
+
+// sheetRows reads rows of text cells from a spreadsheet reader with
+// sheetTextSchema, for inferTable
+func sheetRows(r dsio.EntryReader, format string) func() ([]string, error) {
+	return func() ([]string, error) {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err == io.EOF {
+				return nil, err
+			}
+			return nil, fmt.Errorf("error reading %s file: %s", format, err.Error())
+		}
+		cells, _ := ent.Value.([]interface{})
+		row := make([]string, len(cells))
+		for i, c := range cells {
+			row[i], _ = c.(string)
+		}
+		return row, nil
+	}
 }
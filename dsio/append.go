@@ -0,0 +1,531 @@
+package dsio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+	"github.com/ugorji/go/codec"
+)
+
+// AppendEntries writes a new body to dst made of an existing body followed by
+// entries read from r, returning a copy of st with updated Entries, Length,
+// Depth & Checksum values. st must describe the existing body, and r must
+// produce entries that match it. Entries assumes st.Entries counts the entries
+// of the existing body, and Depth can only increase from st.Depth.
+//
+// NDJSON & CSV bodies are appended to without decoding existing entries, and
+// compressed bodies get the new entries as an additional compressed stream
+// where the format allows, like a gzip member or zstd frame. seekable zstd
+// bodies are recompressed with a single seek table. JSON & CBOR bodies are
+// copied up to their closing bracket or item count, and have new entries
+// inserted. Appended CBOR map keys aren't sorted canonically, and duplicate
+// keys in object bodies aren't checked against the existing body
+func AppendEntries(dst io.Writer, body io.Reader, st *dataset.Structure, r EntryReader) (*dataset.Structure, error) {
+	sum := newChecksumWriter(dst)
+
+	var (
+		appended *appendCount
+		err      error
+	)
+	switch st.DataFormat() {
+	case dataset.NDJSONDataFormat, dataset.CSVDataFormat:
+		appended, err = appendText(sum, body, st, r)
+	case dataset.JSONDataFormat:
+		appended, err = appendJSON(sum, body, st, r)
+	case dataset.CBORDataFormat:
+		appended, err = appendCBOR(sum, body, st, r)
+	case dataset.UnknownDataFormat:
+		err = fmt.Errorf("structure must have a data format")
+	default:
+		err = fmt.Errorf("appending is not supported for %s data", st.Format)
+	}
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	updated := &dataset.Structure{}
+	updated.Assign(st)
+	updated.Entries = st.Entries + appended.entries
+	if appended.depth > updated.Depth {
+		updated.Depth = appended.depth
+	}
+	updated.Length = int(sum.n)
+	if updated.Checksum, err = dataset.HashSum(sum.h); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// appendCount tracks entries written by an append
+type appendCount struct {
+	entries int
+	depth   int
+}
+
+// add records an appended entry value
+func (c *appendCount) add(v interface{}) {
+	c.entries++
+	if d := valueDepth(v) + 1; d > c.depth {
+		c.depth = d
+	}
+}
+
+// valueDepth gives the nesting level of composite types in a value
+func valueDepth(v interface{}) int {
+	depth := 0
+	switch x := v.(type) {
+	case []interface{}:
+		for _, el := range x {
+			if d := valueDepth(el); d > depth {
+				depth = d
+			}
+		}
+		return depth + 1
+	case map[string]interface{}:
+		for _, el := range x {
+			if d := valueDepth(el); d > depth {
+				depth = d
+			}
+		}
+		return depth + 1
+	}
+	return depth
+}
+
+// checksumWriter hashes & counts bytes written through it
+type checksumWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func newChecksumWriter(w io.Writer) *checksumWriter {
+	return &checksumWriter{w: w, h: sha256.New()}
+}
+
+func (cw *checksumWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.h.Write(p[:n])
+	cw.n += int64(n)
+	return n, err
+}
+
+// lastByteWriter remembers the final byte written to it
+type lastByteWriter struct {
+	last  byte
+	empty bool
+}
+
+func (lw *lastByteWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		lw.last = p[len(p)-1]
+		lw.empty = false
+	}
+	return len(p), nil
+}
+
+// holdbackWriter passes bytes through to w, holding back the final byte
+// written
+type holdbackWriter struct {
+	w    io.Writer
+	last byte
+	held bool
+}
+
+func (hw *holdbackWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if hw.held {
+		if _, err := hw.w.Write([]byte{hw.last}); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := hw.w.Write(p[:len(p)-1]); err != nil {
+		return 0, err
+	}
+	hw.last, hw.held = p[len(p)-1], true
+	return len(p), nil
+}
+
+// appendText copies the raw bytes of a line-oriented body, decoding them only
+// to check the body ends with a line terminator, then writes new entries
+func appendText(w io.Writer, body io.Reader, st *dataset.Structure, r EntryReader) (*appendCount, error) {
+	concat, err := concatenable(st)
+	if err != nil {
+		return nil, err
+	}
+	if st.Compression != "" && !concat {
+		// compressed streams that can't be joined are decompressed & recompressed
+		dr, closeDecompressor, err := maybeWrapDecompressor(st, body)
+		if err != nil {
+			return nil, err
+		}
+		defer closeDecompressor()
+		cw, closeCompressor, err := maybeWrapCompressor(st, w)
+		if err != nil {
+			return nil, err
+		}
+		ust := &dataset.Structure{}
+		ust.Assign(st)
+		ust.Compression = ""
+		count, err := appendText(cw, dr, ust, r)
+		if err != nil {
+			return nil, err
+		}
+		return count, closeCompressor()
+	}
+
+	tail := &lastByteWriter{empty: true}
+	dr, closeDecoder, err := maybeWrapTextDecoder(st, io.TeeReader(body, w))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(tail, dr); err != nil {
+		return nil, err
+	}
+	if closeDecoder != nil {
+		if err := closeDecoder(); err != nil {
+			return nil, err
+		}
+	}
+
+	// new entries are encoded separately from the existing body. the writer
+	// gets a copy of the structure without compression or encoding, and a
+	// CSV header row is only written to empty bodies
+	ew, closeEncoder, err := maybeWrapTextEncoder(st, w)
+	if err != nil {
+		return nil, err
+	}
+	wst := &dataset.Structure{}
+	wst.Assign(st)
+	wst.Compression = ""
+	wst.Encoding = ""
+
+	terminator := "\n"
+	if st.DataFormat() == dataset.CSVDataFormat {
+		opts, err := dataset.NewCSVOptions(st.FormatConfig)
+		if err != nil {
+			return nil, err
+		}
+		if opts.LineTerminator != "" {
+			terminator = opts.LineTerminator
+		}
+		if !tail.empty && opts.HeaderRow {
+			opts.HeaderRow = false
+			wst.FormatConfig = opts.Map()
+		}
+	}
+	if !tail.empty && tail.last != '\n' && tail.last != '\r' {
+		if _, err := io.WriteString(ew, terminator); err != nil {
+			return nil, err
+		}
+	}
+
+	wr, err := NewEntryWriter(wst, ew)
+	if err != nil {
+		return nil, err
+	}
+	count, err := copyAppendEntries(wr, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := wr.Close(); err != nil {
+		return nil, err
+	}
+	if closeEncoder != nil {
+		if err := closeEncoder(); err != nil {
+			return nil, err
+		}
+	}
+	return count, nil
+}
+
+// concatenable reports if new entries can be appended to a compressed body
+// as an additional compressed stream. seekable zstd bodies end with a seek
+// table indexing every frame, so a second stream would need a merged table
+func concatenable(st *dataset.Structure) (bool, error) {
+	if !compression.Concatenable(compression.Format(st.Compression)) {
+		return false, nil
+	}
+	opts, err := compression.NewOptions(st.CompressionConfig)
+	if err != nil {
+		return false, err
+	}
+	return opts.FrameSize == 0, nil
+}
+
+// copyAppendEntries writes all entries from r to w, counting them
+func copyAppendEntries(w EntryWriter, r EntryReader) (*appendCount, error) {
+	count := &appendCount{}
+	for {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err == io.EOF {
+				return count, nil
+			}
+			return nil, fmt.Errorf("reading appended entries: %w", err)
+		}
+		if err := w.WriteEntry(ent); err != nil {
+			return nil, err
+		}
+		count.add(ent.Value)
+	}
+}
+
+// appendJSON copies a JSON body up to it's closing bracket, inserts new
+// entries, and closes the top level type. Compressed & encoded bodies are
+// decoded and re-encoded in full
+func appendJSON(w io.Writer, body io.Reader, st *dataset.Structure, r EntryReader) (*appendCount, error) {
+	tlt, err := GetTopLevelType(st)
+	if err != nil {
+		return nil, err
+	}
+	open, close := byte('['), byte(']')
+	if tlt == "object" {
+		open, close = '{', '}'
+	}
+
+	dr, closeDecoder, err := maybeWrapTextDecoder(st, body)
+	if err != nil {
+		return nil, err
+	}
+	if closeDecoder != nil {
+		defer closeDecoder()
+	}
+	ew, closeEncoder, err := maybeWrapTextEncoder(st, w)
+	if err != nil {
+		return nil, err
+	}
+
+	// copy everything but the final non-whitespace byte & trailing whitespace,
+	// which must be the closing bracket. the byte before it tells if the
+	// existing body is empty
+	var (
+		held      []byte
+		lastWrote byte
+		chunk     = make([]byte, 32*1024)
+		rdr       = bufio.NewReader(dr)
+	)
+	for {
+		n, err := rdr.Read(chunk)
+		if n > 0 {
+			held = append(held, chunk[:n]...)
+			if i := lastNonWhitespace(held); i > 0 {
+				if _, err := ew.Write(held[:i]); err != nil {
+					return nil, err
+				}
+				if j := lastNonWhitespace(held[:i]); j >= 0 {
+					lastWrote = held[j]
+				}
+				held = append([]byte{}, held[i:]...)
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(held) == 0 || held[0] != close || lastWrote == 0 {
+		if len(bytes.TrimSpace(held)) > 0 || lastWrote != 0 {
+			return nil, fmt.Errorf("invalid JSON body: expected body to end with '%c'", close)
+		}
+		// an empty body starts a new top level type
+		held = []byte{close}
+		if _, err := ew.Write([]byte{open}); err != nil {
+			return nil, err
+		}
+		lastWrote = open
+	}
+
+	jw := &JSONWriter{st: st, tlt: tlt, keysWritten: map[string]bool{}}
+	count := &appendCount{}
+	for {
+		ent, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading appended entries: %w", err)
+		}
+		data, err := jw.valBytes(ent)
+		if err != nil {
+			return nil, err
+		}
+		if lastWrote != open || count.entries > 0 {
+			data = append([]byte{','}, data...)
+		}
+		if _, err := ew.Write(data); err != nil {
+			return nil, err
+		}
+		count.add(ent.Value)
+	}
+
+	if _, err := ew.Write(held); err != nil {
+		return nil, err
+	}
+	if closeEncoder != nil {
+		if err := closeEncoder(); err != nil {
+			return nil, err
+		}
+	}
+	return count, nil
+}
+
+// lastNonWhitespace gives the index of the final non-whitespace byte in a
+// slice, or -1 if the slice is all whitespace
+func lastNonWhitespace(p []byte) int {
+	for i := len(p) - 1; i >= 0; i-- {
+		if !isWhitespace(p[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendCBOR rewrites the item count of a CBOR body's top level array or
+// map, copies existing items and adds new ones. Indefinite length bodies
+// have new items inserted before the closing break. New entries are buffered
+// with an EntryStore to count them before the header is written
+func appendCBOR(w io.Writer, body io.Reader, st *dataset.Structure, r EntryReader) (*appendCount, error) {
+	tlt, err := GetTopLevelType(st)
+	if err != nil {
+		return nil, err
+	}
+	major := byte(cborBaseArray)
+	if tlt == "object" {
+		major = cborBaseMap
+	}
+
+	store := NewEntryStore(st, 0)
+	defer store.Close()
+	count := &appendCount{}
+	for {
+		ent, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading appended entries: %w", err)
+		}
+		if tlt == "object" && ent.Key == "" {
+			return nil, fmt.Errorf("entry key cannot be empty")
+		}
+		if err := store.WriteEntry(ent); err != nil {
+			return nil, err
+		}
+		count.add(ent.Value)
+	}
+
+	dr, closeDecoder, err := maybeWrapDecompressor(st, body)
+	if err != nil {
+		return nil, err
+	}
+	if closeDecoder != nil {
+		defer closeDecoder()
+	}
+	cw, closeEncoder, err := maybeWrapCompressor(st, w)
+	if err != nil {
+		return nil, err
+	}
+
+	rdr := bufio.NewReader(dr)
+	length := 0
+	b, err := rdr.ReadByte()
+	if err == io.EOF {
+		// an empty body starts a new top level type
+		b = major
+	} else if err != nil {
+		return nil, err
+	} else if b&cborTypeMask != major {
+		return nil, fmt.Errorf("invalid CBOR body: top level type doesn't match schema")
+	} else if b&0x1f != 0x1f {
+		cr := &CBORReader{rdr: rdr}
+		n, err := cr.getVarLenInt(b)
+		if err != nil {
+			return nil, err
+		}
+		length = int(n)
+	}
+
+	indefinite := b&0x1f == 0x1f
+	if indefinite {
+		// hold back the break byte that ends the existing items
+		if _, err := cw.Write([]byte{b}); err != nil {
+			return nil, err
+		}
+		hw := &holdbackWriter{w: cw}
+		if _, err := io.Copy(hw, rdr); err != nil {
+			return nil, err
+		}
+		if !hw.held || hw.last != cborBdBreak {
+			return nil, fmt.Errorf("invalid CBOR body: missing indefinite length break")
+		}
+	} else {
+		if _, err := cw.Write(cborHeader(major, uint64(length+count.entries))); err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(cw, rdr); err != nil {
+			return nil, err
+		}
+	}
+
+	sr, err := store.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+	h := &codec.CborHandle{TimeRFC3339: true}
+	h.Canonical = true
+	enc := codec.NewEncoder(cw, h)
+	for {
+		ent, err := sr.ReadEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if tlt == "object" {
+			if err := enc.Encode(ent.Key); err != nil {
+				return nil, err
+			}
+		}
+		if err := enc.Encode(ent.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if indefinite {
+		if _, err := cw.Write([]byte{cborBdBreak}); err != nil {
+			return nil, err
+		}
+	}
+	if closeEncoder != nil {
+		if err := closeEncoder(); err != nil {
+			return nil, err
+		}
+	}
+	return count, nil
+}
+
+// cborHeader encodes a CBOR major type & length
+func cborHeader(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major | byte(n)}
+	case n <= 0xff:
+		return []byte{major | 24, byte(n)}
+	case n <= 0xffff:
+		return []byte{major | 25, byte(n >> 8), byte(n)}
+	case n <= 0xffffffff:
+		return []byte{major | 26, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+	return []byte{major | 27, byte(n >> 56), byte(n >> 48), byte(n >> 40), byte(n >> 32), byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+}
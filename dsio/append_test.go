@@ -0,0 +1,255 @@
+package dsio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+)
+
+func TestAppendEntries(t *testing.T) {
+	csvSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "a", "type": "integer"},
+				map[string]interface{}{"title": "b", "type": "string"},
+			},
+		},
+	}
+	rows := []Entry{
+		{Index: 0, Value: []interface{}{int64(3), "c"}},
+		{Index: 1, Value: []interface{}{int64(4), "d"}},
+	}
+	keyed := []Entry{
+		{Key: "c", Value: int64(3)},
+		{Key: "d", Value: []interface{}{int64(4)}},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		body        string
+		entries     []Entry
+		expect      string
+		depth       int
+	}{
+		{"ndjson", &dataset.Structure{Format: "ndjson", Schema: dataset.BaseSchemaArray, Entries: 2, Depth: 2},
+			"[1,\"a\"]\n[2,\"b\"]\n", rows, "[1,\"a\"]\n[2,\"b\"]\n[3,\"c\"]\n[4,\"d\"]\n", 2},
+		{"ndjson missing final newline", &dataset.Structure{Format: "ndjson", Schema: dataset.BaseSchemaArray, Entries: 1},
+			"{\"a\":1}", rows[:1], "{\"a\":1}\n[3,\"c\"]\n", 2},
+		{"csv header row", &dataset.Structure{Format: "csv", Schema: csvSchema, FormatConfig: map[string]interface{}{"headerRow": true}, Entries: 1},
+			"a,b\n1,a\n", rows, "a,b\n1,a\n3,c\n4,d\n", 2},
+		{"csv empty body", &dataset.Structure{Format: "csv", Schema: csvSchema, FormatConfig: map[string]interface{}{"headerRow": true}},
+			"", rows[:1], "a,b\n3,c\n", 2},
+		{"csv crlf", &dataset.Structure{Format: "csv", Schema: csvSchema, FormatConfig: map[string]interface{}{"lineTerminator": "\r\n"}, Entries: 1},
+			"1,a", rows[:1], "1,a\r\n3,c\r\n", 2},
+		{"json array", &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, Entries: 1, Depth: 3},
+			"[[1,[\"a\"]]]\n", rows, "[[1,[\"a\"]],[3,\"c\"],[4,\"d\"]]\n", 3},
+		{"json empty array", &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+			"[ ]", rows[:1], "[ [3,\"c\"]]", 2},
+		{"json empty body", &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaObject},
+			"", keyed, `{"c":3,"d":[4]}`, 2},
+		{"json object", &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaObject, Entries: 1, Depth: 1},
+			"{\n  \"a\": 1\n}\n", keyed, "{\n  \"a\": 1\n,\"c\":3,\"d\":[4]}\n", 2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			got, err := AppendEntries(buf, bytes.NewBufferString(c.body), c.st, newAppendReader(c.st, c.entries))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, buf.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+			checksum, _ := dataset.HashBytes(buf.Bytes())
+			expect := &dataset.Structure{}
+			expect.Assign(c.st)
+			expect.Entries = c.st.Entries + len(c.entries)
+			expect.Depth = c.depth
+			expect.Length = buf.Len()
+			expect.Checksum = checksum
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("structure mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppendEntriesCompressed(t *testing.T) {
+	formats := []compression.Format{
+		compression.FmtGZip,
+		compression.FmtZStandard,
+		compression.FmtXZ,
+		compression.FmtLZ4,
+		compression.FmtSnappy,
+		compression.FmtBrotli,
+	}
+
+	for _, f := range formats {
+		t.Run(f.String(), func(t *testing.T) {
+			st := &dataset.Structure{Format: "ndjson", Schema: dataset.BaseSchemaArray, Compression: f.String(), Entries: 1}
+			body := &bytes.Buffer{}
+			w, err := NewEntryWriter(st, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.WriteEntry(Entry{Value: "a"})
+			w.Close()
+			prefix := body.Bytes()
+
+			buf := &bytes.Buffer{}
+			got, err := AppendEntries(buf, bytes.NewReader(prefix), st, newAppendReader(st, []Entry{{Value: "b"}}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compression.Concatenable(f) && !bytes.HasPrefix(buf.Bytes(), prefix) {
+				t.Error("expected compressed body to be appended to without rewriting existing bytes")
+			}
+			if got.Entries != 2 {
+				t.Errorf("entries mismatch. expected: 2, got: %d", got.Entries)
+			}
+
+			r, err := NewEntryReader(st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vals, err := ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]interface{}{"a", "b"}, vals); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppendEntriesCBOR(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		existing    []Entry
+		appended    []Entry
+	}{
+		{"array", &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray},
+			[]Entry{{Value: "a"}, {Value: int64(1)}}, []Entry{{Value: []interface{}{"b"}}, {Value: true}}},
+		{"object", &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaObject},
+			[]Entry{{Key: "a", Value: "a"}}, []Entry{{Key: "b", Value: int64(2)}}},
+		{"long array", &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray},
+			make([]Entry, 23), make([]Entry, 2)},
+		{"compressed", &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray, Compression: compression.FmtZStandard.String()},
+			[]Entry{{Value: "a"}}, []Entry{{Value: "b"}}},
+		{"empty body", &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray},
+			nil, []Entry{{Value: "b"}}},
+		{"floats & bytes", &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray},
+			[]Entry{{Value: 1.5}}, []Entry{{Value: float64(3)}, {Value: []byte("bytes")}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			if c.existing != nil {
+				w, err := NewEntryWriter(c.st, body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, ent := range c.existing {
+					w.WriteEntry(ent)
+				}
+				w.Close()
+			}
+			c.st.Entries = len(c.existing)
+
+			buf := &bytes.Buffer{}
+			got, err := AppendEntries(buf, body, c.st, newAppendReader(c.st, c.appended))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Entries != len(c.existing)+len(c.appended) {
+				t.Errorf("entries mismatch. expected: %d, got: %d", len(c.existing)+len(c.appended), got.Entries)
+			}
+
+			r, err := NewEntryReader(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expect := []Entry{}
+			for i, ent := range append(c.existing, c.appended...) {
+				if c.st.Schema["type"] == "array" {
+					ent.Index = i
+				}
+				expect = append(expect, ent)
+			}
+			gotEntries := []Entry{}
+			if err := EachEntry(r, func(i int, ent Entry, err error) error {
+				gotEntries = append(gotEntries, ent)
+				return err
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, gotEntries); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppendEntriesCBORIndefinite(t *testing.T) {
+	st := &dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaArray, Entries: 2}
+	// indefinite length array of "a" & 1, ended by a break byte
+	body := bytes.NewBuffer([]byte{0x9f, 0x61, 'a', 0x01, 0xff})
+
+	buf := &bytes.Buffer{}
+	if _, err := AppendEntries(buf, body, st, newAppendReader(st, []Entry{{Value: "b"}})); err != nil {
+		t.Fatal(err)
+	}
+	expect := []byte{0x9f, 0x61, 'a', 0x01, 0x61, 'b', 0xff}
+	if !bytes.Equal(expect, buf.Bytes()) {
+		t.Errorf("result mismatch. expected: %x, got: %x", expect, buf.Bytes())
+	}
+
+	_, err := AppendEntries(ioutil.Discard, bytes.NewBuffer([]byte{0x9f, 0x01}), st, newAppendReader(st, nil))
+	if err == nil || err.Error() != "invalid CBOR body: missing indefinite length break" {
+		t.Errorf("expected missing break error, got: %v", err)
+	}
+}
+
+func TestAppendEntriesErrors(t *testing.T) {
+	cases := []struct {
+		st   *dataset.Structure
+		body string
+		err  string
+	}{
+		{&dataset.Structure{}, "", "structure must have a data format"},
+		{&dataset.Structure{Format: "xlsx", Schema: dataset.BaseSchemaArray}, "", "appending is not supported for xlsx data"},
+		{&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, `{"a":1}`, "invalid JSON body: expected body to end with ']'"},
+		{&dataset.Structure{Format: "cbor", Schema: dataset.BaseSchemaObject}, "\x80", "invalid CBOR body: top level type doesn't match schema"},
+	}
+
+	for i, c := range cases {
+		_, err := AppendEntries(ioutil.Discard, bytes.NewBufferString(c.body), c.st, newAppendReader(c.st, nil))
+		if err == nil || err.Error() != c.err {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+		}
+	}
+}
+
+// newAppendReader creates an EntryReader of entries, via an EntryStore
+func newAppendReader(st *dataset.Structure, entries []Entry) EntryReader {
+	s := NewEntryStore(st, 0)
+	for _, ent := range entries {
+		s.WriteEntry(ent)
+	}
+	r, err := s.NewReader()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
@@ -0,0 +1,75 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
+)
+
+// archiveEntryReader reads entries from a member of an archive
+type archiveEntryReader struct {
+	EntryReader
+	st     *dataset.Structure
+	member io.Closer
+}
+
+// archivePositionReader is an archiveEntryReader that can locate entries
+// within the archive member
+type archivePositionReader struct {
+	*archiveEntryReader
+	pr PositionReader
+}
+
+// newArchiveEntryReader opens the archive member a structure names, reading
+// it with a copy of the structure that doesn't specify an archive
+func newArchiveEntryReader(st *dataset.Structure, r io.Reader) (EntryReader, error) {
+	f, err := archive.ParseFormat(st.Archive)
+	if err != nil {
+		return nil, err
+	}
+	if st.ArchiveMember == "" {
+		return nil, fmt.Errorf("archiveMember is required to read %s archives", f)
+	}
+
+	member, err := archive.Open(f, r, st.ArchiveMember)
+	if err != nil {
+		return nil, err
+	}
+
+	mst := &dataset.Structure{}
+	mst.Assign(st)
+	mst.Archive = ""
+	mst.ArchiveMember = ""
+	er, err := NewEntryReader(mst, member)
+	if err != nil {
+		member.Close()
+		return nil, err
+	}
+
+	ar := &archiveEntryReader{EntryReader: er, st: st, member: member}
+	if pr, ok := er.(PositionReader); ok {
+		return &archivePositionReader{archiveEntryReader: ar, pr: pr}, nil
+	}
+	return ar, nil
+}
+
+// Structure gives the structure being read
+func (r *archiveEntryReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// Close finalizes the reader & closes the archive
+func (r *archiveEntryReader) Close() error {
+	err := r.EntryReader.Close()
+	if cerr := r.member.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// EntryPosition gives the position of a value within the archive member
+func (r *archivePositionReader) EntryPosition(field int) Position {
+	return r.pr.EntryPosition(field)
+}
@@ -0,0 +1,69 @@
+package dsio
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestArchiveEntryReader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("data/body.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("one\ntwo\nthree\n"))
+	zw.Close()
+	data := buf.Bytes()
+
+	st := &dataset.Structure{
+		Format:        "csv",
+		Schema:        basicTableSchema,
+		Archive:       "zip",
+		ArchiveMember: "data/body.csv",
+	}
+	r, err := NewEntryReader(st, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Structure() != st {
+		t.Error("expected reader structure to be the given structure")
+	}
+	got, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"one"},
+		[]interface{}{"two"},
+		[]interface{}{"three"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+
+	errCases := []struct {
+		archive, member, err string
+	}{
+		{"zip", "", "archiveMember is required to read zip archives"},
+		{"zip", "missing.csv", `archive member "missing.csv" not found`},
+		{"rar", "data/body.csv", `unsupported archive format: "rar"`},
+	}
+	for _, c := range errCases {
+		est := &dataset.Structure{Format: "csv", Schema: basicTableSchema, Archive: c.archive, ArchiveMember: c.member}
+		if _, err := NewEntryReader(est, bytes.NewReader(data)); err == nil || err.Error() != c.err {
+			t.Errorf("%s/%s error mismatch. expected: %q, got: %v", c.archive, c.member, c.err, err)
+		}
+	}
+
+	if _, err := NewEntryWriter(st, &bytes.Buffer{}); err == nil {
+		t.Error("expected writing an archived body to error")
+	}
+}
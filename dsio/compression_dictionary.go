@@ -0,0 +1,56 @@
+package dsio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+)
+
+// TrainCompressionDictionary builds a zstd dictionary from sample entries,
+// for use as the "dictionary" value of a structure's compressionConfig. Each
+// entry read from r is encoded on it's own in the data format of st to make a
+// sample, at least 512 are required. size caps the dictionary size, zero or
+// less uses compression.DefaultDictionarySize
+func TrainCompressionDictionary(st *dataset.Structure, r EntryReader, size int) ([]byte, error) {
+	sst := &dataset.Structure{}
+	sst.Assign(st)
+	sst.Compression = ""
+	sst.CompressionConfig = nil
+	if sst.DataFormat() == dataset.CSVDataFormat && HasHeaderRow(sst) {
+		opts, err := dataset.NewCSVOptions(sst.FormatConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts.HeaderRow = false
+		sst.FormatConfig = opts.Map()
+	}
+
+	samples := [][]byte{}
+	buf := &bytes.Buffer{}
+	for {
+		ent, err := r.ReadEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading sample entries: %w", err)
+		}
+
+		buf.Reset()
+		w, err := NewEntryWriter(sst, buf)
+		if err != nil {
+			return nil, err
+		}
+		if err := w.WriteEntry(ent); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		samples = append(samples, append([]byte{}, buf.Bytes()...))
+	}
+
+	return compression.TrainDictionary(samples, size)
+}
@@ -0,0 +1,83 @@
+package dsio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+)
+
+func TestTrainCompressionDictionary(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "csv",
+		FormatConfig: map[string]interface{}{
+			"headerRow": true,
+		},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "id", "type": "integer"},
+					map[string]interface{}{"title": "city", "type": "string"},
+				},
+			},
+		},
+	}
+
+	entries := []Entry{}
+	for i := 0; i < 2000; i++ {
+		entries = append(entries, Entry{Index: i, Value: []interface{}{int64(i), fmt.Sprintf("city number %d of the sample", i%20)}})
+	}
+	dict, err := TrainCompressionDictionary(st, newAppendReader(st, entries), 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cst := &dataset.Structure{}
+	cst.Assign(st)
+	cst.Compression = compression.FmtZStandard.String()
+	cst.CompressionConfig = map[string]interface{}{
+		"level":      float64(19),
+		"dictionary": base64.StdEncoding.EncodeToString(dict),
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(cst, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ent := range entries {
+		if err := w.WriteEntry(ent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewEntryReader(cst, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{}
+	for _, ent := range entries {
+		expect = append(expect, ent.Value)
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	cst.CompressionConfig = map[string]interface{}{"level": "max"}
+	if _, err := NewEntryWriter(cst, &bytes.Buffer{}); err == nil {
+		t.Error("expected invalid compression config to error")
+	}
+}
@@ -1,11 +1,13 @@
 package dsio
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
+	strs "strings"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio/replacecr"
@@ -17,12 +19,23 @@ import (
 type CSVReader struct {
 	st         *dataset.Structure
 	readHeader bool
-	r          *csv.Reader
+	r          csvRecordReader
 	close      func() error
+	nullTokens map[string]struct{}
+	trimSpace  bool
+
+	// lines & bytes skipped before csv parsing begins
+	skippedLines int
+	skippedBytes int64
+	// offset of the most recently read record, and it's field count
+	recordOffset int64
+	fieldCount   int
 
 	// TODO (b5) - this will create problems if users define schemas that support
 	// mutiple types per column. Should replace with a tabular.Columns field
 	types []string
+	// formats coerces string columns with a date or time format
+	formats []columnFormat
 }
 
 var _ EntryReader = (*CSVReader)(nil)
@@ -45,33 +58,106 @@ func NewCSVReaderSize(st *dataset.Structure, r io.Reader, size int) (*CSVReader,
 		types[i] = []string(*c.Type)[0]
 	}
 
-	dr, close, err := maybeWrapDecompressor(st, r)
+	dr, close, err := maybeWrapTextDecoder(st, r)
 	if err != nil {
 		return nil, err
 	}
 
-	csvr := csv.NewReader(replacecr.ReaderWithSize(dr, size))
+	opts, err := dataset.NewCSVOptions(st.FormatConfig)
+	if err != nil {
+		opts = &dataset.CSVOptions{}
+	}
 
-	if fopts, err := dataset.ParseFormatConfigMap(dataset.CSVDataFormat, st.FormatConfig); err == nil {
-		if opts, ok := fopts.(*dataset.CSVOptions); ok {
-			csvr.LazyQuotes = opts.LazyQuotes
-			if opts.VariadicFields == true {
-				csvr.FieldsPerRecord = -1
-			}
-			if opts.Separator != rune(0) {
-				csvr.Comma = opts.Separator
-			}
+	var (
+		src          io.Reader = replacecr.ReaderWithSize(dr, size)
+		skippedLines int
+		skippedBytes int64
+	)
+	if opts.SkipInitialRows > 0 {
+		if src, skippedLines, skippedBytes, err = skipLines(src, opts.SkipInitialRows); err != nil {
+			return nil, err
+		}
+	}
+
+	rr := newCSVRecordReader(src, opts)
+
+	var nullTokens map[string]struct{}
+	if len(opts.NullTokens) > 0 {
+		nullTokens = make(map[string]struct{}, len(opts.NullTokens))
+		for _, t := range opts.NullTokens {
+			nullTokens[t] = struct{}{}
 		}
 	}
 
 	return &CSVReader{
-		st:    st,
-		r:     csvr,
-		types: types,
-		close: close,
+		st:         st,
+		r:          rr,
+		types:      types,
+		formats:    columnFormats(cols),
+		close:      close,
+		nullTokens: nullTokens,
+		trimSpace:  opts.TrimSpace,
+
+		skippedLines: skippedLines,
+		skippedBytes: skippedBytes,
 	}, nil
 }
 
+// CSVRecordReader reads raw records of CSV data
+type CSVRecordReader interface {
+	// Read reads one record, returning io.EOF when no records remain
+	Read() ([]string, error)
+}
+
+// NewCSVRecordReader creates a reader of raw CSV records in the dialect opts
+// describe, including quote & escape characters encoding/csv doesn't support.
+// Other options like header rows & null tokens are left to callers. Readers
+// reuse record slices, records are only valid until the next call to Read,
+// callers that keep records must copy them
+func NewCSVRecordReader(r io.Reader, opts *dataset.CSVOptions) CSVRecordReader {
+	if opts == nil {
+		opts = &dataset.CSVOptions{}
+	}
+	return newCSVRecordReader(r, opts)
+}
+
+// newCSVRecordReader uses the standard library csv reader for double quoted
+// fields, falling back to csvDialectReader for other quote & escape characters
+func newCSVRecordReader(src io.Reader, opts *dataset.CSVOptions) csvRecordReader {
+	if (opts.QuoteChar == rune(0) || opts.QuoteChar == '"') && opts.EscapeChar == rune(0) {
+		csvr := csv.NewReader(src)
+		csvr.LazyQuotes = opts.LazyQuotes
+		if opts.VariadicFields == true {
+			csvr.FieldsPerRecord = -1
+		}
+		if opts.Separator != rune(0) {
+			csvr.Comma = opts.Separator
+		}
+		csvr.Comment = opts.Comment
+		csvr.TrimLeadingSpace = opts.TrimSpace
+		csvr.ReuseRecord = true
+		return csvr
+	}
+	return newCSVDialectReader(src, opts)
+}
+
+// skipLines discards up to n lines from the start of a reader, reporting the
+// number of lines & bytes skipped
+func skipLines(r io.Reader, n int) (rdr io.Reader, lines int, bytes int64, err error) {
+	br := bufio.NewReader(r)
+	for ; lines < n; lines++ {
+		line, err := br.ReadString('\n')
+		bytes += int64(len(line))
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, 0, 0, err
+		}
+	}
+	return br, lines, bytes, nil
+}
+
 // Structure gives this reader's structure
 func (r *CSVReader) Structure() *dataset.Structure {
 	return r.st
@@ -91,11 +177,13 @@ func (r *CSVReader) ReadEntry() (Entry, error) {
 		r.readHeader = true
 	}
 
+	r.recordOffset = r.r.InputOffset()
 	data, err := r.r.Read()
 	if err != nil {
 		log.Debug(err.Error())
 		return Entry{}, err
 	}
+	r.fieldCount = len(data)
 
 	value, err := r.decode(data)
 	if err != nil {
@@ -106,6 +194,29 @@ func (r *CSVReader) ReadEntry() (Entry, error) {
 	return Entry{Value: value}, nil
 }
 
+// EntryPosition gives the position of a field in the most recently read
+// record, or the start of the record if field is -1
+func (r *CSVReader) EntryPosition(field int) Position {
+	if r.fieldCount == 0 {
+		return Position{Offset: r.skippedBytes + r.recordOffset}
+	}
+	first := 0
+	if field >= 0 && field < r.fieldCount {
+		first = field
+	}
+	line, col := r.r.FieldPos(first)
+	pos := Position{
+		Line:   line + r.skippedLines,
+		Column: col,
+		Offset: r.skippedBytes + r.recordOffset,
+	}
+	// values on the first line of a record are offset from the record start
+	if startLine, startCol := r.r.FieldPos(0); startLine == line && startCol == 1 {
+		pos.Offset += int64(col - 1)
+	}
+	return pos
+}
+
 // Close finalizes the reader
 func (r *CSVReader) Close() error {
 	if r.close != nil {
@@ -129,9 +240,21 @@ func (r *CSVReader) decode(strings []string) ([]interface{}, error) {
 		}
 	}
 	for i, str := range strings {
+		if r.trimSpace {
+			str = strs.TrimSpace(str)
+		}
 		vs[i] = str
 
+		if _, isNull := r.nullTokens[str]; isNull {
+			vs[i] = nil
+			continue
+		}
+
 		switch types[i] {
+		case "string":
+			if i < len(r.formats) {
+				vs[i] = r.formats[i].coerce(str)
+			}
 		case "number":
 			if num, err := vals.ParseNumber([]byte(str)); err == nil {
 				vs[i] = num
@@ -176,9 +299,10 @@ func HasHeaderRow(st *dataset.Structure) bool {
 // CSV-formatted data
 type CSVWriter struct {
 	rowsWritten int
-	w           *csv.Writer
+	w           *csvDialectWriter
 	st          *dataset.Structure
 	close       func() error
+	nullToken   string
 
 	// TODO (b5) - this will create problems if users define schemas that support
 	// mutiple types per column. Should replace with a tabular.Columns field
@@ -198,18 +322,16 @@ func NewCSVWriter(st *dataset.Structure, w io.Writer) (*CSVWriter, error) {
 		types[i] = []string(*c.Type)[0]
 	}
 
-	cw, close, err := maybeWrapCompressor(st, w)
+	cw, close, err := maybeWrapTextEncoder(st, w)
 	if err != nil {
 		return nil, err
 	}
 
-	writer := csv.NewWriter(cw)
 	opts, err := dataset.NewCSVOptions(st.FormatConfig)
-	if opts != nil && err == nil {
-		if opts.Separator != rune(0) {
-			writer.Comma = opts.Separator
-		}
+	if err != nil {
+		opts = nil
 	}
+	writer := newCSVDialectWriter(cw, opts)
 
 	wr := &CSVWriter{
 		st:    st,
@@ -219,8 +341,11 @@ func NewCSVWriter(st *dataset.Structure, w io.Writer) (*CSVWriter, error) {
 	}
 
 	if opts != nil {
+		if len(opts.NullTokens) > 0 {
+			wr.nullToken = opts.NullTokens[0]
+		}
 		if opts.HeaderRow {
-			writer.Write(cols.Titles())
+			writer.Write(cols.Titles(), nil, nil)
 		}
 	}
 
@@ -240,7 +365,18 @@ func (w *CSVWriter) WriteEntry(ent Entry) error {
 			log.Debug(err.Error())
 			return fmt.Errorf("error encoding entry: %s", err.Error())
 		}
-		return w.w.Write(strs)
+		numeric := make([]bool, len(arr))
+		unquoted := make([]bool, len(arr))
+		for i, v := range arr {
+			switch v.(type) {
+			case int, int64, float64:
+				numeric[i] = true
+			case nil:
+				strs[i] = w.nullToken
+				unquoted[i] = true
+			}
+		}
+		return w.w.Write(strs, numeric, unquoted)
 	}
 	return fmt.Errorf("expected array value to write csv row. got: %v", ent)
 }
@@ -285,7 +421,9 @@ func encode(vs []interface{}) ([]string, error) {
 // Close finalizes the writer, indicating no more records
 // will be written
 func (w *CSVWriter) Close() error {
-	w.w.Flush()
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
 	if w.close != nil {
 		return w.close()
 	}
@@ -0,0 +1,350 @@
+package dsio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+)
+
+// csvRecordReader reads one record of CSV data at a time. it's satisfied by
+// *csv.Reader from the standard library, which is used for the common case
+// of double-quoted fields, and csvDialectReader, which supports custom
+// quote & escape characters
+type csvRecordReader interface {
+	Read() ([]string, error)
+	// FieldPos gives the 1-based line & column of a field in the most recently
+	// read record
+	FieldPos(field int) (line, column int)
+	// InputOffset gives the byte offset of the reader's current position
+	InputOffset() int64
+}
+
+// csvDialectReader parses CSV data with configurable quote & escape
+// characters, following the rules of encoding/csv otherwise
+type csvDialectReader struct {
+	r                *bufio.Reader
+	comma            rune
+	quote            rune
+	escape           rune
+	comment          rune
+	lazyQuotes       bool
+	trimLeadingSpace bool
+	// FieldsPerRecord follows the rules of csv.Reader.FieldsPerRecord
+	fieldsPerRecord int
+
+	pos       textPosition
+	lastSize  int
+	fieldPos  []Position
+	recordPos Position
+}
+
+func newCSVDialectReader(r io.Reader, opts *dataset.CSVOptions) *csvDialectReader {
+	cr := &csvDialectReader{
+		r:     bufio.NewReader(r),
+		comma: ',',
+		quote: '"',
+	}
+	if opts.Separator != rune(0) {
+		cr.comma = opts.Separator
+	}
+	if opts.QuoteChar != rune(0) {
+		cr.quote = opts.QuoteChar
+	}
+	cr.escape = opts.EscapeChar
+	cr.comment = opts.Comment
+	cr.lazyQuotes = opts.LazyQuotes
+	cr.trimLeadingSpace = opts.TrimSpace
+	if opts.VariadicFields {
+		cr.fieldsPerRecord = -1
+	}
+	return cr
+}
+
+var errCSVQuote = errors.New("extraneous or missing quote in quoted-field")
+var errCSVBareQuote = errors.New("bare quote in non-quoted-field")
+
+// Read reads one record from the reader
+func (cr *csvDialectReader) Read() ([]string, error) {
+	var (
+		record []string
+		err    error
+	)
+	for record == nil {
+		if record, err = cr.readRecord(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cr.fieldsPerRecord > 0 && len(record) != cr.fieldsPerRecord {
+		return record, fmt.Errorf("record on line %d: wrong number of fields", cr.recordPos.Line)
+	} else if cr.fieldsPerRecord == 0 {
+		cr.fieldsPerRecord = len(record)
+	}
+	return record, nil
+}
+
+// readRecord reads one line of fields. blank lines & comment lines return a
+// nil record
+func (cr *csvDialectReader) readRecord() ([]string, error) {
+	cr.recordPos = cr.pos.Position()
+	r, err := cr.readRune()
+	if err != nil {
+		return nil, err
+	}
+	if r == '\r' || r == '\n' {
+		cr.skipLineEnd(r)
+		return nil, nil
+	}
+	if cr.comment != rune(0) && r == cr.comment {
+		for err == nil && r != '\n' {
+			r, err = cr.readRune()
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, nil
+	}
+	cr.unreadRune()
+
+	var (
+		record []string
+		field  strings.Builder
+	)
+	cr.fieldPos = cr.fieldPos[:0]
+	for {
+		field.Reset()
+		cr.fieldPos = append(cr.fieldPos, cr.pos.Position())
+		end, err := cr.readField(&field)
+		if err != nil {
+			return nil, err
+		}
+		record = append(record, field.String())
+		if end {
+			return record, nil
+		}
+	}
+}
+
+// readField reads a single field into buf, reporting if the field ends the
+// record
+func (cr *csvDialectReader) readField(buf *strings.Builder) (endOfRecord bool, err error) {
+	r, err := cr.readRune()
+	if cr.trimLeadingSpace {
+		for err == nil && r != '\n' && r != '\r' && r != cr.comma && unicode.IsSpace(r) {
+			r, err = cr.readRune()
+		}
+	}
+	if err == io.EOF {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if r != cr.quote {
+		// unquoted field
+		for {
+			switch {
+			case r == cr.comma:
+				return false, nil
+			case r == '\r' || r == '\n':
+				cr.skipLineEnd(r)
+				return true, nil
+			case r == cr.quote && !cr.lazyQuotes:
+				return false, fmt.Errorf("line %d: %w", cr.recordPos.Line, errCSVBareQuote)
+			}
+			buf.WriteRune(r)
+			if r, err = cr.readRune(); err == io.EOF {
+				return true, nil
+			} else if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// quoted field
+	for {
+		r, err = cr.readRune()
+		if err == io.EOF {
+			if cr.lazyQuotes {
+				return true, nil
+			}
+			return false, fmt.Errorf("line %d: %w", cr.recordPos.Line, errCSVQuote)
+		} else if err != nil {
+			return false, err
+		}
+
+		switch {
+		case cr.escape != rune(0) && cr.escape != cr.quote && r == cr.escape:
+			next, err := cr.readRune()
+			if err != nil {
+				return false, fmt.Errorf("line %d: %w", cr.recordPos.Line, errCSVQuote)
+			}
+			buf.WriteRune(next)
+		case r == cr.quote:
+			next, err := cr.readRune()
+			switch {
+			case err == io.EOF:
+				return true, nil
+			case err != nil:
+				return false, err
+			case next == cr.quote && (cr.escape == rune(0) || cr.escape == cr.quote):
+				buf.WriteRune(cr.quote)
+			case next == cr.comma:
+				return false, nil
+			case next == '\r' || next == '\n':
+				cr.skipLineEnd(next)
+				return true, nil
+			case cr.lazyQuotes:
+				buf.WriteRune(r)
+				buf.WriteRune(next)
+			default:
+				return false, fmt.Errorf("line %d: %w", cr.recordPos.Line, errCSVQuote)
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// FieldPos gives the line & column of a field in the most recently read
+// record, following the conventions of csv.Reader.FieldPos
+func (cr *csvDialectReader) FieldPos(field int) (line, column int) {
+	if field < 0 || field >= len(cr.fieldPos) {
+		panic("out of range index passed to FieldPos")
+	}
+	return cr.fieldPos[field].Line, cr.fieldPos[field].Column
+}
+
+// InputOffset gives the byte offset of the reader's current position
+func (cr *csvDialectReader) InputOffset() int64 {
+	return cr.pos.offset
+}
+
+func (cr *csvDialectReader) readRune() (rune, error) {
+	r, size, err := cr.r.ReadRune()
+	if err != nil {
+		return r, err
+	}
+	cr.lastSize = size
+	if r == '\n' {
+		cr.pos.advance([]byte{'\n'})
+	} else {
+		cr.pos.offset += int64(size)
+	}
+	return r, nil
+}
+
+// unreadRune steps back one rune. it must not be used to unread line feeds
+func (cr *csvDialectReader) unreadRune() {
+	cr.r.UnreadRune()
+	cr.pos.offset -= int64(cr.lastSize)
+}
+
+// skipLineEnd consumes the \n of a \r\n line ending
+func (cr *csvDialectReader) skipLineEnd(r rune) {
+	if r == '\r' {
+		if next, err := cr.readRune(); err == nil && next != '\n' {
+			cr.unreadRune()
+		}
+	}
+}
+
+// csvDialectWriter writes CSV records with configurable quoting rules
+type csvDialectWriter struct {
+	w          *bufio.Writer
+	comma      rune
+	quote      rune
+	escape     rune
+	quoteStyle string
+	terminator string
+}
+
+func newCSVDialectWriter(w io.Writer, opts *dataset.CSVOptions) *csvDialectWriter {
+	cw := &csvDialectWriter{
+		w:          bufio.NewWriter(w),
+		comma:      ',',
+		quote:      '"',
+		terminator: "\n",
+	}
+	if opts == nil {
+		return cw
+	}
+	if opts.Separator != rune(0) {
+		cw.comma = opts.Separator
+	}
+	if opts.QuoteChar != rune(0) {
+		cw.quote = opts.QuoteChar
+	}
+	if opts.LineTerminator != "" {
+		cw.terminator = opts.LineTerminator
+	}
+	cw.escape = opts.EscapeChar
+	cw.quoteStyle = opts.QuoteStyle
+	return cw
+}
+
+// Write writes a single record. numeric flags fields that hold number values,
+// which matters for the "nonNumeric" quote style. unquoted flags fields that
+// must never be quoted, like null tokens
+func (cw *csvDialectWriter) Write(record []string, numeric, unquoted []bool) error {
+	for i, field := range record {
+		if i > 0 {
+			cw.w.WriteRune(cw.comma)
+		}
+
+		if (i < len(unquoted) && unquoted[i]) || !cw.needsQuotes(field, i < len(numeric) && numeric[i]) {
+			cw.w.WriteString(field)
+			continue
+		}
+
+		cw.w.WriteRune(cw.quote)
+		for _, r := range field {
+			switch {
+			case r == cw.quote && (cw.escape == rune(0) || cw.escape == cw.quote):
+				cw.w.WriteRune(cw.quote)
+			case r == cw.quote || r == cw.escape:
+				cw.w.WriteRune(cw.escape)
+			}
+			cw.w.WriteRune(r)
+		}
+		cw.w.WriteRune(cw.quote)
+	}
+	_, err := cw.w.WriteString(cw.terminator)
+	return err
+}
+
+func (cw *csvDialectWriter) needsQuotes(field string, numeric bool) bool {
+	switch cw.quoteStyle {
+	case dataset.CSVQuoteAll:
+		return true
+	case dataset.CSVQuoteNonNumeric:
+		if !numeric {
+			return true
+		}
+	}
+
+	// minimal quoting follows the rules of encoding/csv
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsRune(field, cw.comma) || strings.ContainsRune(field, cw.quote) ||
+		strings.ContainsAny(field, "\r\n") {
+		return true
+	}
+	if cw.escape != rune(0) && strings.ContainsRune(field, cw.escape) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
+}
+
+// Flush writes any buffered data to the underlying writer
+func (cw *csvDialectWriter) Flush() error {
+	return cw.w.Flush()
+}
@@ -392,3 +392,169 @@ func BenchmarkCSVReader(b *testing.B) {
 		}
 	}
 }
+
+func csvDialectStructure(fc map[string]interface{}) *dataset.Structure {
+	return &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: fc,
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "name", "type": "string"},
+					map[string]interface{}{"title": "count", "type": "integer"},
+				},
+			},
+		},
+	}
+}
+
+func TestCSVReaderDialect(t *testing.T) {
+	cases := []struct {
+		description string
+		fc          map[string]interface{}
+		data        string
+		expect      []interface{}
+	}{
+		{"null tokens",
+			map[string]interface{}{"headerRow": true, "nullTokens": []interface{}{"NA", `\N`}},
+			"name,count\nNA,1\na,\\N\n",
+			[]interface{}{
+				[]interface{}{nil, int64(1)},
+				[]interface{}{"a", nil},
+			},
+		},
+		{"comments & skipped rows",
+			map[string]interface{}{"headerRow": true, "comment": "#", "skipInitialRows": 2},
+			"exported by some tool\n\"generated\",today\nname,count\n# a comment\na,1\n",
+			[]interface{}{
+				[]interface{}{"a", int64(1)},
+			},
+		},
+		{"trim space",
+			map[string]interface{}{"trimSpace": true},
+			"  a , 1 \n",
+			[]interface{}{
+				[]interface{}{"a", int64(1)},
+			},
+		},
+		{"single quotes",
+			map[string]interface{}{"quoteChar": "'"},
+			"'a, ''b''',1\n\"c\",2\r\n",
+			[]interface{}{
+				[]interface{}{"a, 'b'", int64(1)},
+				[]interface{}{`"c"`, int64(2)},
+			},
+		},
+		{"escape char",
+			map[string]interface{}{"escapeChar": `\`},
+			`"a \"b\" \\c",1` + "\n",
+			[]interface{}{
+				[]interface{}{`a "b" \c`, int64(1)},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := NewEntryReader(csvDialectStructure(c.fc), strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSVWriterDialect(t *testing.T) {
+	rows := []Entry{
+		{Value: []interface{}{"a", 1}},
+		{Value: []interface{}{"b \"c\"", nil}},
+	}
+
+	cases := []struct {
+		description string
+		fc          map[string]interface{}
+		expect      string
+	}{
+		{"default",
+			map[string]interface{}{"headerRow": true},
+			"name,count\na,1\n\"b \"\"c\"\"\",\n",
+		},
+		{"quote all",
+			map[string]interface{}{"quoteStyle": "all", "lineTerminator": "\r\n", "nullTokens": []interface{}{"NA"}},
+			"\"a\",\"1\"\r\n\"b \"\"c\"\"\",NA\r\n",
+		},
+		{"quote non-numeric",
+			map[string]interface{}{"headerRow": true, "quoteStyle": "nonNumeric"},
+			"\"name\",\"count\"\n\"a\",1\n\"b \"\"c\"\"\",\n",
+		},
+		{"single quotes with escape char",
+			map[string]interface{}{"quoteStyle": "all", "quoteChar": "'", "escapeChar": `\`},
+			"'a','1'\n'b \"c\"',\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewEntryWriter(csvDialectStructure(c.fc), buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := w.WriteEntry(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.expect, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSVReaderFormats(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "born", "type": "string", "format": "date", "dateLayout": "D/M/YYYY"},
+					map[string]interface{}{"title": "updated", "type": "string", "format": "date-time"},
+					map[string]interface{}{"title": "email", "type": "string", "format": "email"},
+				},
+			},
+		},
+	}
+	body := "born,updated,email\n25/12/1990,2021-06-02 08:00:00,a@example.com\nunknown,2021-06-03T00:00:00-07:00,b@example.org\n"
+
+	r, err := NewEntryReader(st, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"1990-12-25", "2021-06-02 08:00:00", "a@example.com"},
+		[]interface{}{"unknown", "2021-06-03T00:00:00-07:00", "b@example.org"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -3,6 +3,7 @@ package dsio
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 
@@ -50,8 +51,29 @@ type EntryReadWriter interface {
 	Bytes() []byte
 }
 
-// NewEntryReader allocates a EntryReader based on a given structure
+// NewEntryReader allocates a EntryReader based on a given structure. Archived
+// bodies are read from the archive member the structure names. When the
+// structure doesn't specify compression for a format that can be compressed,
+// compressed data is detected from it's leading bytes and read with a copy of
+// st that sets Compression
 func NewEntryReader(st *dataset.Structure, r io.Reader) (EntryReader, error) {
+	if st.Archive != "" {
+		return newArchiveEntryReader(st, r)
+	}
+	if st.Compression == "" && compressible(st.DataFormat()) {
+		comp, rdr, err := compression.DetectFormat(r)
+		if err != nil {
+			return nil, err
+		}
+		r = rdr
+		if comp != compression.FmtNone {
+			cst := &dataset.Structure{}
+			cst.Assign(st)
+			cst.Compression = comp.String()
+			st = cst
+		}
+	}
+
 	switch st.DataFormat() {
 	case dataset.CBORDataFormat:
 		return NewCBORReader(st, r)
@@ -61,6 +83,10 @@ func NewEntryReader(st *dataset.Structure, r io.Reader) (EntryReader, error) {
 		return NewCSVReader(st, r)
 	case dataset.XLSXDataFormat:
 		return NewXLSXReader(st, r)
+	case dataset.ODSDataFormat:
+		return NewODSReader(st, r)
+	case dataset.FixedWidthDataFormat:
+		return NewFixedWidthReader(st, r)
 	case dataset.NDJSONDataFormat:
 		return NewNDJSONReader(st, r)
 	case dataset.UnknownDataFormat:
@@ -76,6 +102,10 @@ func NewEntryReader(st *dataset.Structure, r io.Reader) (EntryReader, error) {
 
 // NewEntryWriter allocates a EntryWriter based on a given structure
 func NewEntryWriter(st *dataset.Structure, w io.Writer) (EntryWriter, error) {
+	if st.Archive != "" {
+		return nil, fmt.Errorf("writing archived bodies isn't supported")
+	}
+
 	switch st.DataFormat() {
 	case dataset.CBORDataFormat:
 		return NewCBORWriter(st, w)
@@ -85,6 +115,10 @@ func NewEntryWriter(st *dataset.Structure, w io.Writer) (EntryWriter, error) {
 		return NewCSVWriter(st, w)
 	case dataset.XLSXDataFormat:
 		return NewXLSXWriter(st, w)
+	case dataset.ODSDataFormat:
+		return NewODSWriter(st, w)
+	case dataset.FixedWidthDataFormat:
+		return NewFixedWidthWriter(st, w)
 	case dataset.NDJSONDataFormat:
 		return NewNDJSONWriter(st, w)
 	case dataset.UnknownDataFormat:
@@ -98,12 +132,26 @@ func NewEntryWriter(st *dataset.Structure, w io.Writer) (EntryWriter, error) {
 	}
 }
 
+// compressible reports if a data format can be read from compressed data.
+// spreadsheet formats are zip archives, and can't be compressed
+func compressible(df dataset.DataFormat) bool {
+	switch df {
+	case dataset.CBORDataFormat, dataset.JSONDataFormat, dataset.CSVDataFormat, dataset.FixedWidthDataFormat, dataset.NDJSONDataFormat:
+		return true
+	}
+	return false
+}
+
 func maybeWrapDecompressor(st *dataset.Structure, r io.Reader) (io.Reader, func() error, error) {
 	if st.Compression == "" {
 		return r, nil, nil
 	}
 
-	rc, err := compression.Decompressor(st.Compression, r)
+	opts, err := compression.NewOptions(st.CompressionConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := compression.DecompressorWithOptions(st.Compression, r, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -115,7 +163,11 @@ func maybeWrapCompressor(st *dataset.Structure, w io.Writer) (io.Writer, func()
 		return w, nil, nil
 	}
 
-	wc, err := compression.Compressor(st.Compression, w)
+	opts, err := compression.NewOptions(st.CompressionConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	wc, err := compression.CompressorWithOptions(st.Compression, w, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -191,18 +243,7 @@ func ReadAllArray(r EntryReader) ([]interface{}, error) {
 func ConvertFile(file qfs.File, in, out *dataset.Structure, limit, offset int, all bool) (data []byte, err error) {
 	buf := &bytes.Buffer{}
 
-	w, err := NewEntryWriter(out, buf)
-	if err != nil {
-		return
-	}
-
-	// TODO(dlong): Kind of a hacky one-off. Generalize this for other format options.
-	if out.DataFormat() == dataset.JSONDataFormat {
-		ok, pretty := out.FormatConfig["pretty"].(bool)
-		if ok && pretty {
-			w, err = NewJSONPrettyWriter(out, buf, " ")
-		}
-	}
+	w, err := newConvertWriter(out, buf)
 	if err != nil {
 		return
 	}
@@ -228,3 +269,98 @@ func ConvertFile(file qfs.File, in, out *dataset.Structure, limit, offset int, a
 
 	return buf.Bytes(), nil
 }
+
+// ConvertProgressFunc receives progress updates from a streaming conversion.
+// bytesRead counts bytes consumed from the source reader, before any
+// decompression
+type ConvertProgressFunc func(bytesRead, entriesWritten int)
+
+// convertProgressInterval is the number of entries written between progress
+// updates
+const convertProgressInterval = 1000
+
+// ConvertFileStream reads entries from src with the in structure, writing
+// them to dst in the out structure without holding the converted data in
+// memory. Compression on either side is set by the Compression field of each
+// structure. Selection with limit, offset & all matches ConvertFile.
+// Cancelling the context stops the conversion between entries. progress may
+// be nil. Wrapping src to count bytes hides random access, so formats like
+// XLSX that need it are spooled to disk when progress is non-nil
+func ConvertFileStream(ctx context.Context, src io.Reader, dst io.Writer, in, out *dataset.Structure, limit, offset int, all bool, progress ConvertProgressFunc) (err error) {
+	var tr *TrackedReader
+	if progress != nil {
+		tr = NewTrackedReader(src)
+		src = tr
+	}
+
+	w, err := newConvertWriter(out, dst)
+	if err != nil {
+		return err
+	}
+	// close the writer on early returns too, releasing any compressor
+	closed := false
+	defer func() {
+		if closed {
+			return
+		}
+		if cerr := w.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("error closing writer: %s", cerr.Error())
+		}
+	}()
+
+	rr, err := NewEntryReader(in, src)
+	if err != nil {
+		return fmt.Errorf("creating entry reader: %w", err)
+	}
+	defer rr.Close()
+
+	if !all {
+		rr = &PagedReader{
+			Reader: rr,
+			Limit:  limit,
+			Offset: offset,
+		}
+	}
+
+	written := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ent, err := rr.ReadEntry()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("row iteration error: %s", err.Error())
+		}
+		if err := w.WriteEntry(ent); err != nil {
+			return fmt.Errorf("error writing value: %s", err.Error())
+		}
+		written++
+		if progress != nil && written%convertProgressInterval == 0 {
+			progress(tr.BytesRead(), written)
+		}
+	}
+
+	closed = true
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error closing writer: %s", err.Error())
+	}
+	if progress != nil {
+		progress(tr.BytesRead(), written)
+	}
+	return nil
+}
+
+// newConvertWriter creates an EntryWriter for conversion output
+func newConvertWriter(out *dataset.Structure, w io.Writer) (EntryWriter, error) {
+	// TODO(dlong): Kind of a hacky one-off. Generalize this for other format options.
+	if out.DataFormat() == dataset.JSONDataFormat {
+		ok, pretty := out.FormatConfig["pretty"].(bool)
+		if ok && pretty {
+			return NewJSONPrettyWriter(out, w, " ")
+		}
+	}
+	return NewEntryWriter(out, w)
+}
@@ -2,12 +2,14 @@ package dsio
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
 	"github.com/qri-io/dataset/tabular"
 	"github.com/qri-io/qfs"
 )
@@ -43,6 +45,39 @@ func TestNewEntryReader(t *testing.T) {
 	}
 }
 
+func TestNewEntryReaderDetectCompression(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	for _, f := range []compression.Format{compression.FmtGZip, compression.FmtZStandard, compression.FmtXZ} {
+		t.Run(f.String(), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := compression.Compressor(f.String(), buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(`["a","b"]`))
+			w.Close()
+
+			r, err := NewEntryReader(st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Structure().Compression != f.String() {
+				t.Errorf("expected reader structure compression to be %q, got: %q", f, r.Structure().Compression)
+			}
+			if st.Compression != "" {
+				t.Error("expected passed-in structure to be unchanged")
+			}
+			got, err := ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]interface{}{"a", "b"}, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestNewEntryWriter(t *testing.T) {
 	cases := []struct {
 		st  *dataset.Structure
@@ -202,3 +237,87 @@ func TestConvertFile(t *testing.T) {
 		t.Error(fmt.Errorf("converted body didn't match, got: %s", got))
 	}
 }
+
+func TestConvertFileStream(t *testing.T) {
+	ctx := context.Background()
+	csvStructure := &dataset.Structure{Format: "csv", Schema: tabular.BaseTabularSchema, Compression: "gzip"}
+	jsonStructure := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, Compression: "zst"}
+
+	lines := make([]string, 2500)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%d,b", i)
+	}
+	src := &bytes.Buffer{}
+	cw, err := compression.Compressor("gzip", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw.Write([]byte(strings.Join(lines, "\n")))
+	cw.Close()
+	srcLen := src.Len()
+
+	var progress [][2]int
+	dst := &bytes.Buffer{}
+	err = ConvertFileStream(ctx, bytes.NewReader(src.Bytes()), dst, csvStructure, jsonStructure, 0, 0, true, func(bytesRead, entries int) {
+		progress = append(progress, [2]int{bytesRead, entries})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(progress) != 3 {
+		t.Fatalf("expected 3 progress updates, got: %v", progress)
+	}
+	if progress[2] != [2]int{srcLen, 2500} {
+		t.Errorf("final progress mismatch. expected: %v, got: %v", [2]int{srcLen, 2500}, progress[2])
+	}
+
+	r, err := NewEntryReader(jsonStructure, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2500 {
+		t.Errorf("expected 2500 entries, got: %d", len(got))
+	}
+
+	// paging matches ConvertFile
+	plain := &dataset.Structure{Format: "csv", Schema: tabular.BaseTabularSchema}
+	out := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	body := "a,b\nc,d\ne,f\ng,h"
+	expect, err := ConvertFile(qfs.NewMemfileBytes("", []byte(body)), plain, out, 2, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst.Reset()
+	if err := ConvertFileStream(ctx, strings.NewReader(body), dst, plain, out, 2, 1, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expect, dst.Bytes()) {
+		t.Errorf("paged result mismatch. expected: %s, got: %s", expect, dst.Bytes())
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := ConvertFileStream(cancelled, strings.NewReader(body), &bytes.Buffer{}, plain, out, 0, 0, true, nil); err != context.Canceled {
+		t.Errorf("expected context.Canceled error, got: %v", err)
+	}
+
+	// writers are closed when reading fails, finishing compressed output
+	gz := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray, Compression: compression.FmtGZip.String()}
+	dst.Reset()
+	if err := ConvertFileStream(ctx, strings.NewReader(`[1,2,`), dst, out, gz, 0, 0, true, nil); err == nil {
+		t.Error("expected invalid JSON to error")
+	}
+	r, err = NewEntryReader(gz, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := ReadAllArray(r); err != nil {
+		t.Errorf("expected partial output to be readable, got error: %v", err)
+	}
+}
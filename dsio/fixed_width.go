@@ -0,0 +1,389 @@
+package dsio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+// FixedWidthReader implements the RowReader interface for fixed-width text.
+// Each line of text is one entry, with column values read from the character
+// positions specified by the structure's FormatConfig
+type FixedWidthReader struct {
+	st         *dataset.Structure
+	r          *bufio.Reader
+	close      func() error
+	cols       []dataset.FixedWidthColumn
+	types      []string
+	formats    []columnFormat
+	opts       *dataset.FixedWidthOptions
+	readHeader bool
+	idx        int
+}
+
+var _ EntryReader = (*FixedWidthReader)(nil)
+
+// NewFixedWidthReader creates a reader from a structure and read source
+func NewFixedWidthReader(st *dataset.Structure, r io.Reader) (*FixedWidthReader, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := dataset.NewFixedWidthOptions(st.FormatConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := FixedWidthColumns(opts, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, len(cols))
+	for i, c := range cols {
+		types[i] = []string(*c.Type)[0]
+	}
+
+	dr, close, err := maybeWrapTextDecoder(st, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FixedWidthReader{
+		st:      st,
+		r:       bufio.NewReaderSize(dr, 256*1024),
+		close:   close,
+		cols:    positions,
+		types:   types,
+		formats: columnFormats(cols),
+		opts:    opts,
+	}, nil
+}
+
+// FixedWidthColumns resolves column positions for fixed-width data. Positions
+// listed in options take precedence. Otherwise positions are derived from
+// "width" (and optionally "start") keywords on schema column definitions
+func FixedWidthColumns(opts *dataset.FixedWidthOptions, cols tabular.Columns) ([]dataset.FixedWidthColumn, error) {
+	var positions []dataset.FixedWidthColumn
+	if opts != nil && len(opts.Columns) > 0 {
+		positions = opts.Columns
+	} else {
+		start := 0
+		positions = make([]dataset.FixedWidthColumn, len(cols))
+		for i, col := range cols {
+			width, ok := col.Validation["width"].(float64)
+			if !ok || width <= 0 {
+				return nil, fmt.Errorf("fixed-width column %d (%s) has no width. widths must be set in formatConfig or schema", i, col.Title)
+			}
+			if s, ok := col.Validation["start"].(float64); ok {
+				start = int(s)
+			}
+			positions[i] = dataset.FixedWidthColumn{Start: start, Width: int(width)}
+			start += int(width)
+		}
+	}
+
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("fixed-width data requires at least one column")
+	}
+	sorted := make([]dataset.FixedWidthColumn, len(positions))
+	copy(sorted, positions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	for i, c := range sorted {
+		if c.Start < 0 || c.Width <= 0 {
+			return nil, fmt.Errorf("invalid fixed-width column position: start %d, width %d", c.Start, c.Width)
+		}
+		if i > 0 && sorted[i-1].Start+sorted[i-1].Width > c.Start {
+			return nil, fmt.Errorf("fixed-width columns starting at %d and %d overlap", sorted[i-1].Start, c.Start)
+		}
+	}
+	return positions, nil
+}
+
+// Structure gives this reader's structure
+func (r *FixedWidthReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one line of fixed-width text from the reader
+func (r *FixedWidthReader) ReadEntry() (Entry, error) {
+	if !r.readHeader {
+		r.readHeader = true
+		if r.opts.HeaderRow {
+			if _, err := r.readLine(); err != nil {
+				return Entry{}, err
+			}
+		}
+	}
+
+	line, err := r.readLine()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	ent := Entry{Index: r.idx, Value: r.decode(r.split(line))}
+	r.idx++
+	return ent, nil
+}
+
+// readLine gives the next non-empty line of text, without line terminators
+func (r *FixedWidthReader) readLine() (string, error) {
+	for {
+		line, err := r.r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			return line, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// split cuts a line into column values, removing padding according to the
+// configured trim policy
+func (r *FixedWidthReader) split(line string) []string {
+	chars := []rune(line)
+	pad := string(padChar(r.opts))
+	strs := make([]string, len(r.cols))
+	for i, c := range r.cols {
+		if c.Start >= len(chars) {
+			continue
+		}
+		end := c.Start + c.Width
+		if end > len(chars) {
+			end = len(chars)
+		}
+		str := string(chars[c.Start:end])
+		switch r.opts.Trim {
+		case dataset.FixedWidthTrimNone:
+		case dataset.FixedWidthTrimLeft:
+			str = strings.TrimLeft(str, pad)
+		case dataset.FixedWidthTrimRight:
+			str = strings.TrimRight(str, pad)
+		default:
+			str = strings.Trim(str, pad)
+		}
+		strs[i] = str
+	}
+	return strs
+}
+
+// decode uses specified types from structure's schema to cast string values
+// to their intended types. Empty values in non-string columns are read as
+// null. If casting fails because the data is invalid, it's left as a string
+// instead of causing an error.
+func (r *FixedWidthReader) decode(strs []string) []interface{} {
+	vs := make([]interface{}, len(strs))
+	for i, str := range strs {
+		vs[i] = str
+		if i >= len(r.types) {
+			continue
+		}
+		if r.types[i] == "string" {
+			if i < len(r.formats) {
+				vs[i] = r.formats[i].coerce(str)
+			}
+			continue
+		}
+		if strings.TrimSpace(str) == "" {
+			vs[i] = nil
+			continue
+		}
+
+		switch r.types[i] {
+		case "number":
+			if num, err := vals.ParseNumber([]byte(str)); err == nil {
+				vs[i] = num
+			}
+		case "integer":
+			if num, err := vals.ParseInteger([]byte(str)); err == nil {
+				vs[i] = num
+			}
+		case "boolean":
+			if b, err := vals.ParseBoolean([]byte(str)); err == nil {
+				vs[i] = b
+			}
+		case "object":
+			v := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(str), &v); err == nil {
+				vs[i] = v
+			}
+		case "array":
+			v := []interface{}{}
+			if err := json.Unmarshal([]byte(str), &v); err == nil {
+				vs[i] = v
+			}
+		case "null":
+			vs[i] = nil
+		}
+	}
+	return vs
+}
+
+// Close finalizes the reader
+func (r *FixedWidthReader) Close() error {
+	if r.close != nil {
+		return r.close()
+	}
+	return nil
+}
+
+func padChar(opts *dataset.FixedWidthOptions) rune {
+	if opts == nil || opts.PadChar == rune(0) {
+		return ' '
+	}
+	return opts.PadChar
+}
+
+// FixedWidthWriter implements the RowWriter interface for fixed-width text
+type FixedWidthWriter struct {
+	st    *dataset.Structure
+	w     *bufio.Writer
+	close func() error
+	cols  []dataset.FixedWidthColumn
+	opts  *dataset.FixedWidthOptions
+	line  []rune
+}
+
+// NewFixedWidthWriter creates a Writer from a structure and write destination
+func NewFixedWidthWriter(st *dataset.Structure, w io.Writer) (*FixedWidthWriter, error) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := dataset.NewFixedWidthOptions(st.FormatConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := FixedWidthColumns(opts, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	cw, close, err := maybeWrapTextEncoder(st, w)
+	if err != nil {
+		return nil, err
+	}
+
+	wr := &FixedWidthWriter{
+		st:    st,
+		w:     bufio.NewWriter(cw),
+		close: close,
+		cols:  positions,
+		opts:  opts,
+	}
+
+	if opts.HeaderRow {
+		titles := cols.Titles()
+		header := make([]string, len(positions))
+		for i, c := range positions {
+			if i < len(titles) {
+				// titles are truncated to fit, values are not
+				header[i] = titles[i]
+				if utf8.RuneCountInString(header[i]) > c.Width {
+					header[i] = string([]rune(header[i])[:c.Width])
+				}
+			}
+		}
+		if err := wr.writeLine(header, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return wr, nil
+}
+
+// Structure gives this writer's structure
+func (w *FixedWidthWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one line of fixed-width text to the writer
+func (w *FixedWidthWriter) WriteEntry(ent Entry) error {
+	arr, ok := ent.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected array value to write fixed-width row. got: %v", ent)
+	}
+	strs, err := encode(arr)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error encoding entry: %s", err.Error())
+	}
+	return w.writeLine(strs, arr)
+}
+
+func (w *FixedWidthWriter) writeLine(strs []string, values []interface{}) error {
+	pad := padChar(w.opts)
+	w.line = w.line[:0]
+	for i, c := range w.cols {
+		for len(w.line) < c.Start+c.Width {
+			w.line = append(w.line, ' ')
+		}
+		var str string
+		if i < len(strs) {
+			str = strs[i]
+		}
+		chars := []rune(str)
+		if len(chars) > c.Width {
+			return fmt.Errorf("value %q is too wide for column %d (width %d)", str, i, c.Width)
+		}
+
+		var value interface{}
+		if i < len(values) {
+			value = values[i]
+		}
+		padding := c.Width - len(chars)
+		start := c.Start
+		if w.alignRight(value) {
+			start += padding
+		}
+		for j := c.Start; j < c.Start+c.Width; j++ {
+			w.line[j] = pad
+		}
+		copy(w.line[start:], chars)
+	}
+
+	if _, err := w.w.WriteString(string(w.line)); err != nil {
+		return err
+	}
+	return w.w.WriteByte('\n')
+}
+
+func (w *FixedWidthWriter) alignRight(v interface{}) bool {
+	switch w.opts.Align {
+	case dataset.FixedWidthAlignLeft:
+		return false
+	case dataset.FixedWidthAlignRight:
+		return true
+	default:
+		switch v.(type) {
+		case int, int64, float64:
+			return true
+		}
+		return false
+	}
+}
+
+// Close finalizes the writer, indicating no more records
+// will be written
+func (w *FixedWidthWriter) Close() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	if w.close != nil {
+		return w.close()
+	}
+	return nil
+}
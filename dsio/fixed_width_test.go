@@ -0,0 +1,163 @@
+package dsio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+const fixedWidthData = `name      countprice  ok   
+apple         3   1.25true 
+banana       12       false
+cherry pie  140   12.5true 
+`
+
+var fixedWidthStruct = &dataset.Structure{
+	Format: "fwf",
+	FormatConfig: map[string]interface{}{
+		"headerRow": true,
+		"columns": []interface{}{
+			map[string]interface{}{"start": float64(0), "width": float64(10)},
+			map[string]interface{}{"start": float64(10), "width": float64(5)},
+			map[string]interface{}{"start": float64(15), "width": float64(7)},
+			map[string]interface{}{"start": float64(22), "width": float64(5)},
+		},
+	},
+	Schema: map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "count", "type": "integer"},
+				map[string]interface{}{"title": "price", "type": "number"},
+				map[string]interface{}{"title": "ok", "type": "boolean"},
+			},
+		},
+	},
+}
+
+func TestFixedWidthReader(t *testing.T) {
+	r, err := NewEntryReader(fixedWidthStruct, strings.NewReader(fixedWidthData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"apple", int64(3), 1.25, true},
+		[]interface{}{"banana", int64(12), nil, false},
+		[]interface{}{"cherry pie", int64(140), 12.5, true},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFixedWidthSchemaWidths(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "fwf",
+		FormatConfig: map[string]interface{}{"trim": "none"},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "a", "type": "string", "width": float64(3)},
+					map[string]interface{}{"title": "b", "type": "string", "width": float64(2)},
+				},
+			},
+		},
+	}
+	r, err := NewFixedWidthReader(st, strings.NewReader("ab cd\r\n\r\nxyz\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"ab ", "cd"},
+		[]interface{}{"xyz", ""},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	st.Schema = map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": []interface{}{map[string]interface{}{"title": "a", "type": "string"}},
+		},
+	}
+	if _, err := NewFixedWidthReader(st, strings.NewReader("")); err == nil {
+		t.Error("expected missing column widths to error")
+	}
+}
+
+func TestFixedWidthWriter(t *testing.T) {
+	rows := []Entry{
+		{Value: []interface{}{"apple", 3, 1.25, true}},
+		{Value: []interface{}{"banana", int64(12), nil, false}},
+		{Value: []interface{}{"cherry pie", 140, 12.5, true}},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(fixedWidthStruct, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteEntry(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `name      countprice  ok   
+apple         3   1.25true 
+banana       12       false
+cherry pie  140   12.5true 
+`
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := w.WriteEntry(Entry{Value: []interface{}{"a name that's too long", 1, 1, true}}); err == nil {
+		t.Error("expected writing a value wider than it's column to error")
+	}
+}
+
+func TestFixedWidthOverlappingColumns(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "fwf",
+		FormatConfig: map[string]interface{}{
+			"columns": []interface{}{
+				map[string]interface{}{"start": float64(0), "width": float64(4)},
+				map[string]interface{}{"start": float64(2), "width": float64(4)},
+			},
+		},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "a", "type": "string"},
+					map[string]interface{}{"title": "b", "type": "string"},
+				},
+			},
+		},
+	}
+	if _, err := NewFixedWidthWriter(st, &bytes.Buffer{}); err == nil {
+		t.Error("expected overlapping columns to error")
+	}
+}
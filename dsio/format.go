@@ -0,0 +1,38 @@
+package dsio
+
+import (
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+// columnFormat is the string format a tabular column's schema gives with the
+// "format" keyword. Dates may also set a "dateLayout" from vals.DateLayouts
+type columnFormat struct {
+	format     vals.Format
+	dateLayout string
+}
+
+// columnFormats gives the formats of tabular columns, nil if no column
+// specifies a format with a date layout. Other formats are read as-is
+func columnFormats(cols tabular.Columns) []columnFormat {
+	var formats []columnFormat
+	for i, col := range cols {
+		f, _ := col.Validation["format"].(string)
+		layout, _ := col.Validation["dateLayout"].(string)
+		if f == "" || layout == "" {
+			continue
+		}
+		if formats == nil {
+			formats = make([]columnFormat, len(cols))
+		}
+		formats[i] = columnFormat{format: vals.Format(f), dateLayout: layout}
+	}
+	return formats
+}
+
+// coerce rewrites dates in their JSON schema form, leaving values that can't
+// be read unchanged
+func (cf columnFormat) coerce(str string) string {
+	coerced, _ := vals.CoerceFormat(cf.format, str, cf.dateLayout)
+	return coerced
+}
@@ -22,6 +22,11 @@ type JSONReader struct {
 	reader      *bufio.Reader
 	close       func() error // close func from wrapped reader
 	prevSize    int          // when buffer is extended, remember how much of the old buffer to discard
+
+	pos      textPosition
+	depth    int
+	entryPos Position
+	fieldPos []Position
 }
 
 var _ EntryReader = (*JSONReader)(nil)
@@ -45,7 +50,7 @@ func NewJSONReaderSize(st *dataset.Structure, r io.Reader, size int) (*JSONReade
 		return nil, err
 	}
 
-	r, close, err := maybeWrapDecompressor(st, r)
+	r, close, err := maybeWrapTextDecoder(st, r)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +110,10 @@ func (r *JSONReader) ReadEntry() (Entry, error) {
 	}
 	r.initialized = true
 
+	r.currentBuffer()
+	r.entryPos = r.pos.Position()
+	r.fieldPos = r.fieldPos[:0]
+
 	// Read actual entry, format depends depends upon mode.
 	if r.tlt == "object" {
 		key, val, err := r.readKeyValuePair()
@@ -125,6 +134,24 @@ func (r *JSONReader) ReadEntry() (Entry, error) {
 	return ent, nil
 }
 
+// EntryPosition gives the position of a value in the most recently read
+// entry. Values of array entries are located individually, field -1 gives
+// the start of the entry
+func (r *JSONReader) EntryPosition(field int) Position {
+	if field >= 0 && field < len(r.fieldPos) {
+		return r.fieldPos[field]
+	}
+	return r.entryPos
+}
+
+// discard consumes n buffered bytes, tracking the reader's position
+func (r *JSONReader) discard(n int) {
+	if b, err := r.reader.Peek(n); err == nil {
+		r.pos.advance(b)
+	}
+	_, _ = r.reader.Discard(n)
+}
+
 // Close finalizes the reader
 func (r *JSONReader) Close() error {
 	if r.close != nil {
@@ -141,7 +168,7 @@ func (r *JSONReader) readTokenChar(ch byte) bool {
 	buff := r.currentBuffer()
 	if len(buff) > 0 && buff[0] == ch {
 		// Either 0 or 1 characters are matched, only need to discard 1.
-		_, _ = r.reader.Discard(1)
+		r.discard(1)
 		return true
 	}
 	return false
@@ -159,7 +186,7 @@ func (r *JSONReader) readLiteralToken(tok []byte) bool {
 	}
 	if len(tok) <= len(buff) && bytes.Compare(tok, buff[0:len(tok)]) == 0 {
 		// If the buffer was extended, only discard the new bytes.
-		_, _ = r.reader.Discard(len(tok) - r.prevSize)
+		r.discard(len(tok) - r.prevSize)
 		return true
 	}
 	return false
@@ -225,7 +252,7 @@ func (r *JSONReader) currentBuffer() []byte {
 	}
 	// Discard whitespace characters, move the buffer forward.
 	if skip > 0 {
-		_, _ = r.reader.Discard(skip - r.prevSize)
+		r.discard(skip - r.prevSize)
 		r.prevSize = 0
 		buff = buff[skip:]
 	}
@@ -239,7 +266,7 @@ func (r *JSONReader) extendBuffer(orig []byte) ([]byte, bool) {
 	size := r.reader.Buffered()
 	r.prevSize += size
 	// Clear the reader's buffer, fill it back up.
-	_, _ = r.reader.Discard(size)
+	r.discard(size)
 	_, _ = r.reader.Peek(blockSize)
 	size = r.reader.Buffered()
 	if size > 0 {
@@ -252,7 +279,7 @@ func (r *JSONReader) extendBuffer(orig []byte) ([]byte, bool) {
 
 func (r *JSONReader) extractFromBuffer(buffer []byte, i int) string {
 	text := string(buffer[0:i])
-	_, _ = r.reader.Discard(i - r.prevSize)
+	r.discard(i - r.prevSize)
 	r.prevSize = 0
 	return text
 }
@@ -335,6 +362,9 @@ func (r *JSONReader) readObject() (interface{}, error) {
 	if !r.readTokenChar('{') {
 		return nil, fmt.Errorf("Expected: opening '{' for object")
 	}
+	r.depth++
+	defer func() { r.depth-- }()
+
 	obj := make(map[string]interface{})
 	if r.readTokenChar('}') {
 		return obj, nil
@@ -365,11 +395,15 @@ func (r *JSONReader) readArray() ([]interface{}, error) {
 	if !r.readTokenChar('[') {
 		return nil, fmt.Errorf("Expected: opening '[' for array")
 	}
+	r.depth++
+	defer func() { r.depth-- }()
+
 	array := make([]interface{}, 0)
 	if r.readTokenChar(']') {
 		return array, nil
 	}
 	// Read first element.
+	r.markField()
 	val, err := r.readValue()
 	if err != nil {
 		return array, nil
@@ -384,6 +418,7 @@ func (r *JSONReader) readArray() ([]interface{}, error) {
 			log.Error(string(buff))
 			return nil, fmt.Errorf("Expected: ',' to separate elements")
 		}
+		r.markField()
 		val, err := r.readValue()
 		if err != nil {
 			return array, err
@@ -393,6 +428,15 @@ func (r *JSONReader) readArray() ([]interface{}, error) {
 	return array, nil
 }
 
+// markField records the position of an element when reading the values of
+// an array entry
+func (r *JSONReader) markField() {
+	if r.depth == 1 {
+		r.currentBuffer()
+		r.fieldPos = append(r.fieldPos, r.pos.Position())
+	}
+}
+
 func (r *JSONReader) readKeyValuePair() (string, interface{}, error) {
 	key, err := r.readString()
 	if err != nil {
@@ -433,7 +477,7 @@ func NewJSONWriter(st *dataset.Structure, w io.Writer) (*JSONWriter, error) {
 		return nil, err
 	}
 
-	w, close, err := maybeWrapCompressor(st, w)
+	w, close, err := maybeWrapTextEncoder(st, w)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package dsio
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,6 +17,11 @@ type NDJSONReader struct {
 	buf         *bufio.Reader
 	close       func() error // close func from wrapped reader
 	prevSize    int          // when buffer is extended, remember how much of the old buffer to discard
+
+	pos      textPosition
+	line     []byte
+	entryPos Position
+	fieldPos []Position
 }
 
 var _ EntryReader = (*NDJSONReader)(nil)
@@ -36,7 +42,7 @@ func NewNDJSONReader(st *dataset.Structure, r io.Reader) (*NDJSONReader, error)
 		return nil, fmt.Errorf("NDJSON top level type must be 'array'")
 	}
 
-	r, close, err := maybeWrapDecompressor(st, r)
+	r, close, err := maybeWrapTextDecoder(st, r)
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +62,14 @@ func (r *NDJSONReader) Structure() *dataset.Structure {
 
 // ReadEntry reads one JSON record from the reader
 func (r *NDJSONReader) ReadEntry() (Entry, error) {
+	r.entryPos = r.pos.Position()
 	line, err := r.buf.ReadBytes('\n')
+	r.pos.advance(line)
 	if err != nil {
 		return Entry{}, err
 	}
+	r.line = line
+	r.fieldPos = nil
 
 	var v interface{}
 	if err := json.Unmarshal(line, &v); err != nil {
@@ -75,6 +85,49 @@ func (r *NDJSONReader) ReadEntry() (Entry, error) {
 	return ent, nil
 }
 
+// EntryPosition gives the position of a value in the most recently read
+// entry. Values of array entries are located individually, field -1 gives
+// the start of the line
+func (r *NDJSONReader) EntryPosition(field int) Position {
+	if field < 0 {
+		return r.entryPos
+	}
+	if r.fieldPos == nil {
+		// element positions are only worked out on request
+		r.fieldPos = jsonArrayElementOffsets(r.line, r.entryPos)
+	}
+	if field < len(r.fieldPos) {
+		return r.fieldPos[field]
+	}
+	return r.entryPos
+}
+
+// jsonArrayElementOffsets locates the elements of a single-line JSON array
+func jsonArrayElementOffsets(line []byte, start Position) []Position {
+	positions := []Position{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return positions
+	}
+	for dec.More() {
+		offset := dec.InputOffset()
+		// skip the separator & whitespace preceding the element
+		for offset < int64(len(line)) && (line[offset] == ',' || isWhitespace(line[offset])) {
+			offset++
+		}
+		pos := start
+		pos.Column += int(offset)
+		pos.Offset += offset
+		positions = append(positions, pos)
+
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			break
+		}
+	}
+	return positions
+}
+
 // Close finalizes the reader
 func (r *NDJSONReader) Close() error {
 	if r.close != nil {
@@ -103,7 +156,7 @@ func NewNDJSONWriter(st *dataset.Structure, w io.Writer) (*NDJSONWriter, error)
 		return nil, err
 	}
 
-	w, close, err := maybeWrapCompressor(st, w)
+	w, close, err := maybeWrapTextEncoder(st, w)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,596 @@
+package dsio
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/archive"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+const (
+	odsMimetype = "application/vnd.oasis.opendocument.spreadsheet"
+	odsNSTable  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsNSOffice = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsNSText   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// ODSReader implements the RowReader interface for the OpenDocument
+// Spreadsheet data format. Like XLSXReader, rows are streamed from the
+// document XML one at a time. Entirely empty rows are skipped
+type ODSReader struct {
+	err       error
+	st        *dataset.Structure
+	sheetName string
+	dec       *xml.Decoder
+	file      *zip.File
+	content   io.ReadCloser
+	close     func() error
+	idx       int
+	types     []string
+	skip      int
+	// pending holds repeated copies of the most recently read row
+	pending []string
+	repeat  int
+}
+
+var _ EntryReader = (*ODSReader)(nil)
+
+// NewODSReader creates a reader from a structure and read source. ods files
+// are zip archives, non-seekable readers are buffered to a temporary file
+// that's removed when the reader is closed
+func NewODSReader(st *dataset.Structure, r io.Reader) (*ODSReader, error) {
+	if st.Compression != "" {
+		return nil, fmt.Errorf("ods format does not support compression")
+	}
+
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, len(cols))
+	for i, c := range cols {
+		types[i] = []string(*c.Type)[0]
+	}
+
+	rdr := &ODSReader{
+		st:    st,
+		types: types,
+	}
+
+	if fcg, err := dataset.ParseFormatConfigMap(dataset.ODSDataFormat, st.FormatConfig); err == nil {
+		if opts, ok := fcg.(*dataset.ODSOptions); ok {
+			rdr.sheetName = opts.SheetName
+			rdr.skip = opts.SkipInitialRows
+			if opts.HeaderRow {
+				rdr.skip++
+			}
+		}
+	}
+
+	ra, size, close, err := archive.ReaderAt(r)
+	if err != nil {
+		return nil, err
+	}
+	rdr.close = close
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		rdr.Close()
+		return nil, fmt.Errorf("opening ods archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name == "content.xml" {
+			rdr.file = f
+			break
+		}
+	}
+	if rdr.file == nil {
+		rdr.Close()
+		return nil, fmt.Errorf("ods archive is missing document content")
+	}
+
+	if err := rdr.openContent(); err != nil {
+		rdr.Close()
+		return nil, err
+	}
+	if err := rdr.findSheet(); err != nil {
+		rdr.Close()
+		return nil, err
+	}
+
+	return rdr, nil
+}
+
+// findSheet advances the decoder to the start of the configured sheet. An
+// empty sheet name selects "Sheet1", falling back to the first sheet.
+// Sheets are stored sequentially in a single document, so falling back
+// requires re-reading the document from the start
+func (r *ODSReader) findSheet() error {
+	var (
+		names []string
+		name  = r.sheetName
+	)
+	if name == "" {
+		name = "Sheet1"
+	}
+
+	for {
+		tok, err := r.dec.Token()
+		if err == io.EOF {
+			if r.sheetName == "" && len(names) > 0 {
+				r.sheetName = names[0]
+				return r.rewind()
+			}
+			return fmt.Errorf("ods sheet %q does not exist", r.sheetName)
+		} else if err != nil {
+			return err
+		}
+
+		if el, ok := tok.(xml.StartElement); ok && el.Name.Space == odsNSTable && el.Name.Local == "table" {
+			sheet := odsAttr(el, odsNSTable, "name")
+			if sheet == name {
+				r.sheetName = sheet
+				return nil
+			}
+			names = append(names, sheet)
+			if err := r.dec.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (r *ODSReader) openContent() (err error) {
+	if r.content != nil {
+		r.content.Close()
+	}
+	if r.content, err = r.file.Open(); err != nil {
+		return err
+	}
+	r.dec = newXMLDecoder(r.content)
+	return nil
+}
+
+func (r *ODSReader) rewind() error {
+	if err := r.openContent(); err != nil {
+		return err
+	}
+	return r.findSheet()
+}
+
+// Structure gives this reader's structure
+func (r *ODSReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// ReadEntry reads one ODS record from the reader
+func (r *ODSReader) ReadEntry() (Entry, error) {
+	if r.err != nil {
+		return Entry{}, r.err
+	}
+
+	for ; r.skip > 0; r.skip-- {
+		if _, err := r.nextRow(); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	row, err := r.nextRow()
+	if err != nil {
+		r.err = err
+		return Entry{}, err
+	}
+
+	vals, err := r.decode(row)
+	if err != nil {
+		return Entry{}, err
+	}
+	ent := Entry{Index: r.idx, Value: vals}
+	r.idx++
+	return ent, nil
+}
+
+// nextRow gives the next non-empty row of the sheet
+func (r *ODSReader) nextRow() ([]string, error) {
+	if r.repeat > 0 {
+		r.repeat--
+		return append([]string(nil), r.pending...), nil
+	}
+
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if el.Name.Space == odsNSTable && el.Name.Local == "table-row" {
+				row, err := r.readRow()
+				if err != nil {
+					return nil, err
+				}
+				if len(row) == 0 {
+					continue
+				}
+				if n := odsRepeat(el, "number-rows-repeated"); n > 1 {
+					r.pending = row
+					r.repeat = n - 1
+				}
+				return row, nil
+			}
+		case xml.EndElement:
+			if el.Name.Space == odsNSTable && el.Name.Local == "table" {
+				return nil, io.EOF
+			}
+		}
+	}
+}
+
+// readRow reads the cells of a table-row element, trimming trailing empty
+// cells
+func (r *ODSReader) readRow() ([]string, error) {
+	var (
+		row []string
+		// runs of empty cells are common as padding, only materialize them
+		// if a value follows
+		empty int
+	)
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if el.Name.Space != odsNSTable {
+				if err := r.dec.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			switch el.Name.Local {
+			case "table-cell", "covered-table-cell":
+				val, err := r.readCell(el)
+				if err != nil {
+					return nil, err
+				}
+				n := odsRepeat(el, "number-columns-repeated")
+				if val == "" {
+					empty += n
+					continue
+				}
+				for ; empty > 0; empty-- {
+					row = append(row, "")
+				}
+				for i := 0; i < n; i++ {
+					row = append(row, val)
+				}
+			default:
+				if err := r.dec.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			return row, nil
+		}
+	}
+}
+
+// readCell reads the value of a single cell, preferring typed office:
+// attributes over display text
+func (r *ODSReader) readCell(el xml.StartElement) (string, error) {
+	text, err := r.readCellText()
+	if err != nil {
+		return "", err
+	}
+
+	switch odsAttr(el, odsNSOffice, "value-type") {
+	case "float", "percentage", "currency":
+		if v := odsAttr(el, odsNSOffice, "value"); v != "" {
+			return v, nil
+		}
+	case "date":
+		if v := odsAttr(el, odsNSOffice, "date-value"); v != "" {
+			return v, nil
+		}
+	case "boolean":
+		if v := odsAttr(el, odsNSOffice, "boolean-value"); v != "" {
+			return v, nil
+		}
+	}
+	return text, nil
+}
+
+// readCellText collects the text:p paragraphs of a cell, consuming the
+// cell's end element
+func (r *ODSReader) readCellText() (string, error) {
+	var (
+		buf   strings.Builder
+		paras int
+		depth int
+	)
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if el.Name.Space == odsNSOffice && el.Name.Local == "annotation" {
+				// comments aren't part of the cell value
+				if err := r.dec.Skip(); err != nil {
+					return "", err
+				}
+				continue
+			}
+			depth++
+			if el.Name.Space != odsNSText {
+				continue
+			}
+			switch el.Name.Local {
+			case "p":
+				if paras > 0 {
+					buf.WriteByte('\n')
+				}
+				paras++
+			case "s":
+				n := odsRepeat(el, "c")
+				buf.WriteString(strings.Repeat(" ", n))
+			case "tab":
+				buf.WriteByte('\t')
+			case "line-break":
+				buf.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return buf.String(), nil
+			}
+			depth--
+		case xml.CharData:
+			if depth > 0 {
+				buf.Write(el)
+			}
+		}
+	}
+}
+
+// decode uses specified types from structure's schema to cast ods string values to their
+// intended types. If casting fails because the data is invalid, it's left as a string instead
+// of causing an error.
+func (r *ODSReader) decode(strs []string) ([]interface{}, error) {
+	vs := make([]interface{}, len(strs))
+	for i, str := range strs {
+		vs[i] = str
+		if i >= len(r.types) {
+			continue
+		}
+
+		switch r.types[i] {
+		case "number":
+			if num, err := vals.ParseNumber([]byte(str)); err == nil {
+				vs[i] = num
+			}
+		case "integer":
+			if num, err := vals.ParseInteger([]byte(str)); err == nil {
+				vs[i] = num
+			}
+		case "boolean":
+			if b, err := vals.ParseBoolean([]byte(str)); err == nil {
+				vs[i] = b
+			}
+		case "object":
+			v := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(str), &v); err == nil {
+				vs[i] = v
+			}
+		case "array":
+			v := []interface{}{}
+			if err := json.Unmarshal([]byte(str), &v); err == nil {
+				vs[i] = v
+			}
+		case "null":
+			vs[i] = nil
+		}
+	}
+
+	return vs, nil
+}
+
+// Close finalizes the reader, indicating no more records will be read
+func (r *ODSReader) Close() error {
+	if r.content != nil {
+		r.content.Close()
+	}
+	if r.close != nil {
+		return r.close()
+	}
+	return nil
+}
+
+func odsAttr(el xml.StartElement, space, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Space == space && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// odsRepeat reads a repetition count attribute, defaulting to 1
+func odsRepeat(el xml.StartElement, name string) int {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			if n, err := strconv.Atoi(a.Value); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+// ODSWriter implements the RowWriter interface for
+// OpenDocument Spreadsheet-formatted data
+type ODSWriter struct {
+	st        *dataset.Structure
+	sheetName string
+	zw        *zip.Writer
+	w         *bufio.Writer
+	cols      tabular.Columns
+}
+
+// NewODSWriter creates a Writer from a structure and write destination. rows
+// are streamed to the document as they're written
+func NewODSWriter(st *dataset.Structure, w io.Writer) (*ODSWriter, error) {
+	if st.Compression != "" {
+		return nil, fmt.Errorf("ods format does not support compression")
+	}
+
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	wr := &ODSWriter{
+		st:   st,
+		cols: cols,
+		zw:   zip.NewWriter(w),
+	}
+
+	opts := &dataset.ODSOptions{}
+	if fcg, err := dataset.ParseFormatConfigMap(dataset.ODSDataFormat, st.FormatConfig); err == nil {
+		if o, ok := fcg.(*dataset.ODSOptions); ok {
+			opts = o
+		}
+	} else {
+		return nil, err
+	}
+	wr.sheetName = opts.SheetName
+	if wr.sheetName == "" {
+		wr.sheetName = "Sheet1"
+	}
+
+	if err := wr.writePackageFiles(); err != nil {
+		return nil, err
+	}
+
+	if opts.HeaderRow {
+		header := make([]interface{}, len(cols))
+		for i, title := range cols.Titles() {
+			header[i] = title
+		}
+		if err := wr.writeRow(header); err != nil {
+			return nil, err
+		}
+	}
+
+	return wr, nil
+}
+
+// writePackageFiles writes the mimetype & manifest entries of the archive,
+// and opens the content document for streaming rows
+func (w *ODSWriter) writePackageFiles() error {
+	// the mimetype file must come first & be stored uncompressed
+	mt, err := w.zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mt, odsMimetype); err != nil {
+		return err
+	}
+
+	manifest, err := w.zw.Create("META-INF/manifest.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(manifest, xml.Header+`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">`+
+		`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="`+odsMimetype+`"/>`+
+		`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>`+
+		`</manifest:manifest>`); err != nil {
+		return err
+	}
+
+	content, err := w.zw.Create("content.xml")
+	if err != nil {
+		return err
+	}
+	w.w = bufio.NewWriter(content)
+
+	columns := len(w.cols)
+	if columns == 0 {
+		columns = 1
+	}
+	w.w.WriteString(xml.Header)
+	w.w.WriteString(`<office:document-content xmlns:office="` + odsNSOffice + `" xmlns:table="` + odsNSTable + `" xmlns:text="` + odsNSText + `" office:version="1.2">`)
+	w.w.WriteString(`<office:body><office:spreadsheet><table:table table:name="`)
+	xml.EscapeText(w.w, []byte(w.sheetName))
+	w.w.WriteString(`">`)
+	_, err = fmt.Fprintf(w.w, `<table:table-column table:number-columns-repeated="%d"/>`, columns)
+	return err
+}
+
+// Structure gives this writer's structure
+func (w *ODSWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes one ODS record to the writer
+func (w *ODSWriter) WriteEntry(ent Entry) error {
+	if arr, ok := ent.Value.([]interface{}); ok {
+		return w.writeRow(arr)
+	}
+	return fmt.Errorf("expected array value to write ods row. got: %v", ent)
+}
+
+func (w *ODSWriter) writeRow(arr []interface{}) error {
+	strs, err := encodeStrings(arr)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error encoding entry: %s", err.Error())
+	}
+
+	w.w.WriteString("<table:table-row>")
+	for i, str := range strs {
+		switch arr[i].(type) {
+		case nil:
+			w.w.WriteString("<table:table-cell/>")
+			continue
+		case int, int64, float64:
+			fmt.Fprintf(w.w, `<table:table-cell office:value-type="float" office:value="%s">`, str)
+		case bool:
+			fmt.Fprintf(w.w, `<table:table-cell office:value-type="boolean" office:boolean-value="%s">`, str)
+		default:
+			w.w.WriteString(`<table:table-cell office:value-type="string">`)
+		}
+		w.w.WriteString("<text:p>")
+		xml.EscapeText(w.w, []byte(str))
+		w.w.WriteString("</text:p></table:table-cell>")
+	}
+	_, err = w.w.WriteString("</table:table-row>")
+	return err
+}
+
+// Close finalizes the writer, indicating no more records
+// will be written
+func (w *ODSWriter) Close() error {
+	w.w.WriteString("</table:table></office:spreadsheet></office:body></office:document-content>")
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
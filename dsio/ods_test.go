@@ -0,0 +1,176 @@
+package dsio
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+var odsStruct = &dataset.Structure{
+	Format: "ods",
+	FormatConfig: map[string]interface{}{
+		"sheetName": "data",
+		"headerRow": true,
+	},
+	Schema: map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "col_a", "type": "string"},
+				map[string]interface{}{"title": "col_b", "type": "number"},
+				map[string]interface{}{"title": "col_c", "type": "integer"},
+				map[string]interface{}{"title": "col_d", "type": "boolean"},
+				map[string]interface{}{"title": "col_e", "type": "object"},
+			},
+		},
+	},
+}
+
+func TestODSReadWrite(t *testing.T) {
+	rows := []interface{}{
+		[]interface{}{"a", 1.5, int64(2), true, map[string]interface{}{"foo": "bar"}},
+		[]interface{}{"b <&> c", float64(-12), int64(0), false, map[string]interface{}{}},
+		[]interface{}{"", nil, int64(3), nil, nil},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(odsStruct, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteEntry(Entry{Value: row}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Errorf("expected first archive entry to be an uncompressed mimetype file")
+	}
+
+	r, err := NewEntryReader(odsStruct, bytes.NewBuffer(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []interface{}{
+		rows[0],
+		rows[1],
+		// trailing empty cells are trimmed
+		[]interface{}{"", "", int64(3)},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	missing := &dataset.Structure{
+		Format:       "ods",
+		FormatConfig: map[string]interface{}{"sheetName": "nope"},
+		Schema:       odsStruct.Schema,
+	}
+	if _, err := NewODSReader(missing, bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("expected reading a missing sheet to error")
+	}
+}
+
+const odsContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>
+<table:table table:name="notes"><table:table-row><table:table-cell office:value-type="string"><text:p>skip me</text:p></table:table-cell></table:table-row></table:table>
+<table:table table:name="first">
+<table:table-column table:number-columns-repeated="3"/>
+<table:table-row table:number-rows-repeated="2">
+<table:table-cell office:value-type="string"><text:p>a<text:s text:c="2"/>b</text:p><text:p>c</text:p></table:table-cell>
+<table:table-cell office:value-type="float" office:value="0.25"><text:p>25%</text:p></table:table-cell>
+<table:table-cell table:number-columns-repeated="2"/>
+<table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>TRUE</text:p></table:table-cell>
+<table:table-cell table:number-columns-repeated="1020"/>
+</table:table-row>
+<table:table-row table:number-rows-repeated="1048000"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+<table:table-row>
+<table:table-cell office:value-type="date" office:date-value="2021-01-02"><text:p>01/02/21</text:p><office:annotation><text:p>a comment</text:p></office:annotation></table:table-cell>
+<table:covered-table-cell/>
+<table:table-cell table:number-columns-repeated="2" office:value-type="float" office:value="7"><text:p>7</text:p></table:table-cell>
+</table:table-row>
+</table:table>
+</office:spreadsheet></office:body></office:document-content>`
+
+func TestODSReaderDocument(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	f, err := zw.Create("content.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(f, odsContent)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// no sheetName selects the first sheet when "Sheet1" doesn't exist
+	st := &dataset.Structure{
+		Format: "ods",
+		Schema: map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "array", "items": []interface{}{}},
+		},
+	}
+	r, err := NewODSReader(st, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"skip me"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	st.FormatConfig = map[string]interface{}{"sheetName": "first"}
+	if r, err = NewODSReader(st, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ReadAllArray(r); err != nil {
+		t.Fatal(err)
+	}
+	expect = []interface{}{
+		[]interface{}{"a  b\nc", "0.25", "", "", "true"},
+		[]interface{}{"a  b\nc", "0.25", "", "", "true"},
+		[]interface{}{"2021-01-02", "", "7", "7"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestODSCompression(t *testing.T) {
+	if _, err := NewODSReader(&dataset.Structure{Format: "ods", Compression: "gzip"}, nil); err == nil {
+		t.Error("expected ods to fail when using compression")
+	}
+	if _, err := NewODSWriter(&dataset.Structure{Format: "ods", Compression: "gzip"}, nil); err == nil {
+		t.Error("expected ods to fail when using compression")
+	}
+}
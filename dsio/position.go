@@ -0,0 +1,84 @@
+package dsio
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Position locates an entry or value in the source data of a reader, for
+// pointing users at problems in their files. Line & Column are 1-based, with
+// columns counted in bytes like encoding/csv. Offset is a 0-based byte offset.
+// Positions of compressed or transcoded data refer to the decompressed, UTF-8
+// text. Spreadsheet positions use Sheet & Cell instead of lines & columns
+type Position struct {
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	Offset int64  `json:"offset"`
+	Sheet  string `json:"sheet,omitempty"`
+	Cell   string `json:"cell,omitempty"`
+}
+
+// String formats a position for display, like "line 3, column 12 (byte 54)"
+// or "sheet Sheet1, cell B12"
+func (p Position) String() string {
+	if p.Cell != "" {
+		if p.Sheet != "" {
+			return fmt.Sprintf("sheet %s, cell %s", p.Sheet, p.Cell)
+		}
+		return fmt.Sprintf("cell %s", p.Cell)
+	}
+	parts := []string{}
+	if p.Line > 0 {
+		parts = append(parts, fmt.Sprintf("line %d", p.Line))
+	}
+	if p.Column > 0 {
+		parts = append(parts, fmt.Sprintf("column %d", p.Column))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("byte %d", p.Offset)
+	}
+	return fmt.Sprintf("%s (byte %d)", strings.Join(parts, ", "), p.Offset)
+}
+
+// PositionReader is an optional interface for EntryReaders that can locate
+// entries in their source data
+type PositionReader interface {
+	// EntryPosition gives the source position of a value in the most recently
+	// read entry. field is the index of a value within an array entry, or -1
+	// for the start of the entry. Readers that can't locate individual values
+	// return the start of the entry
+	EntryPosition(field int) Position
+}
+
+var (
+	_ PositionReader = (*CSVReader)(nil)
+	_ PositionReader = (*JSONReader)(nil)
+	_ PositionReader = (*NDJSONReader)(nil)
+	_ PositionReader = (*XLSXReader)(nil)
+)
+
+// textPosition tracks line & column numbers as text is consumed
+type textPosition struct {
+	offset    int64
+	line      int
+	lineStart int64
+}
+
+// advance moves the position past consumed text
+func (p *textPosition) advance(b []byte) {
+	if n := bytes.Count(b, []byte{'\n'}); n > 0 {
+		p.line += n
+		p.lineStart = p.offset + int64(bytes.LastIndexByte(b, '\n')) + 1
+	}
+	p.offset += int64(len(b))
+}
+
+// Position gives the current position
+func (p *textPosition) Position() Position {
+	return Position{
+		Line:   p.line + 1,
+		Column: int(p.offset-p.lineStart) + 1,
+		Offset: p.offset,
+	}
+}
@@ -0,0 +1,129 @@
+package dsio
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestEntryPosition(t *testing.T) {
+	tabularSchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "a", "type": "string"},
+				map[string]interface{}{"title": "b", "type": "integer"},
+			},
+		},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		data        string
+		// positions of the second entry, first for field -1, then each field
+		expect []Position
+	}{
+		{"csv",
+			&dataset.Structure{Format: "csv", FormatConfig: map[string]interface{}{"headerRow": true}, Schema: tabularSchema},
+			"a,b\nfoo,1\n\"b\nar\",22\n",
+			[]Position{
+				{Line: 3, Column: 1, Offset: 10},
+				{Line: 3, Column: 1, Offset: 10},
+				{Line: 4, Column: 5, Offset: 10},
+			},
+		},
+		{"csv skipped rows & single quotes",
+			&dataset.Structure{Format: "csv", FormatConfig: map[string]interface{}{"skipInitialRows": 1, "quoteChar": "'"}, Schema: tabularSchema},
+			"junk\nfoo,1\n'bar',22\n",
+			[]Position{
+				{Line: 3, Column: 1, Offset: 11},
+				{Line: 3, Column: 1, Offset: 11},
+				{Line: 3, Column: 7, Offset: 17},
+			},
+		},
+		{"json",
+			&dataset.Structure{Format: "json", Schema: tabularSchema},
+			"[\n  [\"foo\", 1],\n  [\"bar\",\n   22]\n]",
+			[]Position{
+				{Line: 3, Column: 3, Offset: 18},
+				{Line: 3, Column: 4, Offset: 19},
+				{Line: 4, Column: 4, Offset: 29},
+			},
+		},
+		{"ndjson",
+			&dataset.Structure{Format: "ndjson", Schema: tabularSchema},
+			"[\"foo\", 1]\n[\"bar\",  22]\n",
+			[]Position{
+				{Line: 2, Column: 1, Offset: 11},
+				{Line: 2, Column: 2, Offset: 12},
+				{Line: 2, Column: 10, Offset: 20},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			r, err := NewEntryReader(c.st, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := r.ReadEntry(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			pr, ok := r.(PositionReader)
+			if !ok {
+				t.Fatalf("%T doesn't implement PositionReader", r)
+			}
+			got := []Position{pr.EntryPosition(-1), pr.EntryPosition(0), pr.EntryPosition(1)}
+			if diff := cmp.Diff(c.expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXLSXEntryPosition(t *testing.T) {
+	f, err := os.Open("testdata/xlsx/simple/body.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewXLSXReader(xlsxStruct, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := r.ReadEntry(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadEntry(); err != nil {
+		t.Fatal(err)
+	}
+	expect := Position{Sheet: "Sheet1", Cell: "B2"}
+	if diff := cmp.Diff(expect, r.EntryPosition(1)); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPositionString(t *testing.T) {
+	cases := []struct {
+		pos    Position
+		expect string
+	}{
+		{Position{Line: 3, Column: 12, Offset: 54}, "line 3, column 12 (byte 54)"},
+		{Position{Offset: 54}, "byte 54"},
+		{Position{Sheet: "Sheet1", Cell: "B12"}, "sheet Sheet1, cell B12"},
+	}
+	for _, c := range cases {
+		if got := c.pos.String(); got != c.expect {
+			t.Errorf("expected %q, got %q", c.expect, got)
+		}
+	}
+}
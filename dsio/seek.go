@@ -0,0 +1,125 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/compression"
+)
+
+// offsetEntryReader reads entries from an offset within a body
+type offsetEntryReader struct {
+	EntryReader
+	st    *dataset.Structure
+	close func() error
+}
+
+// NewEntryReaderAt creates a reader that starts at offset, a byte position in
+// the decompressed body like the Offset of an entry Position. offset must be
+// the start of an entry. Reading from an offset is supported for line-based
+// formats (CSV, NDJSON & fixed-width) that are uncompressed or compressed as
+// seekable zstd. Entry indexes count from the offset, and header & skipped
+// initial rows aren't read
+func NewEntryReaderAt(st *dataset.Structure, r io.ReadSeeker, offset int64) (EntryReader, error) {
+	df := st.DataFormat()
+	switch df {
+	case dataset.CSVDataFormat, dataset.NDJSONDataFormat, dataset.FixedWidthDataFormat:
+	default:
+		return nil, fmt.Errorf("reading from an offset isn't supported for %s data", df)
+	}
+	if st.Archive != "" {
+		return nil, fmt.Errorf("reading from an offset isn't supported for archived bodies")
+	}
+	if !isUTF8(st.Encoding) {
+		return nil, fmt.Errorf("reading from an offset isn't supported for %s encoded data", st.Encoding)
+	}
+
+	comp := st.Compression
+	if comp == "" {
+		f, err := sniffSeeker(r)
+		if err != nil {
+			return nil, err
+		}
+		comp = f.String()
+	}
+
+	var (
+		rs    io.ReadSeeker = r
+		close               = func() error { return nil }
+	)
+	switch comp {
+	case "":
+	case compression.FmtZStandard.String():
+		opts, err := compression.NewOptions(st.CompressionConfig)
+		if err != nil {
+			return nil, err
+		}
+		sr, err := compression.NewSeekableReader(r, opts)
+		if err != nil {
+			return nil, err
+		}
+		rs, close = sr, sr.Close
+	default:
+		return nil, fmt.Errorf("reading from an offset isn't supported for %s compressed data", comp)
+	}
+
+	if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+		close()
+		return nil, err
+	}
+
+	// read with a copy of the structure that has no compression or leading
+	// rows, offsets are past any header & skipped rows
+	ost := &dataset.Structure{}
+	ost.Assign(st)
+	ost.Compression = ""
+	ost.CompressionConfig = nil
+	if st.FormatConfig != nil {
+		ost.FormatConfig = map[string]interface{}{}
+		for k, v := range st.FormatConfig {
+			ost.FormatConfig[k] = v
+		}
+		for _, k := range []string{"headerRow", "skipInitialRows", "description"} {
+			delete(ost.FormatConfig, k)
+		}
+	}
+
+	er, err := NewEntryReader(ost, rs)
+	if err != nil {
+		close()
+		return nil, err
+	}
+	return &offsetEntryReader{EntryReader: er, st: st, close: close}, nil
+}
+
+// sniffSeeker detects compression from the leading bytes of r, returning r to
+// the start
+func sniffSeeker(r io.ReadSeeker) (compression.Format, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return compression.FmtNone, err
+	}
+	p := make([]byte, 10)
+	n, err := io.ReadFull(r, p)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return compression.FmtNone, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return compression.FmtNone, err
+	}
+	return compression.FormatFromMagic(p[:n]), nil
+}
+
+// Structure gives the structure being read
+func (r *offsetEntryReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// Close finalizes the reader & any decompressor
+func (r *offsetEntryReader) Close() error {
+	err := r.EntryReader.Close()
+	if cerr := r.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
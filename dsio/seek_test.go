@@ -0,0 +1,158 @@
+package dsio
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestNewEntryReaderAt(t *testing.T) {
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		// prefix is written before entries
+		prefix string
+	}{
+		{"csv", &dataset.Structure{
+			Format:       "csv",
+			Schema:       basicTableSchema,
+			FormatConfig: map[string]interface{}{"headerRow": true},
+		}, ""},
+		{"csv skipping initial rows", &dataset.Structure{
+			Format:       "csv",
+			Schema:       basicTableSchema,
+			FormatConfig: map[string]interface{}{"headerRow": true, "skipInitialRows": 2},
+		}, "Report\nnotes\n"},
+		{"seekable zstd ndjson", &dataset.Structure{
+			Format:            "ndjson",
+			Schema:            basicTableSchema,
+			Compression:       "zst",
+			CompressionConfig: map[string]interface{}{"frameSize": 64},
+		}, ""},
+	}
+
+	entries := []interface{}{}
+	for i := 0; i < 50; i++ {
+		entries = append(entries, []interface{}{string(rune('a' + i%26))})
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			buf := bytes.NewBufferString(c.prefix)
+			w, err := NewEntryWriter(c.st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, ent := range entries {
+				if err := w.WriteEntry(Entry{Index: i, Value: ent}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// record the position of each entry from a full read
+			r, err := NewEntryReader(c.st, bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			offsets := []int64{}
+			for {
+				if _, err := r.ReadEntry(); err != nil {
+					break
+				}
+				offsets = append(offsets, r.(PositionReader).EntryPosition(-1).Offset)
+			}
+			r.Close()
+			if len(offsets) != len(entries) {
+				t.Fatalf("expected %d offsets, got: %d", len(entries), len(offsets))
+			}
+
+			for _, i := range []int{37, 0, 49} {
+				r, err := NewEntryReaderAt(c.st, bytes.NewReader(buf.Bytes()), offsets[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Structure() != c.st {
+					t.Error("expected reader structure to be the given structure")
+				}
+				got, err := ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := r.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(entries[i:], got); diff != "" {
+					t.Errorf("entry %d: result mismatch (-want +got):\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestNewEntryReaderAtErrors(t *testing.T) {
+	cases := []struct {
+		st   *dataset.Structure
+		body []byte
+		err  string
+	}{
+		{&dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}, nil, "reading from an offset isn't supported for json data"},
+		{&dataset.Structure{Format: "csv", Schema: basicTableSchema, Encoding: "ISO-8859-1"}, nil, "reading from an offset isn't supported for ISO-8859-1 encoded data"},
+		{&dataset.Structure{Format: "csv", Schema: basicTableSchema, Compression: "gzip"}, nil, "reading from an offset isn't supported for gzip compressed data"},
+		{&dataset.Structure{Format: "csv", Schema: basicTableSchema, Compression: "zst"}, []byte("not seekable"), "zstd data has no seek table"},
+	}
+
+	for _, c := range cases {
+		if _, err := NewEntryReaderAt(c.st, bytes.NewReader(c.body), 0); err == nil || err.Error() != c.err {
+			t.Errorf("error mismatch. expected: %q, got: %v", c.err, err)
+		}
+	}
+}
+
+func TestNewEntryReaderAtAppended(t *testing.T) {
+	st := &dataset.Structure{
+		Format:            "ndjson",
+		Schema:            dataset.BaseSchemaArray,
+		Compression:       "zst",
+		CompressionConfig: map[string]interface{}{"frameSize": 64},
+	}
+	body := &bytes.Buffer{}
+	w, err := NewEntryWriter(st, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{}
+	for i := 0; i < 20; i++ {
+		w.WriteEntry(Entry{Index: i, Value: strconv.Itoa(i)})
+		expect = append(expect, strconv.Itoa(i))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	st.Entries = 20
+
+	// appending to a seekable body keeps a single seek table
+	buf := &bytes.Buffer{}
+	if _, err := AppendEntries(buf, body, st, newAppendReader(st, []Entry{{Value: "20"}, {Value: "21"}})); err != nil {
+		t.Fatal(err)
+	}
+	expect = append(expect, "20", "21")
+
+	r, err := NewEntryReaderAt(st, bytes.NewReader(buf.Bytes()), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
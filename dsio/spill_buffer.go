@@ -0,0 +1,246 @@
+package dsio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/qri-io/dataset"
+)
+
+// DefaultMemoryBudget is the number of bytes a SpillBuffer holds in memory
+// before spilling to disk when no budget is given
+const DefaultMemoryBudget = 32 << 20
+
+// SpillBuffer is an append-only byte buffer that holds data in memory up to a
+// budget, moving everything to a temporary file once the budget is exceeded.
+// Any number of readers can replay the buffered bytes. Callers must Close the
+// buffer to remove the temporary file
+type SpillBuffer struct {
+	budget int
+	mem    bytes.Buffer
+	f      *os.File
+	fw     *bufio.Writer
+	size   int64
+	closed bool
+}
+
+var _ io.WriteCloser = (*SpillBuffer)(nil)
+
+// NewSpillBuffer creates a buffer that spills to disk after budget bytes are
+// written. budgets of zero or less use DefaultMemoryBudget
+func NewSpillBuffer(budget int) *SpillBuffer {
+	if budget <= 0 {
+		budget = DefaultMemoryBudget
+	}
+	return &SpillBuffer{budget: budget}
+}
+
+// Write appends to the buffer
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.closed {
+		return 0, fmt.Errorf("write to closed spill buffer")
+	}
+
+	if b.f == nil && b.mem.Len()+len(p) > b.budget {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		n   int
+		err error
+	)
+	if b.f != nil {
+		n, err = b.fw.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// spill moves buffered bytes to a temporary file
+func (b *SpillBuffer) spill() error {
+	f, err := ioutil.TempFile("", "dsio-spill-")
+	if err != nil {
+		return fmt.Errorf("creating spill file: %w", err)
+	}
+	b.f = f
+	b.fw = bufio.NewWriterSize(f, 256*1024)
+	if _, err := b.fw.Write(b.mem.Bytes()); err != nil {
+		return fmt.Errorf("writing spill file: %w", err)
+	}
+	b.mem = bytes.Buffer{}
+	return nil
+}
+
+// Len gives the number of bytes written to the buffer
+func (b *SpillBuffer) Len() int64 {
+	return b.size
+}
+
+// Spilled reports if the buffer has moved to disk
+func (b *SpillBuffer) Spilled() bool {
+	return b.f != nil
+}
+
+// SpillReader reads bytes written to a SpillBuffer, with random access
+type SpillReader interface {
+	io.ReadCloser
+	io.ReaderAt
+	io.Seeker
+}
+
+// NewReader creates a reader of all bytes written to the buffer so far.
+// Readers must be closed. Readers of spilled buffers hold their own file
+// handle, and remain readable on systems that allow removing open files
+func (b *SpillBuffer) NewReader() (SpillReader, error) {
+	if b.closed {
+		return nil, fmt.Errorf("read from closed spill buffer")
+	}
+	if b.f == nil {
+		return struct {
+			*bytes.Reader
+			io.Closer
+		}{bytes.NewReader(b.mem.Bytes()[:b.size]), ioutil.NopCloser(nil)}, nil
+	}
+
+	if err := b.fw.Flush(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(b.f.Name())
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		*io.SectionReader
+		io.Closer
+	}{io.NewSectionReader(f, 0, b.size), f}, nil
+}
+
+// Close releases the buffer, removing any temporary file
+func (b *SpillBuffer) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	b.mem = bytes.Buffer{}
+	if b.f == nil {
+		return nil
+	}
+	b.f.Close()
+	return os.Remove(b.f.Name())
+}
+
+// EntryStore buffers entries up to a memory budget, spilling to a temporary
+// file once the budget is exceeded. Unlike EntryBuffer, stored entries can be
+// replayed any number of times with NewReader. Entries are stored with gob,
+// so values are read back with the types they were written with. Values must
+// be types EntryReaders give, or types registered with gob.Register
+type EntryStore struct {
+	st  *dataset.Structure
+	buf *SpillBuffer
+	enc *gob.Encoder
+	n   int
+}
+
+func init() {
+	// composite values readers give are stored in interface fields
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(map[interface{}]interface{}{})
+	gob.Register(time.Time{})
+}
+
+// storedEntry is the on-disk representation of an entry
+type storedEntry struct {
+	Index int
+	Key   string
+	Value interface{}
+}
+
+// NewEntryStore creates an entry store. budget is the number of encoded bytes
+// held in memory, zero or less uses DefaultMemoryBudget
+func NewEntryStore(st *dataset.Structure, budget int) *EntryStore {
+	buf := NewSpillBuffer(budget)
+	return &EntryStore{
+		st:  st,
+		buf: buf,
+		enc: gob.NewEncoder(buf),
+	}
+}
+
+// Structure gives the structure of stored entries
+func (s *EntryStore) Structure() *dataset.Structure {
+	return s.st
+}
+
+// WriteEntry adds an entry to the store
+func (s *EntryStore) WriteEntry(ent Entry) error {
+	if err := s.enc.Encode(storedEntry{Index: ent.Index, Key: ent.Key, Value: ent.Value}); err != nil {
+		return fmt.Errorf("storing entry %d: %w", ent.Index, err)
+	}
+	s.n++
+	return nil
+}
+
+// Len gives the number of stored entries
+func (s *EntryStore) Len() int {
+	return s.n
+}
+
+// Size gives the number of bytes used to store entries
+func (s *EntryStore) Size() int64 {
+	return s.buf.Len()
+}
+
+// Spilled reports if the store has moved to disk
+func (s *EntryStore) Spilled() bool {
+	return s.buf.Spilled()
+}
+
+// NewReader creates an EntryReader that replays stored entries from the start
+func (s *EntryStore) NewReader() (EntryReader, error) {
+	rc, err := s.buf.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	dec := gob.NewDecoder(bufio.NewReader(rc))
+	return &entryStoreReader{st: s.st, dec: dec, close: rc.Close}, nil
+}
+
+// Close releases the store, removing any temporary file
+func (s *EntryStore) Close() error {
+	return s.buf.Close()
+}
+
+type entryStoreReader struct {
+	st    *dataset.Structure
+	dec   *gob.Decoder
+	close func() error
+}
+
+var _ EntryReader = (*entryStoreReader)(nil)
+
+func (r *entryStoreReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+func (r *entryStoreReader) ReadEntry() (Entry, error) {
+	se := storedEntry{}
+	if err := r.dec.Decode(&se); err != nil {
+		return Entry{}, err
+	}
+	return Entry{Index: se.Index, Key: se.Key, Value: se.Value}, nil
+}
+
+func (r *entryStoreReader) Close() error {
+	return r.close()
+}
@@ -0,0 +1,102 @@
+package dsio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestSpillBuffer(t *testing.T) {
+	buf := NewSpillBuffer(8)
+	if _, err := buf.Write([]byte("hello ")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Spilled() {
+		t.Error("expected buffer under budget to be held in memory")
+	}
+	if _, err := buf.Write([]byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	if !buf.Spilled() {
+		t.Fatal("expected buffer over budget to spill to disk")
+	}
+	path := buf.f.Name()
+
+	for i := 0; i < 2; i++ {
+		r, err := buf.NewReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "hello world" {
+			t.Errorf("replay %d mismatch. expected: %q, got: %q", i, "hello world", string(data))
+		}
+	}
+
+	if err := buf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected spill file to be removed on close, got: %v", err)
+	}
+	if _, err := buf.Write([]byte("!")); err == nil {
+		t.Error("expected writing to a closed buffer to error")
+	}
+}
+
+func TestEntryStore(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	entries := []Entry{
+		{Index: 0, Value: []interface{}{"a", int64(1), 1.5, float64(3), []byte("bytes"), true, nil}},
+		{Index: 1, Value: map[string]interface{}{"b": []interface{}{int64(2)}}},
+		{Index: 2, Key: "c", Value: "long value that pushes the store over it's budget"},
+	}
+
+	for _, budget := range []int{0, 64} {
+		s := NewEntryStore(st, budget)
+		for _, ent := range entries {
+			if err := s.WriteEntry(ent); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if s.Len() != len(entries) {
+			t.Errorf("budget %d: expected %d entries, got %d", budget, len(entries), s.Len())
+		}
+		if spilled := budget > 0; s.Spilled() != spilled {
+			t.Errorf("budget %d: expected spilled to be %t", budget, spilled)
+		}
+
+		for i := 0; i < 2; i++ {
+			r, err := s.NewReader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []Entry{}
+			for {
+				ent, err := r.ReadEntry()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, ent)
+			}
+			r.Close()
+			if diff := cmp.Diff(entries, got); diff != "" {
+				t.Errorf("budget %d replay %d mismatch (-want +got):\n%s", budget, i, diff)
+			}
+		}
+
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,669 @@
+package dsio
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
+)
+
+// structField connects a struct field to a dataset column title. Struct fields
+// are mapped to columns with a "dataset" struct tag:
+//
+//	type City struct {
+//		Name       string    `dataset:"name"`
+//		Population int64     `dataset:"pop"`
+//		Founded    time.Time `dataset:"founded"`
+//		Internal   string    `dataset:"-"`
+//	}
+//
+// Untagged fields use the field name as a title. Column titles match tags
+// exactly first, falling back to a case-insensitive match
+type structField struct {
+	title string
+	index []int
+	typ   reflect.Type
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// structFields lists the mappable fields of a struct type, flattening
+// untagged embedded structs
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("dataset")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				for _, ef := range structFields(ft) {
+					ef.index = append([]int{i}, ef.index...)
+					fields = append(fields, ef)
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		title := f.Name
+		if tag != "" {
+			title = tag
+		}
+		fields = append(fields, structField{title: title, index: []int{i}, typ: f.Type})
+	}
+	return fields
+}
+
+func structType(v interface{}) (reflect.Type, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct or pointer to a struct, got %T", v)
+	}
+	return t, nil
+}
+
+// StructSchema derives a tabular JSON schema from a struct type, with one
+// column per mapped field. v may be a struct value or a pointer to a struct,
+// and may be nil: StructSchema((*City)(nil))
+func StructSchema(v interface{}) (map[string]interface{}, error) {
+	t, err := structType(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := structFields(t)
+	cols := make([]interface{}, len(fields))
+	for i, f := range fields {
+		col := map[string]interface{}{"title": f.title}
+		typ, format := schemaType(f.typ)
+		if f.typ.Kind() == reflect.Ptr {
+			col["type"] = []interface{}{typ, "null"}
+		} else {
+			col["type"] = typ
+		}
+		if format != "" {
+			col["format"] = format
+		}
+		if isBytes(f.typ) {
+			col["contentEncoding"] = "base64"
+		}
+		cols[i] = col
+	}
+
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": cols,
+		},
+	}, nil
+}
+
+// schemaType gives the JSON schema type for a go type, and a format keyword
+// where one applies
+func schemaType(t reflect.Type) (typ, format string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "string", "date-time"
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return "string", ""
+	}
+
+	if isBytes(t) {
+		return "string", ""
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", ""
+	case reflect.Bool:
+		return "boolean", ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", ""
+	case reflect.Float32, reflect.Float64:
+		return "number", ""
+	case reflect.Slice, reflect.Array:
+		return "array", ""
+	case reflect.Map, reflect.Struct:
+		return "object", ""
+	default:
+		return "string", ""
+	}
+}
+
+// isBytes reports if a type is a byte slice, which is written as base64
+// encoded text like encoding/json does
+func isBytes(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// entryColumns gives column titles & formats for array-row structures. Object
+// row structures return nil titles, their values are matched by key
+func entryColumns(st *dataset.Structure) (titles, formats []string) {
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil, nil
+	}
+	titles = cols.Titles()
+	formats = make([]string, len(cols))
+	for i, c := range cols {
+		formats[i], _ = c.Validation["format"].(string)
+	}
+	return titles, formats
+}
+
+// matchColumns maps each struct field to a column index, -1 for fields
+// without a matching column
+func matchColumns(fields []structField, titles []string) []int {
+	idx := make([]int, len(fields))
+	for i, f := range fields {
+		idx[i] = -1
+		for j, t := range titles {
+			if t == f.title {
+				idx[i] = j
+				break
+			}
+		}
+		if idx[i] != -1 {
+			continue
+		}
+		for j, t := range titles {
+			if strings.EqualFold(t, f.title) {
+				idx[i] = j
+				break
+			}
+		}
+	}
+	return idx
+}
+
+// StructDecoder fills go structs with entry values, matching struct fields to
+// schema column titles & converting values to field types
+type StructDecoder struct {
+	r      EntryReader
+	titles []string
+	cache  map[reflect.Type]*structMapping
+}
+
+type structMapping struct {
+	fields  []structField
+	columns []int
+}
+
+// NewStructDecoder creates a decoder that reads entries from an EntryReader.
+// The reader may be nil if entries are only decoded with DecodeEntry
+func NewStructDecoder(st *dataset.Structure, r EntryReader) *StructDecoder {
+	titles, _ := entryColumns(st)
+	return &StructDecoder{
+		r:      r,
+		titles: titles,
+		cache:  map[reflect.Type]*structMapping{},
+	}
+}
+
+// Decode reads the next entry into v, which must be a pointer to a struct.
+// Decode returns io.EOF when the reader has no more entries
+func (d *StructDecoder) Decode(v interface{}) error {
+	if d.r == nil {
+		return fmt.Errorf("struct decoder has no entry reader")
+	}
+	ent, err := d.r.ReadEntry()
+	if err != nil {
+		return err
+	}
+	return d.DecodeEntry(ent, v)
+}
+
+// DecodeEntry fills a struct pointer with the values of an entry. Entry values
+// may be arrays, matched to fields by schema column titles, or objects,
+// matched to fields by key. Values that can't be converted to the type of
+// their field cause an error
+func (d *StructDecoder) DecodeEntry(ent Entry, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decoding entry: expected a pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+	m := d.mapping(rv.Type())
+
+	switch row := ent.Value.(type) {
+	case []interface{}:
+		for i, f := range m.fields {
+			col := m.columns[i]
+			if col < 0 || col >= len(row) {
+				continue
+			}
+			if err := setValue(fieldByIndex(rv, f.index), row[col]); err != nil {
+				return fmt.Errorf("entry %d: decoding column %q into field %s: %w", ent.Index, f.title, rv.Type().FieldByIndex(f.index).Name, err)
+			}
+		}
+	case map[string]interface{}:
+		for _, f := range m.fields {
+			val, ok := row[f.title]
+			if !ok {
+				for key, kv := range row {
+					if strings.EqualFold(key, f.title) {
+						val, ok = kv, true
+						break
+					}
+				}
+			}
+			if !ok {
+				continue
+			}
+			if err := setValue(fieldByIndex(rv, f.index), val); err != nil {
+				return fmt.Errorf("entry %d: decoding key %q into field %s: %w", ent.Index, f.title, rv.Type().FieldByIndex(f.index).Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("entry %d: expected an array or object value to decode into a struct, got %T", ent.Index, ent.Value)
+	}
+	return nil
+}
+
+func (d *StructDecoder) mapping(t reflect.Type) *structMapping {
+	if m, ok := d.cache[t]; ok {
+		return m
+	}
+	fields := structFields(t)
+	m := &structMapping{fields: fields, columns: matchColumns(fields, d.titles)}
+	d.cache[t] = m
+	return m
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, allocating nil embedded struct
+// pointers along the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// setValue assigns an entry value to a struct field, converting types. Empty
+// strings are treated as null for fields that aren't strings, as text formats
+// like CSV can't otherwise express null values
+func setValue(dst reflect.Value, val interface{}) error {
+	if str, ok := val.(string); ok && str == "" && !stringLike(dst.Type()) {
+		val = nil
+	}
+	if val == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return setValue(dst.Elem(), val)
+	}
+
+	if dst.Type() == timeType {
+		return setTime(dst, val)
+	}
+	if dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		if str, ok := val.(string); ok {
+			return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(val))
+		return nil
+	case reflect.String:
+		switch x := val.(type) {
+		case string:
+			dst.SetString(x)
+		case float64:
+			dst.SetString(strconv.FormatFloat(x, 'f', -1, 64))
+		case int64, int, uint64, bool:
+			dst.SetString(fmt.Sprintf("%v", x))
+		default:
+			data, err := json.Marshal(x)
+			if err != nil {
+				return err
+			}
+			dst.SetString(string(data))
+		}
+		return nil
+	case reflect.Bool:
+		switch x := val.(type) {
+		case bool:
+			dst.SetBool(x)
+			return nil
+		case string:
+			b, err := vals.ParseBoolean([]byte(strings.TrimSpace(x)))
+			if err != nil {
+				return fmt.Errorf("cannot convert %q to bool", x)
+			}
+			dst.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %s", n, dst.Type())
+		}
+		dst.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		if n < 0 || dst.OverflowUint(uint64(n)) {
+			return fmt.Errorf("value %d overflows %s", n, dst.Type())
+		}
+		dst.SetUint(uint64(n))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(val)
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+		return nil
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		if isBytes(dst.Type()) {
+			switch x := val.(type) {
+			case []byte:
+				dst.SetBytes(append([]byte(nil), x...))
+				return nil
+			case string:
+				data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(x))
+				if err != nil {
+					return fmt.Errorf("cannot convert %q to bytes: %w", x, err)
+				}
+				dst.SetBytes(data)
+				return nil
+			}
+		}
+		// composite values take a trip through JSON, which also covers string
+		// values that hold JSON text
+		data, ok := val.(string)
+		var raw []byte
+		if ok {
+			raw = []byte(data)
+		} else {
+			var err error
+			if raw, err = json.Marshal(val); err != nil {
+				return err
+			}
+		}
+		return json.Unmarshal(raw, dst.Addr().Interface())
+	}
+
+	return fmt.Errorf("cannot convert %T to %s", val, dst.Type())
+}
+
+func stringLike(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String || t.Kind() == reflect.Interface
+}
+
+func toInt64(val interface{}) (int64, error) {
+	switch x := val.(type) {
+	case int64:
+		return x, nil
+	case int:
+		return int64(x), nil
+	case uint64:
+		return int64(x), nil
+	case float64:
+		if x != float64(int64(x)) {
+			return 0, fmt.Errorf("cannot convert %v to an integer without losing precision", x)
+		}
+		return int64(x), nil
+	case string:
+		n, err := vals.ParseInteger([]byte(strings.TrimSpace(x)))
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to an integer", x)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to an integer", val)
+}
+
+func toFloat64(val interface{}) (float64, error) {
+	switch x := val.(type) {
+	case float64:
+		return x, nil
+	case int64:
+		return float64(x), nil
+	case int:
+		return float64(x), nil
+	case uint64:
+		return float64(x), nil
+	case string:
+		f, err := vals.ParseNumber([]byte(strings.TrimSpace(x)))
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to a number", x)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to a number", val)
+}
+
+// structTimeLayouts are attempted in order when converting strings to times
+var structTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func setTime(dst reflect.Value, val interface{}) error {
+	switch x := val.(type) {
+	case time.Time:
+		dst.Set(reflect.ValueOf(x))
+		return nil
+	case string:
+		for _, layout := range structTimeLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(x)); err == nil {
+				dst.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot convert %q to a time", x)
+	case int64, int, float64:
+		// numbers are read as unix timestamps in seconds
+		n, err := toFloat64(x)
+		if err != nil {
+			return err
+		}
+		sec := int64(n)
+		dst.Set(reflect.ValueOf(time.Unix(sec, int64((n-float64(sec))*1e9)).UTC()))
+		return nil
+	}
+	return fmt.Errorf("cannot convert %T to a time", val)
+}
+
+// StructEncoder writes go structs to an EntryWriter. Array-row structures
+// write struct fields in schema column order, object-row structures write
+// fields keyed by title
+type StructEncoder struct {
+	w       EntryWriter
+	titles  []string
+	formats []string
+	objects bool
+	cache   map[reflect.Type]*structMapping
+	idx     int
+}
+
+// NewStructEncoder creates an encoder that writes to w, using the structure
+// of the writer to order values
+func NewStructEncoder(w EntryWriter) *StructEncoder {
+	st := w.Structure()
+	titles, formats := entryColumns(st)
+	return &StructEncoder{
+		w:       w,
+		titles:  titles,
+		formats: formats,
+		objects: titles == nil,
+		cache:   map[reflect.Type]*structMapping{},
+	}
+}
+
+// Encode writes a struct or pointer to a struct as an entry
+func (e *StructEncoder) Encode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("encoding entry: expected a struct or pointer to a struct, got %T", v)
+	}
+	m := e.mapping(rv.Type())
+
+	ent := Entry{Index: e.idx}
+	if e.objects {
+		obj := make(map[string]interface{}, len(m.fields))
+		for _, f := range m.fields {
+			val, err := entryValue(rv, f, "")
+			if err != nil {
+				return err
+			}
+			obj[f.title] = val
+		}
+		ent.Value = obj
+	} else {
+		row := make([]interface{}, len(e.titles))
+		for i, f := range m.fields {
+			col := m.columns[i]
+			if col < 0 {
+				continue
+			}
+			val, err := entryValue(rv, f, e.formats[col])
+			if err != nil {
+				return err
+			}
+			row[col] = val
+		}
+		ent.Value = row
+	}
+
+	if err := e.w.WriteEntry(ent); err != nil {
+		return err
+	}
+	e.idx++
+	return nil
+}
+
+func (e *StructEncoder) mapping(t reflect.Type) *structMapping {
+	if m, ok := e.cache[t]; ok {
+		return m
+	}
+	fields := structFields(t)
+	m := &structMapping{fields: fields, columns: matchColumns(fields, e.titles)}
+	e.cache[t] = m
+	return m
+}
+
+// entryValue converts a struct field to an entry value of the types readers
+// produce: string, int64, float64, bool, nil, []interface{} and
+// map[string]interface{}
+func entryValue(rv reflect.Value, f structField, format string) (interface{}, error) {
+	v := rv
+	for i, x := range f.index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if format == "date" {
+			return t.Format("2006-01-02"), nil
+		}
+		return t.Format(time.RFC3339Nano), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, fmt.Errorf("encoding field %s: %w", f.title, err)
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("encoding field %s: value %d overflows int64", f.title, v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	default:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("encoding field %s: %w", f.title, err)
+		}
+		var val interface{}
+		if err := json.Unmarshal(data, &val); err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+}
@@ -0,0 +1,211 @@
+package dsio
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+type structTestBase struct {
+	ID int64 `dataset:"id"`
+}
+
+type structTestCity struct {
+	structTestBase
+	Name       string    `dataset:"name"`
+	Population *int      `dataset:"pop"`
+	Area       float32   `dataset:"area"`
+	Capital    bool      `dataset:"capital"`
+	Founded    time.Time `dataset:"founded"`
+	Tags       []string  `dataset:"tags"`
+	Logo       []byte    `dataset:"logo"`
+	Notes      string
+	internal   string
+	Skipped    string `dataset:"-"`
+}
+
+func TestStructSchema(t *testing.T) {
+	got, err := StructSchema((*structTestCity)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "id", "type": "integer"},
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": []interface{}{"integer", "null"}},
+				map[string]interface{}{"title": "area", "type": "number"},
+				map[string]interface{}{"title": "capital", "type": "boolean"},
+				map[string]interface{}{"title": "founded", "type": "string", "format": "date-time"},
+				map[string]interface{}{"title": "tags", "type": "array"},
+				map[string]interface{}{"title": "logo", "type": "string", "contentEncoding": "base64"},
+				map[string]interface{}{"title": "Notes", "type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := StructSchema("not a struct"); err == nil {
+		t.Error("expected non-struct value to error")
+	}
+}
+
+func TestStructRoundTrip(t *testing.T) {
+	sch, err := StructSchema(structTestCity{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema:       sch,
+	}
+
+	pop := 8000000
+	cities := []structTestCity{
+		{
+			structTestBase: structTestBase{ID: 1},
+			Name:           "New York",
+			Population:     &pop,
+			Area:           783.8,
+			Founded:        time.Date(1624, 1, 1, 0, 0, 0, 0, time.UTC),
+			Tags:           []string{"big", "apple"},
+			Logo:           []byte{0x89, 'P', 'N', 'G'},
+			Notes:          "hello, world",
+		},
+		{structTestBase: structTestBase{ID: 2}, Name: "Nowhere", Capital: true},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := NewStructEncoder(w)
+	for _, c := range cities {
+		c.Skipped = "not written"
+		if err := enc.Encode(&c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewEntryReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := NewStructDecoder(st, r)
+	var got []structTestCity
+	for {
+		c := structTestCity{}
+		if err := dec.Decode(&c); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, c)
+	}
+
+	if diff := cmp.Diff(cities, got, cmp.AllowUnexported(structTestCity{})); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStructDecoderConversion(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "json",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "NAME", "type": "string"},
+					map[string]interface{}{"title": "pop", "type": "string"},
+					map[string]interface{}{"title": "founded", "type": "string"},
+					map[string]interface{}{"title": "capital", "type": "string"},
+				},
+			},
+		},
+	}
+
+	dec := NewStructDecoder(st, nil)
+	got := structTestCity{}
+	ent := Entry{Value: []interface{}{"Paris", "2148000", "0250-01-01", "true"}}
+	if err := dec.DecodeEntry(ent, &got); err != nil {
+		t.Fatal(err)
+	}
+	pop := 2148000
+	expect := structTestCity{
+		Name:       "Paris",
+		Population: &pop,
+		Founded:    time.Date(250, 1, 1, 0, 0, 0, 0, time.UTC),
+		Capital:    true,
+	}
+	if diff := cmp.Diff(expect, got, cmp.AllowUnexported(structTestCity{})); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	// object entries are matched by key
+	got = structTestCity{}
+	ent = Entry{Value: map[string]interface{}{"id": float64(7), "name": "Oslo", "tags": []interface{}{"north"}}}
+	if err := dec.DecodeEntry(ent, &got); err != nil {
+		t.Fatal(err)
+	}
+	expect = structTestCity{structTestBase: structTestBase{ID: 7}, Name: "Oslo", Tags: []string{"north"}}
+	if diff := cmp.Diff(expect, got, cmp.AllowUnexported(structTestCity{})); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	bad := []struct {
+		val interface{}
+		err string
+	}{
+		{[]interface{}{"Paris", "lots"}, `entry 0: decoding column "pop" into field Population: cannot convert "lots" to an integer`},
+		{map[string]interface{}{"id": 1.5}, `entry 0: decoding key "id" into field ID: cannot convert 1.5 to an integer without losing precision`},
+		{"nope", "entry 0: expected an array or object value to decode into a struct, got string"},
+	}
+	for _, c := range bad {
+		err := dec.DecodeEntry(Entry{Value: c.val}, &structTestCity{})
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("expected error %q, got: %v", c.err, err)
+		}
+	}
+}
+
+func TestStructEncoderUintOverflow(t *testing.T) {
+	type counter struct {
+		Count uint64 `dataset:"count"`
+	}
+	sch, err := StructSchema(counter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &dataset.Structure{Format: "json", Schema: sch}
+	w, err := NewEntryWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	enc := NewStructEncoder(w)
+
+	if err := enc.Encode(counter{Count: math.MaxInt64}); err != nil {
+		t.Fatal(err)
+	}
+	expect := "encoding field count: value 18446744073709551615 overflows int64"
+	if err := enc.Encode(counter{Count: math.MaxUint64}); err == nil || err.Error() != expect {
+		t.Errorf("expected error %q, got: %v", expect, err)
+	}
+}
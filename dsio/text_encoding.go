@@ -0,0 +1,138 @@
+package dsio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// TextEncoding looks up a character encoding by name, accepting IANA names &
+// aliases like "ISO-8859-1", "latin1", "windows-1252", "UTF-16LE". An empty
+// name is UTF-8
+func TextEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utf-8", "utf8":
+		return unicode.UTF8, nil
+	case "utf-16", "utf16":
+		// UTF-16 without a byte order mark is big-endian per RFC 2781
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM), nil
+	}
+
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		if enc, err = htmlindex.Get(name); err != nil {
+			return nil, fmt.Errorf("unsupported character encoding: %q", name)
+		}
+	}
+	return enc, nil
+}
+
+// isUTF8 reports if an encoding name refers to UTF-8
+func isUTF8(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utf-8", "utf8":
+		return true
+	}
+	return false
+}
+
+// NewTextDecoder wraps a reader of text in the named character encoding,
+// returning a reader of UTF-8 text. A leading byte order mark is removed, and
+// takes precedence over the named encoding
+func NewTextDecoder(name string, r io.Reader) (io.Reader, error) {
+	enc, err := TextEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return transform.NewReader(r, unicode.BOMOverride(enc.NewDecoder())), nil
+}
+
+// NewTextEncoder wraps a writer, encoding UTF-8 text written to it as the
+// named character encoding. Callers must close the returned writer to flush
+// any buffered data. Characters that can't be represented in the encoding
+// cause an error
+func NewTextEncoder(name string, w io.Writer) (io.WriteCloser, error) {
+	enc, err := TextEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return transform.NewWriter(w, enc.NewEncoder()), nil
+}
+
+// maybeWrapTextDecoder decompresses & decodes text-based data formats,
+// transcoding to UTF-8 when the structure specifies a character encoding
+func maybeWrapTextDecoder(st *dataset.Structure, r io.Reader) (io.Reader, func() error, error) {
+	dr, close, err := maybeWrapDecompressor(st, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if st.Encoding == "" {
+		return dr, close, nil
+	}
+
+	tr, err := NewTextDecoder(st.Encoding, dr)
+	if err != nil {
+		if close != nil {
+			close()
+		}
+		return nil, nil, err
+	}
+	return tr, close, nil
+}
+
+// maybeWrapTextEncoder compresses & encodes text-based data formats,
+// transcoding from UTF-8 when the structure specifies a character encoding
+func maybeWrapTextEncoder(st *dataset.Structure, w io.Writer) (io.Writer, func() error, error) {
+	cw, close, err := maybeWrapCompressor(st, w)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isUTF8(st.Encoding) {
+		return cw, close, nil
+	}
+
+	ew, err := NewTextEncoder(st.Encoding, cw)
+	if err != nil {
+		if close != nil {
+			close()
+		}
+		return nil, nil, err
+	}
+	return ew, func() error {
+		if err := ew.Close(); err != nil {
+			return err
+		}
+		if close != nil {
+			return close()
+		}
+		return nil
+	}, nil
+}
+
+// xmlCharsetReader satisfies xml.Decoder.CharsetReader for XML documents that
+// declare a character encoding other than UTF-8. UTF-16 documents must begin
+// with a byte order mark, and are transcoded by newXMLReader before the
+// declaration is read, so they're passed through as-is
+func xmlCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	if strings.HasPrefix(strings.ToLower(label), "utf-16") {
+		return input, nil
+	}
+	enc, err := TextEncoding(label)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// newXMLReader transcodes XML documents that begin with a UTF-16 byte order
+// mark to UTF-8, leaving all other input untouched
+func newXMLReader(r io.Reader) io.Reader {
+	return transform.NewReader(r, unicode.BOMOverride(encoding.Nop.NewDecoder()))
+}
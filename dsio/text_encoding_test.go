@@ -0,0 +1,105 @@
+package dsio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func encodedCSVStructure(enc string) *dataset.Structure {
+	return &dataset.Structure{
+		Format:       "csv",
+		Encoding:     enc,
+		FormatConfig: map[string]interface{}{"headerRow": true, "separator": "\t"},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "city", "type": "string"},
+					map[string]interface{}{"title": "note", "type": "string"},
+				},
+			},
+		},
+	}
+}
+
+func TestTextEncodingReader(t *testing.T) {
+	expect := []interface{}{
+		[]interface{}{"Zürich", "“quoted” – 5€"},
+	}
+
+	cases := []struct {
+		encoding string
+		data     []byte
+	}{
+		{"", []byte("city\tnote\nZürich\t“quoted” – 5€\n")},
+		{"utf-8", append([]byte{0xEF, 0xBB, 0xBF}, []byte("city\tnote\nZürich\t“quoted” – 5€\n")...)},
+		{"windows-1252", []byte("city\tnote\nZ\xfcrich\t\x93quoted\x94 \x96 5\x80\n")},
+		{"UTF-16LE", utf16le("\ufeffcity\tnote\nZürich\t“quoted” – 5€\n")},
+		// the byte order mark overrides the named encoding
+		{"UTF-16", utf16le("\ufeffcity\tnote\nZürich\t“quoted” – 5€\n")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.encoding, func(t *testing.T) {
+			r, err := NewEntryReader(encodedCSVStructure(c.encoding), bytes.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := NewEntryReader(encodedCSVStructure("not-an-encoding"), &bytes.Buffer{}); err == nil {
+		t.Error("expected unknown encoding to error")
+	}
+}
+
+func TestTextEncodingWriter(t *testing.T) {
+	st := encodedCSVStructure("ISO-8859-1")
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEntry(Entry{Value: []interface{}{"Zürich", "café"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []byte("city\tnote\nZ\xfcrich\tcaf\xe9\n")
+	if !bytes.Equal(expect, buf.Bytes()) {
+		t.Errorf("output mismatch. want: %q got: %q", expect, buf.Bytes())
+	}
+
+	w, err = NewEntryWriter(st, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteEntry(Entry{Value: []interface{}{"東京", ""}})
+	if err := w.Close(); err == nil {
+		t.Error("expected writing characters latin-1 can't represent to error")
+	}
+}
+
+func utf16le(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		if r > 0xFFFF {
+			panic("utf16le test helper doesn't support surrogate pairs")
+		}
+		b = append(b, byte(r), byte(r>>8))
+	}
+	return b
+}
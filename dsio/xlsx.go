@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/qri-io/dataset"
@@ -12,18 +15,30 @@ import (
 	"github.com/qri-io/dataset/vals"
 )
 
-// XLSXReader implements the RowReader interface for the XLSX data format
+// XLSXReader implements the RowReader interface for the XLSX data format.
+// XLSXReader streams rows directly from worksheet XML, holding only the
+// current row and the workbook's shared strings table in memory
 type XLSXReader struct {
 	err       error
 	st        *dataset.Structure
 	sheetName string
-	file      *excelize.File
-	r         *excelize.Rows
+	wb        *xlsxWorkbook
+	r         *xlsxSheetRows
 	idx       int
 	types     []string
+	formats   []string
+	// number of description, initial & header rows to skip before the first entry
+	skip int
+	// sheet row number of the most recently read entry
+	rowNum int
 }
 
-// NewXLSXReader creates a reader from a structure and read source
+var _ EntryReader = (*XLSXReader)(nil)
+
+// NewXLSXReader creates a reader from a structure and read source. xlsx files
+// are zip archives that require random access to read. If r is an
+// io.ReaderAt & io.Seeker (like *os.File) it's read in place, otherwise r is
+// buffered to a temporary file that's removed when the reader is closed
 func NewXLSXReader(st *dataset.Structure, r io.Reader) (*XLSXReader, error) {
 	if st.Compression != "" {
 		return nil, fmt.Errorf("xlsx format does not support compression")
@@ -35,16 +50,19 @@ func NewXLSXReader(st *dataset.Structure, r io.Reader) (*XLSXReader, error) {
 	}
 
 	types := make([]string, len(cols))
+	formats := make([]string, len(cols))
 	for i, c := range cols {
 		types[i] = []string(*c.Type)[0]
+		formats[i], _ = c.Validation["format"].(string)
 	}
 
 	rdr := &XLSXReader{
-		st:    st,
-		types: types,
+		st:      st,
+		types:   types,
+		formats: formats,
 	}
 
-	rdr.file, rdr.err = excelize.OpenReader(r)
+	rdr.wb, rdr.err = openXLSXWorkbook(r)
 	if rdr.err != nil {
 		return rdr, rdr.err
 	}
@@ -52,17 +70,24 @@ func NewXLSXReader(st *dataset.Structure, r io.Reader) (*XLSXReader, error) {
 	if fcg, err := dataset.ParseFormatConfigMap(dataset.XLSXDataFormat, st.FormatConfig); err == nil {
 		if opts, ok := fcg.(*dataset.XLSXOptions); ok {
 			rdr.sheetName = opts.SheetName
+			if opts.Description != "" {
+				rdr.skip++
+			}
+			rdr.skip += opts.SkipInitialRows
+			if opts.HeaderRow {
+				rdr.skip++
+			}
 		}
 	}
-	if rdr.sheetName == "" {
-		rdr.sheetName = "Sheet1"
-	}
 
-	if rdr.err == nil {
-		rdr.r, rdr.err = rdr.file.Rows(rdr.sheetName)
+	rdr.r, rdr.err = rdr.wb.Sheet(rdr.sheetName)
+	if rdr.err != nil {
+		rdr.wb.Close()
+		return rdr, rdr.err
 	}
+	rdr.sheetName = rdr.r.name
 
-	return rdr, rdr.err
+	return rdr, nil
 }
 
 // Structure gives this reader's structure
@@ -75,10 +100,16 @@ func (r *XLSXReader) ReadEntry() (Entry, error) {
 	if r.err != nil {
 		return Entry{}, r.err
 	}
-	if !r.r.Next() {
-		return Entry{}, io.EOF
+	for ; r.skip > 0; r.skip-- {
+		if _, err := r.r.Next(); err != nil {
+			return Entry{}, err
+		}
+	}
+	cols, err := r.r.Next()
+	if err != nil {
+		return Entry{}, err
 	}
-	cols := r.r.Columns()
+	r.rowNum = r.r.rowNum
 	vals, err := r.decode(cols)
 	if err != nil {
 		return Entry{}, err
@@ -89,6 +120,18 @@ func (r *XLSXReader) ReadEntry() (Entry, error) {
 	return ent, nil
 }
 
+// EntryPosition gives the sheet & cell reference of a value in the most
+// recently read row, field -1 refers to the first cell of the row
+func (r *XLSXReader) EntryPosition(field int) Position {
+	if field < 0 {
+		field = 0
+	}
+	return Position{
+		Sheet: r.sheetName,
+		Cell:  ColIndexToLetters(field) + strconv.Itoa(r.rowNum),
+	}
+}
+
 // decode uses specified types from structure's schema to cast xlsx string values to their
 // intended types. If casting fails because the data is invalid, it's left as a string instead
 // of causing an error.
@@ -131,15 +174,28 @@ func (r *XLSXReader) decode(strings []string) ([]interface{}, error) {
 			}
 		case "null":
 			vs[i] = nil
+		case "string":
+			// excel stores dates as numeric serials, text cells are read as-is
+			if i < len(r.formats) && (r.formats[i] == "date" || r.formats[i] == "date-time") && r.r.numeric(i) {
+				if serial, err := vals.ParseNumber([]byte(str)); err == nil {
+					vs[i] = formatXLSXDate(serial, r.formats[i])
+				}
+			}
 		}
 	}
 
 	return vs, nil
 }
 
-// Close finalizes the writer, indicating no more records will be read
+// Close finalizes the reader, indicating no more records will be read
 func (r *XLSXReader) Close() error {
-	return nil
+	if r.wb == nil {
+		return nil
+	}
+	if r.r != nil {
+		r.r.Close()
+	}
+	return r.wb.Close()
 }
 
 // XLSXWriter implements the RowWriter interface for
@@ -150,7 +206,13 @@ type XLSXWriter struct {
 	f           *excelize.File
 	st          *dataset.Structure
 	w           io.Writer
+	cols        tabular.Columns
 	types       []string
+	opts        *dataset.XLSXOptions
+	// number of description & header rows written before the first entry
+	headRows int
+	// widest value written to each column, in characters
+	widths []int
 }
 
 // NewXLSXWriter creates a Writer from a structure and write destination
@@ -170,14 +232,18 @@ func NewXLSXWriter(st *dataset.Structure, w io.Writer) (*XLSXWriter, error) {
 	}
 
 	wr := &XLSXWriter{
-		st:    st,
-		f:     excelize.NewFile(),
-		types: types,
-		w:     w,
+		st:     st,
+		f:      excelize.NewFile(),
+		cols:   cols,
+		types:  types,
+		w:      w,
+		opts:   &dataset.XLSXOptions{},
+		widths: make([]int, len(cols)),
 	}
 
 	if fcg, err := dataset.ParseFormatConfigMap(dataset.XLSXDataFormat, st.FormatConfig); err == nil {
 		if opts, ok := fcg.(*dataset.XLSXOptions); ok {
+			wr.opts = opts
 			wr.sheetName = opts.SheetName
 		}
 	} else {
@@ -191,6 +257,22 @@ func NewXLSXWriter(st *dataset.Structure, w io.Writer) (*XLSXWriter, error) {
 	idx := wr.f.NewSheet(wr.sheetName)
 	wr.f.SetActiveSheet(idx)
 
+	if wr.opts.Description != "" {
+		wr.f.SetCellStr(wr.sheetName, wr.axis(0), wr.opts.Description)
+		if len(cols) > 1 {
+			wr.f.MergeCell(wr.sheetName, wr.axis(0), wr.axis(len(cols)-1))
+		}
+		wr.rowsWritten++
+	}
+	if wr.opts.HeaderRow {
+		for i, title := range cols.Titles() {
+			wr.f.SetCellStr(wr.sheetName, wr.axis(i), title)
+			wr.fitWidth(i, title)
+		}
+		wr.rowsWritten++
+	}
+	wr.headRows = wr.rowsWritten
+
 	return wr, nil
 }
 
@@ -208,7 +290,8 @@ func (w *XLSXWriter) WriteEntry(ent Entry) error {
 			return fmt.Errorf("error encoding entry: %s", err.Error())
 		}
 		for i, str := range strs {
-			w.f.SetCellValue(w.sheetName, w.axis(i), str)
+			w.f.SetCellValue(w.sheetName, w.axis(i), w.cellValue(i, arr[i], str))
+			w.fitWidth(i, str)
 		}
 		w.rowsWritten++
 		return nil
@@ -216,6 +299,42 @@ func (w *XLSXWriter) WriteEntry(ent Entry) error {
 	return fmt.Errorf("expected array value to write xlsx row. got: %v", ent)
 }
 
+// cellValue picks the value to set for a cell. numbers are written as numeric
+// cells so number formats apply, and strings in date-formatted columns are
+// written as dates when a date number format is configured
+func (w *XLSXWriter) cellValue(colIdx int, v interface{}, str string) interface{} {
+	switch x := v.(type) {
+	case int, int64, float64:
+		return x
+	case string:
+		format := w.columnFormat(colIdx)
+		if _, ok := w.opts.NumberFormats[format]; ok {
+			if t, err := parseXLSXDate(x); err == nil {
+				return t
+			}
+		}
+	}
+	return str
+}
+
+// columnFormat gives the json schema "format" keyword for a column, if any
+func (w *XLSXWriter) columnFormat(colIdx int) string {
+	if colIdx >= len(w.cols) {
+		return ""
+	}
+	format, _ := w.cols[colIdx].Validation["format"].(string)
+	return format
+}
+
+func (w *XLSXWriter) fitWidth(colIdx int, str string) {
+	for len(w.widths) <= colIdx {
+		w.widths = append(w.widths, 0)
+	}
+	if n := utf8.RuneCountInString(str); n > w.widths[colIdx] {
+		w.widths[colIdx] = n
+	}
+}
+
 func (w *XLSXWriter) axis(colIDx int) string {
 	return ColIndexToLetters(colIDx) + strconv.Itoa(w.rowsWritten+1)
 }
@@ -223,10 +342,105 @@ func (w *XLSXWriter) axis(colIDx int) string {
 // Close finalizes the writer, indicating no more records
 // will be written
 func (w *XLSXWriter) Close() error {
+	if err := w.applyFormatting(); err != nil {
+		return err
+	}
 	_, err := w.f.WriteTo(w.w)
 	return err
 }
 
+const (
+	xlsxMinColWidth = 8
+	xlsxMaxColWidth = 80
+)
+
+// applyFormatting sets header row, column width, and number format styles.
+// styles are applied once all rows are written so they can cover complete
+// column ranges
+func (w *XLSXWriter) applyFormatting() error {
+	headerRow := strconv.Itoa(w.headRows)
+	lastCol := ColIndexToLetters(len(w.cols) - 1)
+
+	if w.opts.HeaderRow && w.opts.BoldHeader && len(w.cols) > 0 {
+		style, err := w.f.NewStyle(`{"font":{"bold":true}}`)
+		if err != nil {
+			return err
+		}
+		w.f.SetCellStyle(w.sheetName, "A"+headerRow, lastCol+headerRow, style)
+	}
+
+	if w.opts.HeaderRow && w.opts.FreezeHeader {
+		panes, err := json.Marshal(map[string]interface{}{
+			"freeze":        true,
+			"split":         false,
+			"x_split":       0,
+			"y_split":       w.headRows,
+			"top_left_cell": fmt.Sprintf("A%d", w.headRows+1),
+			"active_pane":   "bottomLeft",
+		})
+		if err != nil {
+			return err
+		}
+		w.f.SetPanes(w.sheetName, string(panes))
+	}
+
+	if w.rowsWritten > w.headRows {
+		first, last := strconv.Itoa(w.headRows+1), strconv.Itoa(w.rowsWritten)
+		for i, typ := range w.types {
+			code, ok := w.opts.NumberFormats[w.columnFormat(i)]
+			if !ok {
+				if code, ok = w.opts.NumberFormats[typ]; !ok {
+					continue
+				}
+			}
+			style, err := w.f.NewStyle(fmt.Sprintf(`{"custom_number_format":%q}`, code))
+			if err != nil {
+				return err
+			}
+			col := ColIndexToLetters(i)
+			w.f.SetCellStyle(w.sheetName, col+first, col+last, style)
+		}
+	}
+
+	if w.opts.AutoColumnWidth {
+		for i, n := range w.widths {
+			width := n + 2
+			if width < xlsxMinColWidth {
+				width = xlsxMinColWidth
+			} else if width > xlsxMaxColWidth {
+				width = xlsxMaxColWidth
+			}
+			col := ColIndexToLetters(i)
+			w.f.SetColWidth(w.sheetName, col, col, float64(width))
+		}
+	}
+
+	return nil
+}
+
+// excelEpoch is the zero time for excel date serial numbers. excel
+// (mistakenly) treats 1900 as a leap year, offsetting the epoch by one day
+// for all dates after february 1900
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+func parseXLSXDate(str string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", str)
+}
+
+// formatXLSXDate converts an excel date serial number to an ISO 8601 string
+func formatXLSXDate(serial float64, format string) string {
+	t := excelEpoch.Add(time.Duration(math.Round(serial*24*60*60*1000)) * time.Millisecond)
+	if format == "date" {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
 func encodeStrings(vs []interface{}) (strs []string, err error) {
 	strs = make([]string, len(vs))
 	for i, v := range vs {
@@ -0,0 +1,386 @@
+package dsio
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset/archive"
+)
+
+// xlsxWorkbook provides streaming access to the worksheets of an xlsx file.
+// Only the shared strings table is held in memory, sheets are decoded one
+// row at a time directly from the compressed archive
+type xlsxWorkbook struct {
+	zr     *zip.Reader
+	sheets []xlsxSheetRef
+	sst    []string
+	close  func() error
+}
+
+// xlsxSheetRef connects a sheet name to it's path within the archive
+type xlsxSheetRef struct {
+	Name string
+	Path string
+}
+
+// openXLSXWorkbook reads workbook metadata & the shared strings table from
+// an xlsx archive. zip archives require random access, so readers that aren't
+// an io.ReaderAt are spooled to a temporary file instead of memory. Callers
+// must Close the returned workbook
+func openXLSXWorkbook(r io.Reader) (*xlsxWorkbook, error) {
+	ra, size, close, err := archive.ReaderAt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	wb := &xlsxWorkbook{close: close}
+	if wb.zr, err = zip.NewReader(ra, size); err != nil {
+		wb.Close()
+		return nil, fmt.Errorf("opening xlsx archive: %w", err)
+	}
+	if err = wb.readSheetRefs(); err != nil {
+		wb.Close()
+		return nil, err
+	}
+	if err = wb.readSharedStrings(); err != nil {
+		wb.Close()
+		return nil, err
+	}
+	return wb, nil
+}
+
+// Close releases any resources held by the workbook
+func (wb *xlsxWorkbook) Close() error {
+	if wb.close != nil {
+		return wb.close()
+	}
+	return nil
+}
+
+func (wb *xlsxWorkbook) open(name string) (io.ReadCloser, error) {
+	for _, f := range wb.zr.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func (wb *xlsxWorkbook) readSheetRefs() error {
+	rels := map[string]string{}
+	if f, err := wb.open("xl/_rels/workbook.xml.rels"); err == nil {
+		doc := struct {
+			Relationships []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}{}
+		err = newXMLDecoder(f).Decode(&doc)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading xlsx workbook relationships: %w", err)
+		}
+		for _, rel := range doc.Relationships {
+			if strings.HasPrefix(rel.Target, "/") {
+				rels[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				rels[rel.ID] = path.Join("xl", rel.Target)
+			}
+		}
+	}
+
+	f, err := wb.open("xl/workbook.xml")
+	if err != nil {
+		return fmt.Errorf("xlsx archive is missing a workbook")
+	}
+	defer f.Close()
+
+	doc := struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}{}
+	if err := newXMLDecoder(f).Decode(&doc); err != nil {
+		return fmt.Errorf("reading xlsx workbook: %w", err)
+	}
+
+	for i, sh := range doc.Sheets {
+		p, ok := rels[sh.ID]
+		if !ok {
+			p = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		wb.sheets = append(wb.sheets, xlsxSheetRef{Name: sh.Name, Path: p})
+	}
+	return nil
+}
+
+func (wb *xlsxWorkbook) readSharedStrings() error {
+	f, err := wb.open("xl/sharedStrings.xml")
+	if err != nil {
+		// workbooks without any string cells may omit the shared strings table
+		return nil
+	}
+	defer f.Close()
+
+	var (
+		dec      = newXMLDecoder(f)
+		buf      strings.Builder
+		inText   bool
+		phonetic int
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading xlsx shared strings: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				buf.Reset()
+			case "t":
+				inText = phonetic == 0
+			case "rPh":
+				// skip phonetic hints, they aren't part of the cell value
+				phonetic++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				wb.sst = append(wb.sst, buf.String())
+			case "t":
+				inText = false
+			case "rPh":
+				phonetic--
+			}
+		case xml.CharData:
+			if inText {
+				buf.Write(t)
+			}
+		}
+	}
+}
+
+// SheetNames lists the names of all sheets in the workbook, in order
+func (wb *xlsxWorkbook) SheetNames() []string {
+	names := make([]string, len(wb.sheets))
+	for i, sh := range wb.sheets {
+		names[i] = sh.Name
+	}
+	return names
+}
+
+// Sheet opens a row iterator for a named sheet. An empty name selects
+// "Sheet1", falling back to the first sheet in the workbook
+func (wb *xlsxWorkbook) Sheet(name string) (*xlsxSheetRows, error) {
+	var ref *xlsxSheetRef
+	for i, sh := range wb.sheets {
+		if sh.Name == name || (name == "" && sh.Name == "Sheet1") {
+			ref = &wb.sheets[i]
+			break
+		}
+	}
+	if ref == nil && name == "" && len(wb.sheets) > 0 {
+		ref = &wb.sheets[0]
+	}
+	if ref == nil {
+		return nil, fmt.Errorf("xlsx sheet %q does not exist", name)
+	}
+
+	f, err := wb.open(ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("opening xlsx sheet %q: %w", ref.Name, err)
+	}
+
+	return &xlsxSheetRows{
+		name: ref.Name,
+		f:    f,
+		dec:  newXMLDecoder(f),
+		sst:  wb.sst,
+	}, nil
+}
+
+// xlsxSheetRows iterates the rows of a worksheet. Rows that are absent from
+// the sheet XML (entirely empty rows) are skipped
+type xlsxSheetRows struct {
+	name string
+	f    io.ReadCloser
+	dec  *xml.Decoder
+	sst  []string
+	// row number of the most recently read row, starting at 1
+	rowNum int
+	// type attributes of the cells of the most recently read row
+	types []string
+}
+
+// Next reads the next row of cell values, returning io.EOF when the sheet has
+// no more rows
+func (sr *xlsxSheetRows) Next() ([]string, error) {
+	for {
+		tok, err := sr.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if el, ok := tok.(xml.StartElement); ok && el.Name.Local == "row" {
+			sr.rowNum++
+			if r := xmlAttr(el, "r"); r != "" {
+				if n, err := strconv.Atoi(r); err == nil {
+					sr.rowNum = n
+				}
+			}
+			return sr.readRow()
+		}
+	}
+}
+
+func (sr *xlsxSheetRows) readRow() ([]string, error) {
+	sr.types = sr.types[:0]
+	var (
+		row   []string
+		col   = -1
+		typ   string
+		value strings.Builder
+		text  bool
+	)
+
+	for {
+		tok, err := sr.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "c":
+				col++
+				if ref := xmlAttr(t, "r"); ref != "" {
+					idx, err := cellRefColIndex(ref)
+					if err != nil {
+						return nil, err
+					}
+					col = idx
+				}
+				if col >= xlsxMaxColumns {
+					return nil, fmt.Errorf("xlsx row %d has more than %d columns", sr.rowNum, xlsxMaxColumns)
+				}
+				typ = xmlAttr(t, "t")
+				value.Reset()
+			case "v", "t":
+				text = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "c":
+				for len(row) <= col {
+					row = append(row, "")
+					sr.types = append(sr.types, "")
+				}
+				row[col] = sr.cellValue(typ, value.String())
+				sr.types[col] = typ
+			case "v", "t":
+				text = false
+			case "row":
+				return row, nil
+			}
+		case xml.CharData:
+			if text {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// cellValue converts raw cell XML text into a string value according to the
+// cell's type attribute
+func (sr *xlsxSheetRows) cellValue(typ, raw string) string {
+	switch typ {
+	case "s":
+		idx, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || idx < 0 || idx >= len(sr.sst) {
+			return raw
+		}
+		return sr.sst[idx]
+	case "b":
+		switch raw {
+		case "1":
+			return "true"
+		case "0":
+			return "false"
+		}
+	}
+	return raw
+}
+
+// numeric reports if a cell of the most recently read row holds a number.
+// cells without a type attribute are numbers
+func (sr *xlsxSheetRows) numeric(col int) bool {
+	if col >= len(sr.types) {
+		return false
+	}
+	return sr.types[col] == "" || sr.types[col] == "n"
+}
+
+// Close finalizes the iterator
+func (sr *xlsxSheetRows) Close() error {
+	return sr.f.Close()
+}
+
+func xmlAttr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// xlsxMaxColumns is the number of columns excel sheets can have, the last
+// column is "XFD"
+const xlsxMaxColumns = 16384
+
+// cellRefColIndex converts the column portion of a cell reference like "AB12"
+// to a zero-based column index. It's the inverse of ColIndexToLetters.
+// References past the last column excel allows are invalid
+func cellRefColIndex(ref string) (int, error) {
+	idx := 0
+	n := 0
+	for _, r := range ref {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		idx = idx*26 + int(r-'A'+1)
+		n++
+		if idx > xlsxMaxColumns {
+			return 0, fmt.Errorf("invalid cell reference: %q is past the last column", ref)
+		}
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("invalid cell reference: %q", ref)
+	}
+	return idx - 1, nil
+}
+
+// newXMLDecoder creates a decoder for XML documents in any supported
+// character encoding
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(newXMLReader(r))
+	dec.CharsetReader = xmlCharsetReader
+	return dec
+}
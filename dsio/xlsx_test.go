@@ -2,9 +2,15 @@ package dsio
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dstest"
 )
@@ -150,3 +156,265 @@ func BenchmarkXLSXReader(b *testing.B) {
 		}
 	}
 }
+
+func TestXLSXReaderStreaming(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "xlsx",
+		FormatConfig: map[string]interface{}{"sheetName": "data"},
+		Schema:       xlsxStruct.Schema,
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewXLSXWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := w.WriteEntry(Entry{Value: []interface{}{"a", float64(i) + 0.5, i, true}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	readers := map[string]io.Reader{
+		"seekable":   bytes.NewReader(data),
+		"unseekable": bytes.NewBuffer(data),
+	}
+	for name, src := range readers {
+		r, err := NewXLSXReader(st, src)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		count := 0
+		for {
+			ent, err := r.ReadEntry()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				t.Fatalf("%s: unexpected error: %s", name, err)
+			}
+			expect := []interface{}{"a", float64(count) + 0.5, int64(count), true}
+			if diff := cmp.Diff(expect, ent.Value); diff != "" {
+				t.Errorf("%s: entry %d mismatch (-want +got):\n%s", name, count, diff)
+			}
+			count++
+		}
+		if count != 100 {
+			t.Errorf("%s: expected %d rows, got: %d", name, 100, count)
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("%s: closing reader: %s", name, err)
+		}
+	}
+
+	missing := &dataset.Structure{
+		Format:       "xlsx",
+		FormatConfig: map[string]interface{}{"sheetName": "nope"},
+		Schema:       xlsxStruct.Schema,
+	}
+	if _, err := NewXLSXReader(missing, bytes.NewReader(data)); err == nil {
+		t.Error("expected reading a missing sheet to error")
+	}
+}
+
+func TestCellRefColIndex(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		got, err := cellRefColIndex(ColIndexToLetters(i) + "12")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != i {
+			t.Errorf("expected %s to map to %d, got: %d", ColIndexToLetters(i), i, got)
+		}
+	}
+	if _, err := cellRefColIndex("12"); err == nil {
+		t.Error("expected reference without a column to error")
+	}
+	if got, err := cellRefColIndex("XFD1"); err != nil || got != xlsxMaxColumns-1 {
+		t.Errorf("expected XFD to map to %d, got: %d, %v", xlsxMaxColumns-1, got, err)
+	}
+	for _, ref := range []string{"XFE1", "ZZZZZZZ1", "ZZZZZZZZZZZZZZZZ1"} {
+		if _, err := cellRefColIndex(ref); err == nil {
+			t.Errorf("expected reference %q past the last column to error", ref)
+		}
+	}
+}
+
+func TestXLSXSheetRowsColumnLimit(t *testing.T) {
+	sheet := `<worksheet><sheetData><row r="1"><c r="ZZZZZZZ1"><v>1</v></c></row></sheetData></worksheet>`
+	sr := &xlsxSheetRows{dec: newXMLDecoder(strings.NewReader(sheet))}
+	if _, err := sr.Next(); err == nil {
+		t.Error("expected a cell past the last column to error")
+	}
+}
+
+func TestXLSXWriterFormatting(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "xlsx",
+		FormatConfig: map[string]interface{}{
+			"headerRow":       true,
+			"boldHeader":      true,
+			"freezeHeader":    true,
+			"autoColumnWidth": true,
+			"numberFormats": map[string]interface{}{
+				"number": "#,##0.00",
+				"date":   "yyyy-mm-dd",
+			},
+			"description": "Fruit: prices by day",
+		},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "name", "type": "string"},
+					map[string]interface{}{"title": "price", "type": "number"},
+					map[string]interface{}{"title": "day", "type": "string", "format": "date"},
+				},
+			},
+		},
+	}
+
+	rows := []interface{}{
+		[]interface{}{"apple", 1.5, "2021-01-02"},
+		[]interface{}{"a particularly long name for a fruit", float64(1200), "2021-12-31"},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewXLSXWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteEntry(Entry{Value: row}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wb, err := openXLSXWorkbook(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	f, err := wb.open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheetXML, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{`state="frozen"`, `topLeftCell="A3"`, `<cols>`, `<mergeCell ref="A1:C1"`} {
+		if !strings.Contains(string(sheetXML), expect) {
+			t.Errorf("expected sheet XML to contain %s", expect)
+		}
+	}
+
+	r, err := NewXLSXReader(st, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAllArray(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rows, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestXLSXReaderSkipInitialRows(t *testing.T) {
+	f := excelize.NewFile()
+	for i, row := range [][]interface{}{
+		{"Quarterly report"},
+		{"name", "total"},
+		{"a", 1},
+		{"b", 2},
+	} {
+		f.SetSheetRow("Sheet1", fmt.Sprintf("A%d", i+1), &row)
+	}
+	buf := &bytes.Buffer{}
+	if err := f.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	st := &dataset.Structure{
+		Format:       "xlsx",
+		FormatConfig: map[string]interface{}{"skipInitialRows": 1, "headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "name", "type": "string"},
+					map[string]interface{}{"title": "total", "type": "integer"},
+				},
+			},
+		},
+	}
+	r, err := NewXLSXReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		[]interface{}{"a", int64(1)},
+		[]interface{}{"b", int64(2)},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestXLSXReaderDateText(t *testing.T) {
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", 44197)
+	f.SetCellStr("Sheet1", "A2", "44198")
+	buf := &bytes.Buffer{}
+	if err := f.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	st := &dataset.Structure{
+		Format: "xlsx",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "day", "type": "string", "format": "date"},
+				},
+			},
+		},
+	}
+	r, err := NewXLSXReader(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only numeric cells hold date serials, text that looks like a number is
+	// read as-is
+	expect := []interface{}{
+		[]interface{}{"2021-01-01"},
+		[]interface{}{"44198"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -4,6 +4,7 @@ package dsstats
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/axiomhq/hyperloglog"
 	topk "github.com/dgryski/go-topk"
@@ -11,6 +12,8 @@ import (
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/dataset/dsstats/histosketch"
+	"github.com/qri-io/dataset/tabular"
+	"github.com/qri-io/dataset/vals"
 )
 
 var (
@@ -142,6 +145,9 @@ func (r *Accumulator) Structure() *dataset.Structure {
 func (r *Accumulator) WriteEntry(ent dsio.Entry) error {
 	if r.stats == nil {
 		r.stats = newAccumulator(ent.Value)
+		if acc, ok := r.stats.(*arrayAcc); ok {
+			acc.formats = columnFormats(r.st)
+		}
 	}
 	r.stats.Write(ent)
 	return nil
@@ -241,6 +247,8 @@ func (acc *objectAcc) Close() {
 
 type arrayAcc struct {
 	children []accumulator
+	// formats are string formats of tabular columns
+	formats []columnFormat
 }
 
 var (
@@ -265,13 +273,30 @@ func (acc *arrayAcc) Write(e dsio.Entry) {
 	if arrayEntry, ok := e.Value.([]interface{}); ok {
 		for i, val := range arrayEntry {
 			if len(acc.children) == i {
-				acc.children = append(acc.children, newAccumulator(val))
+				acc.children = append(acc.children, acc.newChild(i, val))
 			}
 			acc.children[i].Write(dsio.Entry{Index: i, Value: val})
 		}
 	}
 }
 
+// newChild creates an accumulator for the value at index i, using any string
+// format the column specifies
+func (acc *arrayAcc) newChild(i int, val interface{}) accumulator {
+	if _, ok := val.(string); ok && i < len(acc.formats) {
+		switch f := acc.formats[i]; f.format {
+		case vals.FormatDate, vals.FormatDateTime, vals.FormatTime:
+			return newTimeAcc(f)
+		case vals.FormatNone:
+		default:
+			sa := newStringAcc()
+			sa.format = f.format
+			return sa
+		}
+	}
+	return newAccumulator(val)
+}
+
 // Map formats stat values as a map
 func (acc *arrayAcc) Map() map[string]interface{} {
 	vals := make([]map[string]interface{}, len(acc.children))
@@ -397,6 +422,7 @@ func (acc *numericAcc) Close() {
 const maxKeyLen = 40
 
 type stringAcc struct {
+	format      vals.Format
 	count       int
 	minLength   int
 	maxLength   int
@@ -455,6 +481,9 @@ func (acc *stringAcc) Map() map[string]interface{} {
 	if acc.unique != 0 {
 		m["unique"] = acc.unique
 	}
+	if acc.format != vals.FormatNone {
+		m["format"] = acc.format.String()
+	}
 	if acc.frequencies != nil {
 		m["frequencies"] = acc.frequencies
 	}
@@ -476,6 +505,113 @@ func (acc *stringAcc) Close() {
 	acc.unique = int(acc.hll.Estimate())
 }
 
+// columnFormat is the string format a tabular column's schema specifies
+type columnFormat struct {
+	format     vals.Format
+	dateLayout string
+}
+
+// columnFormats gives the string formats of a tabular structure's columns
+func columnFormats(st *dataset.Structure) []columnFormat {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	cols, _, err := tabular.ColumnsFromJSONSchema(st.Schema)
+	if err != nil {
+		return nil
+	}
+	formats := make([]columnFormat, len(cols))
+	for i, col := range cols {
+		f, _ := col.Validation["format"].(string)
+		layout, _ := col.Validation["dateLayout"].(string)
+		formats[i] = columnFormat{format: vals.Format(f), dateLayout: layout}
+	}
+	return formats
+}
+
+// timeAcc accumulates stats for string values with a date, date-time or time
+// format
+type timeAcc struct {
+	columnFormat
+	count    int
+	invalid  int
+	min, max time.Time
+}
+
+var _ accumulator = (*timeAcc)(nil)
+
+func newTimeAcc(f columnFormat) *timeAcc {
+	return &timeAcc{columnFormat: f}
+}
+
+// Type indicates this stat accumulator kind, one of "date", "date-time" or
+// "time"
+func (acc *timeAcc) Type() string { return acc.format.String() }
+
+// Write adds an entry to the stat accumulator
+func (acc *timeAcc) Write(e dsio.Entry) {
+	str, ok := e.Value.(string)
+	if !ok {
+		return
+	}
+	var (
+		t   time.Time
+		err error
+	)
+	switch acc.format {
+	case vals.FormatDate:
+		// readers coerce dates to the ISO layout, other layouts are read as-is
+		if t, err = vals.ParseDate(str, ""); err != nil {
+			t, err = vals.ParseDate(str, acc.dateLayout)
+		}
+	case vals.FormatDateTime:
+		t, err = vals.ParseDateTime(str)
+	case vals.FormatTime:
+		t, err = vals.ParseTime(str)
+	}
+	if err != nil {
+		acc.invalid++
+		return
+	}
+
+	if acc.count == 0 || t.Before(acc.min) {
+		acc.min = t
+	}
+	if acc.count == 0 || t.After(acc.max) {
+		acc.max = t
+	}
+	acc.count++
+}
+
+// Map formats stat values as a map
+func (acc *timeAcc) Map() map[string]interface{} {
+	m := map[string]interface{}{"count": acc.count}
+	if acc.invalid > 0 {
+		m["invalid"] = acc.invalid
+	}
+	if acc.count == 0 {
+		return m
+	}
+	m["min"] = acc.formatTime(acc.min)
+	m["max"] = acc.formatTime(acc.max)
+	return m
+}
+
+// formatTime writes a time in the form of the accumulator's format
+func (acc *timeAcc) formatTime(t time.Time) string {
+	switch acc.format {
+	case vals.FormatDate:
+		return t.Format("2006-01-02")
+	case vals.FormatTime:
+		return t.Format("15:04:05.999999999Z07:00")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
+
+// Close finalizes the accumulator
+func (acc *timeAcc) Close() {}
+
 type boolAcc struct {
 	count      int
 	trueCount  int
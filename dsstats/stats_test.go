@@ -527,3 +527,48 @@ func TestLongStringValues(t *testing.T) {
 		t.Errorf("result mismatch (-want +got):%s\n", diff)
 	}
 }
+
+func TestFormatStats(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "born", "type": "string", "format": "date", "dateLayout": "D/M/YYYY"},
+					map[string]interface{}{"title": "alarm", "type": "string", "format": "time"},
+					map[string]interface{}{"title": "email", "type": "string", "format": "email"},
+				},
+			},
+		},
+	}
+	body := "born,alarm,email\n25/12/1990,07:30,a@example.com\n1/2/1985,22:15,b@example.org\nunknown,06:00,c@example.net\n"
+
+	r, err := dsio.NewEntryReader(st, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := CalculateFromEntryReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []map[string]interface{}{
+		{"type": "date", "count": 2, "invalid": 1, "min": "1985-02-01", "max": "1990-12-25"},
+		{"type": "time", "count": 3, "min": "06:00:00Z", "max": "22:15:00Z"},
+		{
+			"type":        "string",
+			"format":      "email",
+			"count":       3,
+			"minLength":   13,
+			"maxLength":   13,
+			"unique":      3,
+			"frequencies": map[string]int{"a@example.com": 1, "b@example.org": 1, "c@example.net": 1},
+		},
+	}
+	if diff := cmp.Diff(expect, got.Stats); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
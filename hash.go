@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"hash"
 
 	"github.com/mr-tron/base58/base58"
 	"github.com/multiformats/go-multihash"
@@ -33,6 +34,12 @@ func HashBytes(data []byte) (hash string, err error) {
 		return
 	}
 
+	return HashSum(h)
+}
+
+// HashSum encodes the current sum of a SHA-256 hash the same way as
+// HashBytes, for checksumming data that's written to h as a stream
+func HashSum(h hash.Hash) (hash string, err error) {
 	mhBuf, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
 	if err != nil {
 		err = fmt.Errorf("error allocating multihash buffer: %s", err.Error())
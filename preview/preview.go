@@ -30,6 +30,9 @@ const (
 	// MaxReadmePreviewBytes determines the maximum amount of bytes a readme
 	// preview can be. three bytes less than 1000 to make room for an elipsis
 	MaxReadmePreviewBytes = 997
+	// BodyBufferMemoryBudget is the number of body bytes held in memory while
+	// creating a preview before spilling to disk
+	BodyBufferMemoryBudget = dsio.DefaultMemoryBudget
 )
 
 // Create generates a preview for a dataset version
@@ -82,7 +85,9 @@ func Create(ctx context.Context, ds *dataset.Dataset) (*dataset.Dataset, error)
 			Schema: ds.Structure.Schema,
 		}
 
-		buf := &bytes.Buffer{}
+		// bytes read while previewing are kept to restore the body file. formats
+		// like XLSX read the entire body, so large bodies spill to disk
+		buf := dsio.NewSpillBuffer(BodyBufferMemoryBudget)
 		f := ds.BodyFile()
 		tr := io.TeeReader(f, buf)
 		teedFile := qfs.NewMemfileReader(f.FullPath(), tr)
@@ -93,12 +98,21 @@ func Create(ctx context.Context, ds *dataset.Dataset) (*dataset.Dataset, error)
 
 		data, err := dsio.ConvertFile(teedFile, ds.Structure, st, MaxNumDatasetRowsInPreview, 0, false)
 		if err != nil {
+			buf.Close()
 			log.Debugw("converting body file", "err", err.Error())
 			return nil, err
 		}
 
+		replay, err := buf.NewReader()
+		// removing the spill file doesn't affect the open replay reader
+		buf.Close()
+		if err != nil {
+			return nil, err
+		}
+
 		ds.Body = json.RawMessage(data)
-		ds.SetBodyFile(qfs.NewMemfileReaderSize(f.FullPath(), io.MultiReader(buf, f), int64(size)))
+		body := replayReader{Reader: io.MultiReader(replay, f), closers: []io.Closer{replay, f}}
+		ds.SetBodyFile(qfs.NewMemfileReaderSize(f.FullPath(), body, int64(size)))
 	}
 
 	// Note: stats can get arbitrarily large, potentially bloating the size
@@ -110,3 +124,20 @@ func Create(ctx context.Context, ds *dataset.Dataset) (*dataset.Dataset, error)
 
 	return ds, nil
 }
+
+// replayReader reads bytes replayed from a buffer followed by the rest of a
+// file. Closing it closes both, replays of spilled buffers hold an open file
+type replayReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes the replay & the file, returning the first error
+func (r replayReader) Close() (err error) {
+	for _, c := range r.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
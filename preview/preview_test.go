@@ -1,9 +1,11 @@
 package preview
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/qri-io/dataset"
@@ -73,3 +75,30 @@ func TestCreate(t *testing.T) {
 		t.Fatalf("unexpected error creating a preview of a dataset without a body: %s", err)
 	}
 }
+
+func TestCreateBodyFile(t *testing.T) {
+	expect, err := ioutil.ReadFile("testdata/earthquakes/body.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc, err := dstest.NewTestCaseFromDir("testdata/earthquakes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Create(context.Background(), tc.Input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := got.BodyFile()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expect, data) {
+		t.Errorf("expected body file to replay the original body")
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("closing body file: %s", err)
+	}
+}
@@ -27,6 +27,11 @@ var (
 // provided in a dataset's structure, and then by the natural comparibilty of
 // the datasets
 type Structure struct {
+	// Archive specifies the archive format the body is a member of, like
+	// "zip" or "tar.gz". if empty the body isn't archived
+	Archive string `json:"archive,omitempty"`
+	// ArchiveMember is the path of the body file within the archive
+	ArchiveMember string `json:"archiveMember,omitempty"`
 	// Checksum is a bas58-encoded multihash checksum of the entire data
 	// file this structure points to. This is different from IPFS
 	// hashes, which are calculated after breaking the file into blocks
@@ -35,6 +40,9 @@ type Structure struct {
 	// Compression specifies any compression on the source data,
 	// if empty assume no compression
 	Compression string `json:"compression,omitempty"`
+	// CompressionConfig holds options for the compression format, like
+	// compression level, zstd dictionaries & gzip header metadata
+	CompressionConfig map[string]interface{} `json:"compressionConfig,omitempty"`
 	// Maximum nesting level of composite types in the dataset.
 	// eg: depth 1 == [], depth 2 == [[]]
 	// derived
@@ -63,6 +71,9 @@ type Structure struct {
 	// location of this structure, transient
 	// derived
 	Path string `json:"path,omitempty"`
+	// PrimaryKey lists the titles of tabular columns whose values together
+	// identify each row, for tools that diff or join rows
+	PrimaryKey []string `json:"primaryKey,omitempty"`
 	// Qri should always be KindStructure
 	// derived
 	Qri string `json:"qri"`
@@ -146,7 +157,10 @@ func (s *Structure) AddFileFormatExtensions(filename string) string {
 // RequiresTabularSchema returns true if the structure's specified data format
 // requires a JSON schema that describes a rectangular data shape
 func (s *Structure) RequiresTabularSchema() bool {
-	return s.Format == CSVDataFormat.String() || s.Format == XLSXDataFormat.String()
+	return s.Format == CSVDataFormat.String() ||
+		s.Format == XLSXDataFormat.String() ||
+		s.Format == ODSDataFormat.String() ||
+		s.Format == FixedWidthDataFormat.String()
 }
 
 // Abstract returns this structure instance in it's "Abstract" form
@@ -209,19 +223,23 @@ func (s Structure) MarshalJSONObject() ([]byte, error) {
 	}
 
 	return json.Marshal(&_structure{
-		Checksum:     s.Checksum,
-		Compression:  s.Compression,
-		Depth:        s.Depth,
-		Encoding:     s.Encoding,
-		Entries:      s.Entries,
-		ErrCount:     s.ErrCount,
-		Format:       s.Format,
-		FormatConfig: opt,
-		Length:       s.Length,
-		Path:         s.Path,
-		Qri:          kind,
-		Schema:       s.Schema,
-		Strict:       s.Strict,
+		Archive:           s.Archive,
+		ArchiveMember:     s.ArchiveMember,
+		Checksum:          s.Checksum,
+		Compression:       s.Compression,
+		CompressionConfig: s.CompressionConfig,
+		Depth:             s.Depth,
+		Encoding:          s.Encoding,
+		Entries:           s.Entries,
+		ErrCount:          s.ErrCount,
+		Format:            s.Format,
+		FormatConfig:      opt,
+		Length:            s.Length,
+		Path:              s.Path,
+		PrimaryKey:        s.PrimaryKey,
+		Qri:               kind,
+		Schema:            s.Schema,
+		Strict:            s.Strict,
 	})
 }
 
@@ -245,8 +263,11 @@ func (s *Structure) UnmarshalJSON(data []byte) (err error) {
 
 // IsEmpty checks to see if structure has any fields other than the internal path
 func (s *Structure) IsEmpty() bool {
-	return s.Checksum == "" &&
+	return s.Archive == "" &&
+		s.ArchiveMember == "" &&
+		s.Checksum == "" &&
 		s.Compression == "" &&
+		s.CompressionConfig == nil &&
 		s.Depth == 0 &&
 		s.Encoding == "" &&
 		s.Entries == 0 &&
@@ -254,6 +275,7 @@ func (s *Structure) IsEmpty() bool {
 		s.Format == "" &&
 		s.FormatConfig == nil &&
 		s.Length == 0 &&
+		s.PrimaryKey == nil &&
 		s.Schema == nil &&
 		!s.Strict
 }
@@ -269,12 +291,21 @@ func (s *Structure) Assign(structures ...*Structure) {
 		if st.Path != "" {
 			s.Path = st.Path
 		}
+		if st.Archive != "" {
+			s.Archive = st.Archive
+		}
+		if st.ArchiveMember != "" {
+			s.ArchiveMember = st.ArchiveMember
+		}
 		if st.Checksum != "" {
 			s.Checksum = st.Checksum
 		}
 		if st.Compression != "" {
 			s.Compression = st.Compression
 		}
+		if st.CompressionConfig != nil {
+			s.CompressionConfig = st.CompressionConfig
+		}
 		if st.Depth != 0 {
 			s.Depth = st.Depth
 		}
@@ -299,6 +330,9 @@ func (s *Structure) Assign(structures ...*Structure) {
 		if st.Length != 0 {
 			s.Length = st.Length
 		}
+		if st.PrimaryKey != nil {
+			s.PrimaryKey = st.PrimaryKey
+		}
 		// TODO - fix me
 		if st.Schema != nil {
 			// if s.Schema == nil {
@@ -102,8 +102,10 @@ func TestStructureBodyFilename(t *testing.T) {
 
 func TestStructureRequiresTabularSchema(t *testing.T) {
 	tabularFormats := map[string]struct{}{
-		CSVDataFormat.String():  struct{}{},
-		XLSXDataFormat.String(): struct{}{},
+		CSVDataFormat.String():        struct{}{},
+		XLSXDataFormat.String():       struct{}{},
+		ODSDataFormat.String():        struct{}{},
+		FixedWidthDataFormat.String(): struct{}{},
 	}
 
 	for _, f := range SupportedDataFormats() {
@@ -135,8 +137,11 @@ func TestStructureIsEmpty(t *testing.T) {
 	cases := []struct {
 		st *Structure
 	}{
+		{&Structure{Archive: "zip"}},
+		{&Structure{ArchiveMember: "data.csv"}},
 		{&Structure{Checksum: "a"}},
 		{&Structure{Compression: compression.FmtZStandard.String()}},
+		{&Structure{CompressionConfig: map[string]interface{}{}}},
 		{&Structure{Depth: 1}},
 		{&Structure{Encoding: "a"}},
 		{&Structure{Entries: 1}},
@@ -144,6 +149,7 @@ func TestStructureIsEmpty(t *testing.T) {
 		{&Structure{Format: "csv"}},
 		{&Structure{FormatConfig: map[string]interface{}{}}},
 		{&Structure{Length: 1}},
+		{&Structure{PrimaryKey: []string{"id"}}},
 		{&Structure{Schema: map[string]interface{}{}}},
 		{&Structure{Strict: true}},
 	}
@@ -158,15 +164,19 @@ func TestStructureIsEmpty(t *testing.T) {
 
 func TestStructureAssign(t *testing.T) {
 	expect := &Structure{
-		Length:      2503,
-		Checksum:    "hey",
-		Compression: compression.FmtZStandard.String(),
-		Depth:       11,
-		ErrCount:    12,
-		Encoding:    "UTF-8",
-		Entries:     3000000000,
-		Format:      "csv",
-		Strict:      true,
+		Archive:           "zip",
+		ArchiveMember:     "data/body.csv",
+		Length:            2503,
+		Checksum:          "hey",
+		Compression:       compression.FmtZStandard.String(),
+		CompressionConfig: map[string]interface{}{"level": 3},
+		Depth:             11,
+		ErrCount:          12,
+		Encoding:          "UTF-8",
+		Entries:           3000000000,
+		Format:            "csv",
+		PrimaryKey:        []string{"id"},
+		Strict:            true,
 	}
 	got := &Structure{
 		Length: 2000,
@@ -174,15 +184,19 @@ func TestStructureAssign(t *testing.T) {
 	}
 
 	got.Assign(&Structure{
-		Length:      2503,
-		Checksum:    "hey",
-		Compression: compression.FmtZStandard.String(),
-		Depth:       11,
-		ErrCount:    12,
-		Encoding:    "UTF-8",
-		Entries:     3000000000,
-		Format:      "csv",
-		Strict:      true,
+		Archive:           "zip",
+		ArchiveMember:     "data/body.csv",
+		Length:            2503,
+		Checksum:          "hey",
+		Compression:       compression.FmtZStandard.String(),
+		CompressionConfig: map[string]interface{}{"level": 3},
+		Depth:             11,
+		ErrCount:          12,
+		Encoding:          "UTF-8",
+		Entries:           3000000000,
+		Format:            "csv",
+		PrimaryKey:        []string{"id"},
+		Strict:            true,
 	})
 
 	if diff := compareStructures(expect, got); diff != "" {
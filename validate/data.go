@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
@@ -12,29 +14,143 @@ import (
 
 const batchSize = 5000
 
-func flushBatch(ctx context.Context, buf *dsio.EntryBuffer, st *dataset.Structure, jsch *jsonschema.Schema, errs *[]jsonschema.KeyError) error {
-	if len(buf.Bytes()) == 0 {
+// EntryError is a validation error for a single entry, located in the source
+// data when the reader supports it
+type EntryError struct {
+	jsonschema.KeyError
+	// Position locates the invalid value in source data. nil if the reader
+	// doesn't implement dsio.PositionReader
+	Position *dsio.Position
+}
+
+// Error implements the error interface, adding source position to the
+// validation message
+func (e EntryError) Error() string {
+	return e.withPosition().Error()
+}
+
+// withPosition gives a KeyError with the source position included in the
+// message
+func (e EntryError) withPosition() jsonschema.KeyError {
+	ke := e.KeyError
+	if e.Position != nil {
+		ke.Message = fmt.Sprintf("%s at %s", ke.Message, e.Position)
+	}
+	return ke
+}
+
+// entryPositions records the source positions of an entry & it's values
+type entryPositions struct {
+	entry  dsio.Position
+	fields []dsio.Position
+}
+
+// batch accumulates entries for validation
+type batch struct {
+	buf *dsio.EntryBuffer
+	// index of the first entry in the batch
+	start int
+	// source positions of each entry in the batch, keyed by the entry's
+	// property path segment: an index within the batch for arrays, a key for
+	// objects
+	positions map[string]entryPositions
+}
+
+func newBatch(st *dataset.Structure, start int) (*batch, error) {
+	buf, err := dsio.NewEntryBuffer(&dataset.Structure{
+		Format: "json",
+		Schema: st.Schema,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error allocating data buffer: %s", err.Error())
+	}
+	return &batch{buf: buf, start: start, positions: map[string]entryPositions{}}, nil
+}
+
+// recordPosition stores the position of the most recently read entry
+func (b *batch) recordPosition(pr dsio.PositionReader, key string, ent dsio.Entry) {
+	ep := entryPositions{entry: pr.EntryPosition(-1)}
+	if row, ok := ent.Value.([]interface{}); ok {
+		ep.fields = make([]dsio.Position, len(row))
+		for i := range row {
+			ep.fields[i] = pr.EntryPosition(i)
+		}
+	}
+	b.positions[key] = ep
+}
+
+func flushBatch(ctx context.Context, b *batch, tlt string, jsch *jsonschema.Schema, errs *[]EntryError) error {
+	if len(b.buf.Bytes()) == 0 {
 		return nil
 	}
 
-	if e := buf.Close(); e != nil {
+	if e := b.buf.Close(); e != nil {
 		return fmt.Errorf("error closing buffer: %s", e.Error())
 	}
 
 	var doc interface{}
-	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+	if err := json.Unmarshal(b.buf.Bytes(), &doc); err != nil {
 		return fmt.Errorf("error parsing JSON bytes: %s", err.Error())
 	}
 	validationState := jsch.Validate(ctx, doc)
-	*errs = append(*errs, *validationState.Errs...)
+	for _, ke := range *validationState.Errs {
+		*errs = append(*errs, b.locate(ke, tlt))
+	}
 
 	return nil
 }
 
-// EntryReader consumes a reader & returns any validation errors present
+// locate connects a validation error to the source position of the entry it
+// refers to, and rewrites array indexes from batch-relative to absolute
+func (b *batch) locate(ke jsonschema.KeyError, tlt string) EntryError {
+	ee := EntryError{KeyError: ke}
+	segments := strings.Split(strings.TrimPrefix(ke.PropertyPath, "/"), "/")
+	if ke.PropertyPath == "" || len(segments) == 0 {
+		return ee
+	}
+
+	ep, ok := b.positions[segments[0]]
+	if tlt == "array" {
+		if idx, err := strconv.Atoi(segments[0]); err == nil {
+			segments[0] = strconv.Itoa(b.start + idx)
+			ee.PropertyPath = "/" + strings.Join(segments, "/")
+		}
+	}
+	if !ok {
+		return ee
+	}
+
+	pos := ep.entry
+	if len(segments) > 1 {
+		if field, err := strconv.Atoi(segments[1]); err == nil && field >= 0 && field < len(ep.fields) {
+			pos = ep.fields[field]
+		}
+	}
+	ee.Position = &pos
+	return ee
+}
+
+// EntryReader consumes a reader & returns any validation errors present.
+// Errors include the source position of invalid values when the reader
+// supports it
 // TODO - refactor this to wrap a reader & return a struct that gives an
 // error or nil on each entry read.
 func EntryReader(r dsio.EntryReader) ([]jsonschema.KeyError, error) {
+	entErrs, err := EntryErrors(r)
+	if err != nil {
+		return nil, err
+	}
+
+	valErrors := make([]jsonschema.KeyError, len(entErrs))
+	for i, e := range entErrs {
+		valErrors[i] = e.withPosition()
+	}
+	return valErrors, nil
+}
+
+// EntryErrors consumes a reader & returns any validation errors present,
+// with source positions for readers that implement dsio.PositionReader
+func EntryErrors(r dsio.EntryReader) ([]EntryError, error) {
 	ctx := context.Background()
 	st := r.Structure()
 
@@ -42,15 +158,14 @@ func EntryReader(r dsio.EntryReader) ([]jsonschema.KeyError, error) {
 	if err != nil {
 		return nil, err
 	}
+	tlt, _ := st.Schema["type"].(string)
+	pr, _ := r.(dsio.PositionReader)
 
-	valErrors := []jsonschema.KeyError{}
+	valErrors := []EntryError{}
 
-	buf, err := dsio.NewEntryBuffer(&dataset.Structure{
-		Format: "json",
-		Schema: st.Schema,
-	})
+	b, err := newBatch(st, 0)
 	if err != nil {
-		return nil, fmt.Errorf("error allocating data buffer: %s", err.Error())
+		return nil, err
 	}
 
 	err = dsio.EachEntry(r, func(i int, ent dsio.Entry, err error) error {
@@ -59,21 +174,25 @@ func EntryReader(r dsio.EntryReader) ([]jsonschema.KeyError, error) {
 		}
 
 		if i%batchSize == 0 {
-			flushErr := flushBatch(ctx, buf, st, jsch, &valErrors)
+			flushErr := flushBatch(ctx, b, tlt, jsch, &valErrors)
 			if flushErr != nil {
 				return flushErr
 			}
 			var bufErr error
-			buf, bufErr = dsio.NewEntryBuffer(&dataset.Structure{
-				Format: "json",
-				Schema: st.Schema,
-			})
-			if bufErr != nil {
-				return fmt.Errorf("error allocating data buffer: %s", bufErr.Error())
+			if b, bufErr = newBatch(st, i); bufErr != nil {
+				return bufErr
+			}
+		}
+
+		if pr != nil {
+			key := ent.Key
+			if tlt != "object" {
+				key = strconv.Itoa(i - b.start)
 			}
+			b.recordPosition(pr, key, ent)
 		}
 
-		err = buf.WriteEntry(ent)
+		err = b.buf.WriteEntry(ent)
 		if err != nil {
 			return fmt.Errorf("error writing row %d: %s", i, err.Error())
 		}
@@ -85,7 +204,7 @@ func EntryReader(r dsio.EntryReader) ([]jsonschema.KeyError, error) {
 		return nil, fmt.Errorf("error reading values: %s", err.Error())
 	}
 
-	if err := flushBatch(ctx, buf, st, jsch, &valErrors); err != nil {
+	if err := flushBatch(ctx, b, tlt, jsch, &valErrors); err != nil {
 		return nil, err
 	}
 
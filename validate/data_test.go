@@ -2,8 +2,11 @@ package validate
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/dataset/dstest"
 )
@@ -16,8 +19,8 @@ func TestEntryReader(t *testing.T) {
 	}{
 		{"craigslist", "", nil},
 		{"movies", "", []string{
-			`/0/1: "" type should be integer, got string`,
-			`/1/1: "" type should be integer, got string`,
+			`/0/1: "" type should be integer, got string at line 2, column 9 (byte 29)`,
+			`/1/1: "" type should be integer, got string at line 3, column 43 (byte 72)`,
 		}},
 	}
 
@@ -53,3 +56,42 @@ func TestEntryReader(t *testing.T) {
 		}
 	}
 }
+
+func TestEntryErrors(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "ndjson",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "a", "type": "integer"},
+				},
+			},
+		},
+	}
+
+	// place an invalid entry in the second validation batch
+	body := strings.Repeat("[1]\n", batchSize+1) + "[\"two\"]\n"
+	r, err := dsio.NewEntryReader(st, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs, err := EntryErrors(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(errs))
+	}
+
+	expectPath := fmt.Sprintf("/%d/0", batchSize+1)
+	if errs[0].PropertyPath != expectPath {
+		t.Errorf("property path mismatch. expected: %s, got: %s", expectPath, errs[0].PropertyPath)
+	}
+	expectPos := &dsio.Position{Line: batchSize + 2, Column: 2, Offset: int64(4*(batchSize+1) + 1)}
+	if diff := cmp.Diff(expectPos, errs[0].Position); diff != "" {
+		t.Errorf("position mismatch (-want +got):\n%s", diff)
+	}
+}
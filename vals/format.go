@@ -0,0 +1,225 @@
+package vals
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Format is a well-known way of writing a string value. Formats are named
+// after values of the JSON schema "format" keyword
+type Format string
+
+const (
+	// FormatNone is a string without a recognized format
+	FormatNone Format = ""
+	// FormatDate is a calendar date
+	FormatDate Format = "date"
+	// FormatDateTime is a date & time of day
+	FormatDateTime Format = "date-time"
+	// FormatTime is a time of day
+	FormatTime Format = "time"
+	// FormatUUID is a universally unique identifier
+	FormatUUID Format = "uuid"
+	// FormatEmail is an email address
+	FormatEmail Format = "email"
+	// FormatURI is an absolute URL
+	FormatURI Format = "uri"
+)
+
+// String implements the stringer interface
+func (f Format) String() string {
+	return string(f)
+}
+
+// ISODateLayout is the RFC 3339 full-date layout that JSON schema "date"
+// values use
+const ISODateLayout = "YYYY-MM-DD"
+
+// DateLayouts lists recognized ways of writing dates, in order of preference
+// when a value matches more than one. Layouts are patterns of YYYY (year),
+// M (month number), D (day), MMM (abbreviated month name) & MMMM (month name).
+// Month-first dates are preferred to day-first dates when a value could be
+// either
+var DateLayouts = []string{
+	ISODateLayout,
+	"M/D/YYYY",
+	"D/M/YYYY",
+	"D.M.YYYY",
+	"YYYY/M/D",
+	"MMM D, YYYY",
+	"MMMM D, YYYY",
+	"D MMM YYYY",
+	"D MMMM YYYY",
+}
+
+// dateLayouts maps date layout patterns to go time layouts
+var dateLayouts = map[string]string{
+	ISODateLayout:  "2006-01-02",
+	"M/D/YYYY":     "1/2/2006",
+	"D/M/YYYY":     "2/1/2006",
+	"D.M.YYYY":     "2.1.2006",
+	"YYYY/M/D":     "2006/1/2",
+	"MMM D, YYYY":  "Jan 2, 2006",
+	"MMMM D, YYYY": "January 2, 2006",
+	"D MMM YYYY":   "2 Jan 2006",
+	"D MMMM YYYY":  "2 January 2006",
+}
+
+var (
+	// dateTimeLayouts are accepted date-time layouts. values without a UTC
+	// offset are read as UTC
+	dateTimeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
+	}
+	// timeLayouts are accepted time of day layouts
+	timeLayouts = []string{
+		"15:04:05.999999999Z07:00",
+		"15:04:05.999999999",
+		"15:04Z07:00",
+		"15:04",
+	}
+
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailPattern = regexp.MustCompile(`^[^@\s"<>,;:]+@[^@\s"<>,;]+\.[a-zA-Z]{2,}$`)
+)
+
+// ParseFormat examines a string value & determines it's format, returning
+// FormatNone if the value has no recognized format. Dates in any of
+// DateLayouts are FormatDate
+func ParseFormat(value []byte) Format {
+	str := strings.TrimSpace(string(value))
+	if str == "" {
+		return FormatNone
+	}
+	if _, err := ParseDateTime(str); err == nil {
+		return FormatDateTime
+	}
+	if len(MatchDateLayouts(str)) > 0 {
+		return FormatDate
+	}
+	if _, err := ParseTime(str); err == nil {
+		return FormatTime
+	}
+	if uuidPattern.MatchString(str) {
+		return FormatUUID
+	}
+	if emailPattern.MatchString(str) {
+		return FormatEmail
+	}
+	if isURL(str) {
+		return FormatURI
+	}
+	return FormatNone
+}
+
+// MatchDateLayouts gives the DateLayouts a value can be parsed with, in order
+// of preference
+func MatchDateLayouts(str string) (layouts []string) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil
+	}
+	for _, l := range DateLayouts {
+		if _, err := time.Parse(dateLayouts[l], str); err == nil {
+			layouts = append(layouts, l)
+		}
+	}
+	return layouts
+}
+
+// ParseDate reads a date written in a layout from DateLayouts. An empty
+// layout is ISODateLayout
+func ParseDate(str, layout string) (time.Time, error) {
+	if layout == "" {
+		layout = ISODateLayout
+	}
+	goLayout, ok := dateLayouts[layout]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unrecognized date layout: %q", layout)
+	}
+	return time.Parse(goLayout, strings.TrimSpace(str))
+}
+
+// ParseDateTime reads an ISO 8601 date & time. The "T" separator may be a
+// space, and seconds & UTC offset are optional. Values without an offset are
+// read as UTC
+func ParseDateTime(str string) (time.Time, error) {
+	return parseLayouts(dateTimeLayouts, str, "date-time")
+}
+
+// ParseTime reads a time of day like "15:04", "15:04:05" or
+// "15:04:05.123-07:00". Values without an offset are read as UTC
+func ParseTime(str string) (time.Time, error) {
+	return parseLayouts(timeLayouts, str, "time")
+}
+
+// IsRFC3339 reports if a date-time or time value is written in the strict
+// RFC 3339 form that JSON schema validation requires, with seconds & a UTC
+// offset. Values of other formats are never RFC 3339
+func IsRFC3339(f Format, str string) bool {
+	str = strings.ToUpper(strings.TrimSpace(str))
+	switch f {
+	case FormatDateTime:
+	case FormatTime:
+		str = "1970-01-01T" + str
+	default:
+		return false
+	}
+	_, err := time.Parse(time.RFC3339, str)
+	return err == nil
+}
+
+func parseLayouts(layouts []string, str, name string) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	for _, l := range layouts {
+		if t, err := time.Parse(l, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s: %q", name, str)
+}
+
+// CoerceFormat rewrites a date read with a layout from DateLayouts in the
+// RFC 3339 form the JSON schema date format requires. Dates without a layout,
+// date-times & times are returned as-is, values without a UTC offset don't
+// have one. ok is false if a date, date-time or time value can't be read
+func CoerceFormat(f Format, str, dateLayout string) (coerced string, ok bool) {
+	var err error
+	switch f {
+	case FormatDate:
+		var t time.Time
+		if t, err = ParseDate(str, dateLayout); err == nil && dateLayout != "" {
+			return t.Format("2006-01-02"), true
+		}
+	case FormatDateTime:
+		_, err = ParseDateTime(str)
+	case FormatTime:
+		_, err = ParseTime(str)
+	}
+	return str, err == nil
+}
+
+// isURL reports if str is an absolute URL with a network scheme & host
+func isURL(str string) bool {
+	if strings.ContainsAny(str, " \t\n") {
+		return false
+	}
+	u, err := url.Parse(str)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp", "ftps", "s3", "gs":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package vals
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFormat(t *testing.T) {
+	cases := []struct {
+		value  string
+		expect Format
+	}{
+		{"", FormatNone},
+		{"hello", FormatNone},
+		{"2021-06-01", FormatDate},
+		{"6/1/2021", FormatDate},
+		{"25/12/2021", FormatDate},
+		{"25.12.2021", FormatDate},
+		{"Jun 1, 2021", FormatDate},
+		{"1 June 2021", FormatDate},
+		{"2021-06-01T12:30:00Z", FormatDateTime},
+		{"2021-06-01T12:30:00.123-07:00", FormatDateTime},
+		{"2021-06-01 12:30:00", FormatDateTime},
+		{"2021-06-01T12:30", FormatDateTime},
+		{"12:30", FormatTime},
+		{"12:30:45.5+02:00", FormatTime},
+		{"25:30", FormatNone},
+		{"123e4567-e89b-12d3-a456-426614174000", FormatUUID},
+		{"123e4567-e89b-12d3-a456", FormatNone},
+		{"someone@example.com", FormatEmail},
+		{"someone@localhost", FormatNone},
+		{"https://qri.io/docs?a=b", FormatURI},
+		{"ftp://files.example.org/data.csv", FormatURI},
+		{"qri.io/docs", FormatNone},
+		{"mailto:someone@example.com", FormatNone},
+	}
+
+	for _, c := range cases {
+		if got := ParseFormat([]byte(c.value)); got != c.expect {
+			t.Errorf("%q format mismatch. expected: %q, got: %q", c.value, c.expect, got)
+		}
+	}
+}
+
+func TestMatchDateLayouts(t *testing.T) {
+	cases := []struct {
+		value  string
+		expect []string
+	}{
+		{"2021-06-01", []string{ISODateLayout}},
+		{"6/1/2021", []string{"M/D/YYYY", "D/M/YYYY"}},
+		{"12/25/2021", []string{"M/D/YYYY"}},
+		{"25/12/2021", []string{"D/M/YYYY"}},
+		{"2021/6/1", []string{"YYYY/M/D"}},
+		{"Dec 25, 2021", []string{"MMM D, YYYY"}},
+		{"December 25, 2021", []string{"MMMM D, YYYY"}},
+		{"not a date", nil},
+	}
+
+	for _, c := range cases {
+		if diff := cmp.Diff(c.expect, MatchDateLayouts(c.value)); diff != "" {
+			t.Errorf("%q layouts mismatch (-want +got):\n%s", c.value, diff)
+		}
+	}
+}
+
+func TestCoerceFormat(t *testing.T) {
+	cases := []struct {
+		f          Format
+		value      string
+		dateLayout string
+		expect     string
+		ok         bool
+	}{
+		{FormatDate, "2021-06-01", "", "2021-06-01", true},
+		{FormatDate, "6/1/2021", "M/D/YYYY", "2021-06-01", true},
+		{FormatDate, "6/1/2021", "D/M/YYYY", "2021-01-06", true},
+		{FormatDate, "1 June 2021", "D MMMM YYYY", "2021-06-01", true},
+		{FormatDate, "6/1/2021", "", "6/1/2021", false},
+		{FormatDate, "6/1/2021", "YYYY", "6/1/2021", false},
+		{FormatDateTime, "2021-06-01 12:30:00", "", "2021-06-01 12:30:00", true},
+		{FormatDateTime, "2021-06-01T12:30:00.5-07:00", "", "2021-06-01T12:30:00.5-07:00", true},
+		{FormatDateTime, "yesterday", "", "yesterday", false},
+		{FormatTime, "9:05", "", "9:05", true},
+		{FormatTime, "09:05:01+02:00", "", "09:05:01+02:00", true},
+		{FormatEmail, "someone@example.com", "", "someone@example.com", true},
+	}
+
+	for _, c := range cases {
+		got, ok := CoerceFormat(c.f, c.value, c.dateLayout)
+		if got != c.expect || ok != c.ok {
+			t.Errorf("%s %q coercion mismatch. expected: %q, %t. got: %q, %t", c.f, c.value, c.expect, c.ok, got, ok)
+		}
+	}
+}
+
+func TestIsRFC3339(t *testing.T) {
+	cases := []struct {
+		f      Format
+		value  string
+		expect bool
+	}{
+		{FormatDateTime, "2021-06-01T12:30:00Z", true},
+		{FormatDateTime, "2021-06-01T12:30:00.5-07:00", true},
+		{FormatDateTime, "2021-06-01 12:30:00", false},
+		{FormatDateTime, "2021-06-01T12:30Z", false},
+		{FormatTime, "09:05:01+02:00", true},
+		{FormatTime, "09:05:01", false},
+		{FormatTime, "9:05", false},
+		{FormatDate, "2021-06-01", false},
+	}
+
+	for _, c := range cases {
+		if got := IsRFC3339(c.f, c.value); got != c.expect {
+			t.Errorf("%s %q mismatch. expected: %t, got: %t", c.f, c.value, c.expect, got)
+		}
+	}
+}